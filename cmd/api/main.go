@@ -6,17 +6,23 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
-	"strings"
 	"syscall"
 	"time"
+	_ "time/tzdata" // Recurring bookings need IANA time zones, which the runtime image lacks
 
 	"cloudflaredb/internal/config"
 	"cloudflaredb/internal/database"
+	"cloudflaredb/internal/events"
 	"cloudflaredb/internal/handlers"
 	"cloudflaredb/internal/repository"
 )
 
 func main() {
+	// Subcommands: "migrate" manages the schema without starting the server
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(os.Args[2:]))
+	}
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
@@ -44,141 +50,54 @@ func main() {
 	log.Println("Database migrations completed")
 
 	// Initialize repositories
-	userRepo := repository.NewUserRepository(db.DB)
 	roomRepo := repository.NewRoomRepository(db.DB)
+	userRepo := repository.NewUserRepository(db.DB, roomRepo)
+	bookingRepo := repository.NewBookingRepository(db.DB)
+	recurringRepo := repository.NewRecurringBookingRepository(db.DB)
+	invitationRepo := repository.NewInvitationRepository(db.DB, roomRepo)
+	joinRequestRepo := repository.NewJoinRequestRepository(db.DB, roomRepo)
+	locationRepo := repository.NewLocationRepository(db.DB)
+	groupRepo := repository.NewGroupRepository(db.DB, roomRepo)
+
+	// Domain events are logged; further subscribers (notifications, webhooks) hook in here
+	bus := events.NewBus()
+	bus.Subscribe(func(ctx context.Context, e events.Event) {
+		log.Printf("event=%s data=%+v", e.Type, e.Data)
+	})
+	roomRepo.SetEventPublisher(bus)
 
 	// Initialize handlers
 	userHandler := handlers.NewUserHandler(userRepo)
 	roomHandler := handlers.NewRoomHandler(roomRepo)
+	searchHandler := handlers.NewSearchHandler(userRepo, roomRepo)
+	bookingHandler := handlers.NewBookingHandler(bookingRepo)
+	recurringHandler := handlers.NewRecurringBookingHandler(recurringRepo)
+	calendarHandler := handlers.NewCalendarHandler(userRepo, roomRepo)
+	invitationHandler := handlers.NewInvitationHandler(invitationRepo, joinRequestRepo)
+	locationHandler := handlers.NewLocationHandler(locationRepo, roomRepo)
+	groupHandler := handlers.NewGroupHandler(groupRepo)
 
 	// Setup HTTP router
-	mux := http.NewServeMux()
+	api := &handlers.API{
+		Users:       userHandler,
+		Rooms:       roomHandler,
+		Search:      searchHandler,
+		Bookings:    bookingHandler,
+		Recurring:   recurringHandler,
+		Calendars:   calendarHandler,
+		Invitations: invitationHandler,
+		Locations:   locationHandler,
+		Groups:      groupHandler,
+	}
+	router := handlers.NewRouter(api.Routes()...)
 
 	// Serve static files (API testing page)
-	fs := http.FileServer(http.Dir("web/static"))
-	mux.Handle("/", fs)
-
-	// Health check endpoint
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"status":"healthy"}`))
-	})
-
-	// User endpoints
-	mux.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case http.MethodGet:
-			userHandler.ListUsers(w, r)
-		case http.MethodPost:
-			userHandler.CreateUser(w, r)
-		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	})
-
-	mux.HandleFunc("/users/", func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path == "/users/" {
-			http.Error(w, "Not found", http.StatusNotFound)
-			return
-		}
-
-		// Extract ID from path
-		path := strings.TrimPrefix(r.URL.Path, "/users/")
-		parts := strings.Split(path, "/")
-
-		// Check for /users/{id}/rooms
-		if len(parts) >= 2 && parts[1] == "rooms" {
-			roomHandler.GetUserRooms(w, r)
-			return
-		}
-
-		// Regular user endpoints /users/{id}
-		if strings.Contains(path, "/") {
-			http.Error(w, "Not found", http.StatusNotFound)
-			return
-		}
-
-		switch r.Method {
-		case http.MethodGet:
-			userHandler.GetUser(w, r)
-		case http.MethodPut:
-			userHandler.UpdateUser(w, r)
-		case http.MethodDelete:
-			userHandler.DeleteUser(w, r)
-		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	})
-
-	// Room endpoints
-	mux.HandleFunc("/rooms", func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case http.MethodGet:
-			roomHandler.ListRooms(w, r)
-		case http.MethodPost:
-			roomHandler.CreateRoom(w, r)
-		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	})
-
-	mux.HandleFunc("/rooms/", func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path == "/rooms/" {
-			http.Error(w, "Not found", http.StatusNotFound)
-			return
-		}
-
-		// Extract path parts
-		path := strings.TrimPrefix(r.URL.Path, "/rooms/")
-		parts := strings.Split(path, "/")
-
-		// /rooms/{id}/users - Get users in room or assign user to room
-		if len(parts) >= 2 && parts[1] == "users" {
-			if len(parts) == 2 {
-				// /rooms/{id}/users
-				switch r.Method {
-				case http.MethodGet:
-					roomHandler.GetRoomUsers(w, r)
-				case http.MethodPost:
-					roomHandler.AssignUserToRoom(w, r)
-				default:
-					http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-				}
-			} else if len(parts) == 3 {
-				// /rooms/{id}/users/{userId}
-				if r.Method == http.MethodDelete {
-					roomHandler.RemoveUserFromRoom(w, r)
-				} else {
-					http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-				}
-			} else {
-				http.Error(w, "Not found", http.StatusNotFound)
-			}
-			return
-		}
-
-		// Regular room endpoints /rooms/{id}
-		if strings.Contains(path, "/") {
-			http.Error(w, "Not found", http.StatusNotFound)
-			return
-		}
-
-		switch r.Method {
-		case http.MethodGet:
-			roomHandler.GetRoom(w, r)
-		case http.MethodPut:
-			roomHandler.UpdateRoom(w, r)
-		case http.MethodDelete:
-			roomHandler.DeleteRoom(w, r)
-		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	})
+	handleStatic(router, "web/static")
 
 	// Create server
 	srv := &http.Server{
 		Addr:         ":" + cfg.Port,
-		Handler:      loggingMiddleware(mux),
+		Handler:      handlers.RequestID(loggingMiddleware(router)),
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
@@ -212,11 +131,19 @@ func main() {
 	log.Println("Server stopped")
 }
 
+// handleStatic serves the files in dir under /static/, and its index.html, the API testing page, at /.
+// The page is registered at /{$} rather than on the / subtree, so unknown paths still get 404 and 405 problems.
+func handleStatic(router *handlers.Router, dir string) {
+	fs := http.FileServer(http.Dir(dir))
+	router.Handle(handlers.Route{Method: http.MethodGet, Pattern: "/{$}", Summary: "API testing page", Handler: fs.ServeHTTP})
+	router.Handle(handlers.Route{Method: http.MethodGet, Pattern: "/static/", Summary: "Static files", Handler: http.StripPrefix("/static", fs).ServeHTTP})
+}
+
 // loggingMiddleware logs HTTP requests
 func loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 		next.ServeHTTP(w, r)
-		log.Printf("%s %s %s", r.Method, r.RequestURI, time.Since(start))
+		log.Printf("request_id=%s %s %s %s", handlers.RequestIDFromContext(r.Context()), r.Method, r.RequestURI, time.Since(start))
 	})
 }
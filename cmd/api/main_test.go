@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cloudflaredb/internal/handlers"
+)
+
+func TestHandleStatic(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "index.html"), []byte("<h1>tester</h1>"), 0o644); err != nil {
+		t.Fatalf("Failed to write index.html: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "app.js"), []byte("console.log('ok')"), 0o644); err != nil {
+		t.Fatalf("Failed to write app.js: %v", err)
+	}
+
+	router := handlers.NewRouter()
+	handleStatic(router, dir)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   int
+		body   string
+	}{
+		{name: "testing page", method: http.MethodGet, path: "/", want: http.StatusOK, body: "<h1>tester</h1>"},
+		{name: "static file", method: http.MethodGet, path: "/static/app.js", want: http.StatusOK, body: "console.log('ok')"},
+		{name: "missing static file", method: http.MethodGet, path: "/static/missing.css", want: http.StatusNotFound},
+		{name: "unknown path", method: http.MethodGet, path: "/nowhere", want: http.StatusNotFound},
+		{name: "wrong method", method: http.MethodPost, path: "/static/app.js", want: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if w.Code != tt.want {
+				t.Errorf("Expected status %d, got %d", tt.want, w.Code)
+			}
+			if tt.body != "" && w.Body.String() != tt.body {
+				t.Errorf("Expected body %q, got %q", tt.body, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"cloudflaredb/internal/config"
+	"cloudflaredb/internal/database"
+)
+
+const migrateUsage = `Usage: api migrate <command> [-dry-run] [argument]
+
+Commands:
+  status            Show applied and pending migrations
+  plan [VERSION]    Print the SQL that "up" would execute (same as "up -dry-run")
+  up [VERSION]      Apply pending migrations, up to VERSION if given
+  down [N]          Roll back the last N applied migrations (default 1)
+
+Flags:
+  -dry-run          Print the SQL that would be executed without touching the database
+`
+
+// runMigrate implements the "migrate" subcommand and returns the process exit code
+func runMigrate(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	command := args[0]
+	switch command {
+	case "status", "plan", "up", "down":
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown migrate command: %s\n\n%s", command, migrateUsage)
+		return 2
+	}
+
+	flags := flag.NewFlagSet("migrate "+command, flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, migrateUsage) }
+	dryRun := flags.Bool("dry-run", false, "print the SQL that would be executed without touching the database")
+
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	if flags.NArg() > 1 {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		return 1
+	}
+
+	db, err := database.New(cfg.DatabaseDriver, cfg.DatabaseDSN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to connect to database: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	switch command {
+	case "status":
+		err = migrateStatus(ctx, db, os.Stdout)
+	case "plan":
+		err = migrateUp(ctx, db, os.Stdout, flags.Arg(0), true)
+	case "up":
+		err = migrateUp(ctx, db, os.Stdout, flags.Arg(0), *dryRun)
+	case "down":
+		err = migrateDown(ctx, db, os.Stdout, flags.Arg(0), *dryRun)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Migration failed: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+// migrateStatus prints every migration with its applied state
+func migrateStatus(ctx context.Context, db *database.DB, out io.Writer) error {
+	statuses, err := db.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+
+	pending := 0
+	for _, st := range statuses {
+		status := "pending"
+		appliedAt := "-"
+
+		switch {
+		case st.Applied && st.Filename == "":
+			status = "applied (file missing)"
+			appliedAt = st.AppliedAt.Format(time.RFC3339)
+		case st.Applied:
+			status = "applied"
+			appliedAt = st.AppliedAt.Format(time.RFC3339)
+		case len(st.Requires) > 0:
+			status = "pending (requires " + strings.Join(st.Requires, ", ") + ")"
+			pending++
+		default:
+			pending++
+		}
+
+		fmt.Fprintf(tw, "%03d\t%s\t%s\t%s\n", st.Version, st.Name, status, appliedAt)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "\n%d migration(s), %d pending\n", len(statuses), pending)
+	return nil
+}
+
+// migrateUp applies pending migrations up to the optional target version. It never rolls back:
+// a target below the current version is rejected, since only "down" may undo migrations.
+func migrateUp(ctx context.Context, db *database.DB, out io.Writer, arg string, dryRun bool) error {
+	target := database.LatestVersion
+	if arg != "" {
+		v, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil || v < 0 {
+			return fmt.Errorf("invalid version %q", arg)
+		}
+		target = v
+	}
+
+	current, err := currentVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+	if target < current {
+		return fmt.Errorf("target version %03d is below the current version %03d; use \"migrate down\" to roll back", target, current)
+	}
+
+	if dryRun {
+		steps, err := db.PlanMigrateTo(ctx, target)
+		if err != nil {
+			return err
+		}
+		printPlan(out, steps)
+		return nil
+	}
+
+	return db.MigrateTo(ctx, target)
+}
+
+// currentVersion returns the highest applied migration version, or 0 when none is applied
+func currentVersion(ctx context.Context, db *database.DB) (int64, error) {
+	statuses, err := db.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var current int64
+	for _, st := range statuses {
+		if st.Applied && st.Version > current {
+			current = st.Version
+		}
+	}
+	return current, nil
+}
+
+// migrateDown rolls back the given number of migrations (default 1)
+func migrateDown(ctx context.Context, db *database.DB, out io.Writer, arg string, dryRun bool) error {
+	steps := 1
+	if arg != "" {
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid number of steps %q", arg)
+		}
+		steps = n
+	}
+
+	if dryRun {
+		plan, err := db.PlanRollback(ctx, steps)
+		if err != nil {
+			return err
+		}
+		printPlan(out, plan)
+		return nil
+	}
+
+	return db.Rollback(ctx, steps)
+}
+
+// printPlan writes each planned step followed by the SQL it would execute
+func printPlan(out io.Writer, steps []database.MigrationStep) {
+	if len(steps) == 0 {
+		fmt.Fprintln(out, "Nothing to do: database schema is up to date")
+		return
+	}
+
+	for _, step := range steps {
+		fmt.Fprintf(out, "-- [%s] %03d_%s\n", step.Direction, step.Version, step.Name)
+		fmt.Fprintln(out, step.SQL())
+	}
+
+	fmt.Fprintf(out, "-- %d step(s), dry run: nothing was executed\n", len(steps))
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"cloudflaredb/internal/database"
+)
+
+func TestMigrateUp_RejectsTargetBelowCurrent(t *testing.T) {
+	db, err := database.New("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	var out bytes.Buffer
+
+	if err := migrateUp(ctx, db, &out, "2", false); err != nil {
+		t.Fatalf("migrateUp(2) error = %v", err)
+	}
+
+	// Going up to an older version must not roll anything back, with or without -dry-run
+	for _, dryRun := range []bool{true, false} {
+		err := migrateUp(ctx, db, &out, "1", dryRun)
+		if err == nil || !strings.Contains(err.Error(), "below the current version") {
+			t.Errorf("migrateUp(1, dryRun=%v) error = %v, want a target below current error", dryRun, err)
+		}
+	}
+
+	var count int
+	query := "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'rooms'"
+	if err := db.QueryRowContext(ctx, query).Scan(&count); err != nil {
+		t.Fatalf("Failed to query sqlite_master: %v", err)
+	}
+	if count != 1 {
+		t.Error("Expected the rooms table of migration 002 to be kept")
+	}
+
+	// Targets at or above the current version still apply
+	if err := migrateUp(ctx, db, &out, "2", false); err != nil {
+		t.Errorf("migrateUp(2) at version 2 error = %v", err)
+	}
+	if err := migrateUp(ctx, db, &out, "", false); err != nil {
+		t.Errorf("migrateUp(latest) error = %v", err)
+	}
+}
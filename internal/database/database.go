@@ -13,6 +13,8 @@ import (
 // DB wraps the database connection
 type DB struct {
 	*sql.DB
+	// driver is the name of the database/sql driver, e.g. sqlite3 or cfd1
+	driver string
 }
 
 // New creates a new database connection
@@ -36,7 +38,7 @@ func New(driver, dsn string) (*DB, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	return &DB{db}, nil
+	return &DB{DB: db, driver: driver}, nil
 }
 
 // Close closes the database connection
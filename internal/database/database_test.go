@@ -2,7 +2,9 @@ package database
 
 import (
 	"context"
+	"strings"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -103,3 +105,497 @@ func TestDB_Migrate(t *testing.T) {
 		t.Errorf("Migrate() second run error = %v", err)
 	}
 }
+
+func TestDB_MigrateFromFiles(t *testing.T) {
+	db, err := New("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	if err := db.MigrateFromFiles(ctx); err != nil {
+		t.Fatalf("MigrateFromFiles() error = %v", err)
+	}
+
+	migrations, err := loadMigrations(migrationsFS)
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	// Migrations needing a module this SQLite build lacks (e.g. fts5 without the sqlite_fts5 tag) stay pending
+	expected := 0
+	for _, m := range migrations {
+		if hasModules(t, db, m.Requires) {
+			expected++
+		}
+	}
+
+	var count int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM schema_migrations").Scan(&count); err != nil {
+		t.Fatalf("Failed to count applied migrations: %v", err)
+	}
+
+	if count != expected {
+		t.Errorf("Expected %d applied migrations, got %d", expected, count)
+	}
+
+	// Verify tables exist
+	for _, table := range []string{"users", "rooms", "user_rooms"} {
+		var name string
+		query := "SELECT name FROM sqlite_master WHERE type='table' AND name=?"
+		if err := db.QueryRowContext(ctx, query, table).Scan(&name); err != nil {
+			t.Errorf("Expected table %s to exist: %v", table, err)
+		}
+	}
+
+	// Run migrations again (already applied versions must be skipped)
+	if err := db.MigrateFromFiles(ctx); err != nil {
+		t.Errorf("MigrateFromFiles() second run error = %v", err)
+	}
+
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM schema_migrations").Scan(&count); err != nil {
+		t.Fatalf("Failed to count applied migrations: %v", err)
+	}
+
+	if count != expected {
+		t.Errorf("Expected %d applied migrations after second run, got %d", expected, count)
+	}
+}
+
+// hasModules reports whether every SQLite module can be used to create a virtual table
+func hasModules(t *testing.T, db *DB, modules []string) bool {
+	t.Helper()
+
+	for _, module := range modules {
+		if _, err := db.Exec("CREATE VIRTUAL TABLE temp.module_probe USING " + module + "(x)"); err != nil {
+			return false
+		}
+		if _, err := db.Exec("DROP TABLE temp.module_probe"); err != nil {
+			t.Fatalf("Failed to drop probe table: %v", err)
+		}
+	}
+	return true
+}
+
+func TestDB_MigrateSkipsMigrationWithMissingModule(t *testing.T) {
+	db, err := New("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	fsys := fstest.MapFS{
+		"migrations/001_create_items.sql": {Data: []byte("CREATE TABLE items (id INTEGER PRIMARY KEY);")},
+		"migrations/002_create_items_index.sql": {Data: []byte(
+			"-- Requires: nosuchmodule\nCREATE VIRTUAL TABLE items_idx USING nosuchmodule(id);")},
+		"migrations/003_create_tags.sql": {Data: []byte("CREATE TABLE tags (id INTEGER PRIMARY KEY);")},
+	}
+
+	if err := db.migrateTo(ctx, fsys, LatestVersion); err != nil {
+		t.Fatalf("migrate() error = %v", err)
+	}
+
+	applied, err := db.appliedMigrations(ctx)
+	if err != nil {
+		t.Fatalf("appliedMigrations() error = %v", err)
+	}
+
+	if _, ok := applied[2]; ok || len(applied) != 2 {
+		t.Errorf("Expected versions 1 and 3 applied and 2 pending, got %v", applied)
+	}
+
+	// Without the header the same failure is fatal
+	fsys["migrations/002_create_items_index.sql"] = &fstest.MapFile{Data: []byte("CREATE VIRTUAL TABLE items_idx USING nosuchmodule(id);")}
+	if err := db.migrateTo(ctx, fsys, LatestVersion); err == nil {
+		t.Error("Expected an error for a migration with an undeclared missing module")
+	}
+}
+
+func TestDB_MigrateFailureRollsBackMigration(t *testing.T) {
+	db, err := New("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	fsys := fstest.MapFS{
+		"migrations/001_create_items.sql": {Data: []byte("CREATE TABLE items (id INTEGER PRIMARY KEY);")},
+		"migrations/002_create_tags.sql": {Data: []byte(
+			"CREATE TABLE tags (id INTEGER PRIMARY KEY);\nINSERT INTO missing (id) VALUES (1);")},
+	}
+
+	if err := db.migrateTo(ctx, fsys, LatestVersion); err == nil {
+		t.Fatal("Expected an error for a failing migration")
+	}
+
+	// The statements before the failing one are rolled back with it
+	var count int
+	query := "SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name='tags'"
+	if err := db.QueryRowContext(ctx, query).Scan(&count); err != nil {
+		t.Fatalf("Failed to query sqlite_master: %v", err)
+	}
+	if count != 0 {
+		t.Error("Expected the tags table of the failed migration to be rolled back")
+	}
+
+	applied, err := db.appliedMigrations(ctx)
+	if err != nil {
+		t.Fatalf("appliedMigrations() error = %v", err)
+	}
+	if _, ok := applied[2]; ok || len(applied) != 1 {
+		t.Errorf("Expected only version 1 applied, got %v", applied)
+	}
+
+	// Once fixed, the migration applies from scratch
+	fsys["migrations/002_create_tags.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE tags (id INTEGER PRIMARY KEY);")}
+	if err := db.migrateTo(ctx, fsys, LatestVersion); err != nil {
+		t.Errorf("migrateTo() after the fix error = %v", err)
+	}
+}
+
+func TestDB_MigrateSkipsAppliedVersions(t *testing.T) {
+	db, err := New("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	// A migration without IF NOT EXISTS would fail if it ran twice
+	fsys := fstest.MapFS{
+		"migrations/001_create_items.sql": {Data: []byte("CREATE TABLE items (id INTEGER PRIMARY KEY);")},
+	}
+
+	if err := db.migrateTo(ctx, fsys, LatestVersion); err != nil {
+		t.Fatalf("migrate() error = %v", err)
+	}
+
+	fsys["migrations/002_add_items_name.sql"] = &fstest.MapFile{Data: []byte("ALTER TABLE items ADD COLUMN name TEXT;")}
+
+	if err := db.migrateTo(ctx, fsys, LatestVersion); err != nil {
+		t.Fatalf("migrate() second run error = %v", err)
+	}
+
+	applied, err := db.appliedMigrations(ctx)
+	if err != nil {
+		t.Fatalf("appliedMigrations() error = %v", err)
+	}
+
+	if len(applied) != 2 {
+		t.Fatalf("Expected 2 applied migrations, got %d", len(applied))
+	}
+
+	if applied[2].Name != "add_items_name" {
+		t.Errorf("Expected migration 2 name 'add_items_name', got '%s'", applied[2].Name)
+	}
+
+	if applied[2].AppliedAt.IsZero() {
+		t.Error("Expected applied_at to be set")
+	}
+}
+
+func TestDB_MigrateChecksumMismatch(t *testing.T) {
+	db, err := New("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	fsys := fstest.MapFS{
+		"migrations/001_create_items.sql": {Data: []byte("CREATE TABLE items (id INTEGER PRIMARY KEY);")},
+	}
+
+	if err := db.migrateTo(ctx, fsys, LatestVersion); err != nil {
+		t.Fatalf("migrate() error = %v", err)
+	}
+
+	// Edit the already applied migration and add a new one
+	fsys["migrations/001_create_items.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT);")}
+	fsys["migrations/002_create_tags.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE tags (id INTEGER PRIMARY KEY);")}
+
+	err = db.migrateTo(ctx, fsys, LatestVersion)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("Expected checksum mismatch error, got %v", err)
+	}
+
+	// The pending migration must not have been applied
+	var count int
+	query := "SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name='tags'"
+	if err := db.QueryRowContext(ctx, query).Scan(&count); err != nil {
+		t.Fatalf("Failed to query sqlite_master: %v", err)
+	}
+
+	if count != 0 {
+		t.Error("Expected pending migration to be skipped after checksum mismatch")
+	}
+}
+
+func TestDB_MigrateToAndRollback(t *testing.T) {
+	db, err := New("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	fsys := fstest.MapFS{
+		"migrations/001_create_items.up.sql":   {Data: []byte("CREATE TABLE items (id INTEGER PRIMARY KEY);")},
+		"migrations/001_create_items.down.sql": {Data: []byte("DROP TABLE items;")},
+		"migrations/002_create_tags.up.sql":    {Data: []byte("CREATE TABLE tags (id INTEGER PRIMARY KEY);")},
+		"migrations/002_create_tags.down.sql":  {Data: []byte("DROP TABLE tags;")},
+		"migrations/003_create_notes.up.sql":   {Data: []byte("CREATE TABLE notes (id INTEGER PRIMARY KEY);")},
+		"migrations/003_create_notes.down.sql": {Data: []byte("DROP TABLE notes;")},
+	}
+
+	tableExists := func(name string) bool {
+		var count int
+		query := "SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name=?"
+		if err := db.QueryRowContext(ctx, query, name).Scan(&count); err != nil {
+			t.Fatalf("Failed to query sqlite_master: %v", err)
+		}
+		return count == 1
+	}
+
+	// Migrate up to version 2 only
+	if err := db.migrateTo(ctx, fsys, 2); err != nil {
+		t.Fatalf("migrateTo(2) error = %v", err)
+	}
+
+	if !tableExists("items") || !tableExists("tags") || tableExists("notes") {
+		t.Fatal("Expected items and tags tables only after migrating to version 2")
+	}
+
+	// Apply the rest
+	if err := db.migrateTo(ctx, fsys, LatestVersion); err != nil {
+		t.Fatalf("migrateTo(latest) error = %v", err)
+	}
+
+	if !tableExists("notes") {
+		t.Fatal("Expected notes table after migrating to latest")
+	}
+
+	// Roll back the newest two migrations
+	if err := db.rollback(ctx, fsys, 2); err != nil {
+		t.Fatalf("rollback(2) error = %v", err)
+	}
+
+	if !tableExists("items") || tableExists("tags") || tableExists("notes") {
+		t.Fatal("Expected only items table after rolling back two migrations")
+	}
+
+	applied, err := db.appliedMigrations(ctx)
+	if err != nil {
+		t.Fatalf("appliedMigrations() error = %v", err)
+	}
+
+	if len(applied) != 1 {
+		t.Errorf("Expected 1 applied migration after rollback, got %d", len(applied))
+	}
+
+	// Migrating to version 0 rolls back everything
+	if err := db.migrateTo(ctx, fsys, 0); err != nil {
+		t.Fatalf("migrateTo(0) error = %v", err)
+	}
+
+	if tableExists("items") {
+		t.Error("Expected items table to be dropped after migrating to version 0")
+	}
+
+	// Rolling back more than is applied fails
+	if err := db.rollback(ctx, fsys, 1); err == nil {
+		t.Error("Expected error when rolling back with nothing applied")
+	}
+}
+
+func TestDB_RollbackWithoutDownMigration(t *testing.T) {
+	db, err := New("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	fsys := fstest.MapFS{
+		"migrations/001_create_items.up.sql":   {Data: []byte("CREATE TABLE items (id INTEGER PRIMARY KEY);")},
+		"migrations/001_create_items.down.sql": {Data: []byte("DROP TABLE items;")},
+		"migrations/002_create_tags.sql":       {Data: []byte("CREATE TABLE tags (id INTEGER PRIMARY KEY);")},
+	}
+
+	if err := db.migrateTo(ctx, fsys, LatestVersion); err != nil {
+		t.Fatalf("migrateTo() error = %v", err)
+	}
+
+	err = db.rollback(ctx, fsys, 2)
+	if err == nil || !strings.Contains(err.Error(), "no down migration") {
+		t.Fatalf("Expected missing down migration error, got %v", err)
+	}
+
+	// Nothing must have been rolled back
+	applied, err := db.appliedMigrations(ctx)
+	if err != nil {
+		t.Fatalf("appliedMigrations() error = %v", err)
+	}
+
+	if len(applied) != 2 {
+		t.Errorf("Expected 2 applied migrations, got %d", len(applied))
+	}
+}
+
+func TestDB_EmbeddedMigrationsRollback(t *testing.T) {
+	db, err := New("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	if err := db.MigrateFromFiles(ctx); err != nil {
+		t.Fatalf("MigrateFromFiles() error = %v", err)
+	}
+
+	// Every embedded migration must be reversible
+	if err := db.MigrateTo(ctx, 0); err != nil {
+		t.Fatalf("MigrateTo(0) error = %v", err)
+	}
+
+	var count int
+	query := "SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name NOT IN ('schema_migrations', 'sqlite_sequence')"
+	if err := db.QueryRowContext(ctx, query).Scan(&count); err != nil {
+		t.Fatalf("Failed to query sqlite_master: %v", err)
+	}
+
+	if count != 0 {
+		t.Errorf("Expected no application tables after full rollback, got %d", count)
+	}
+
+	// And re-applicable afterwards
+	if err := db.MigrateFromFiles(ctx); err != nil {
+		t.Fatalf("MigrateFromFiles() after rollback error = %v", err)
+	}
+}
+
+func TestDB_StatusAndPlan(t *testing.T) {
+	db, err := New("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	// Planning on a fresh database must not create schema_migrations
+	steps, err := db.PlanMigrateTo(ctx, LatestVersion)
+	if err != nil {
+		t.Fatalf("PlanMigrateTo() error = %v", err)
+	}
+
+	migrations, err := loadMigrations(migrationsFS)
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	if len(steps) != len(migrations) {
+		t.Errorf("Expected %d planned steps, got %d", len(migrations), len(steps))
+	}
+
+	for _, step := range steps {
+		if step.Direction != Up || step.SQL() != step.UpSQL {
+			t.Errorf("Expected up step for %s", step.Filename)
+		}
+	}
+
+	exists, err := db.migrationsTableExists(ctx)
+	if err != nil {
+		t.Fatalf("migrationsTableExists() error = %v", err)
+	}
+	if exists {
+		t.Error("Expected planning to leave the database untouched")
+	}
+
+	statuses, err := db.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+
+	for _, st := range statuses {
+		if st.Applied {
+			t.Errorf("Expected migration %s to be pending", st.Filename)
+		}
+	}
+
+	if err := db.MigrateTo(ctx, 1); err != nil {
+		t.Fatalf("MigrateTo(1) error = %v", err)
+	}
+
+	statuses, err = db.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+
+	if !statuses[0].Applied || statuses[0].AppliedAt.IsZero() {
+		t.Error("Expected first migration to be applied with a timestamp")
+	}
+	if len(statuses) > 1 && statuses[1].Applied {
+		t.Error("Expected second migration to be pending")
+	}
+
+	steps, err = db.PlanRollback(ctx, 1)
+	if err != nil {
+		t.Fatalf("PlanRollback() error = %v", err)
+	}
+
+	if len(steps) != 1 || steps[0].Direction != Down || steps[0].Version != 1 {
+		t.Errorf("Expected a single down step for version 1, got %+v", steps)
+	}
+
+	if steps[0].SQL() != steps[0].DownSQL {
+		t.Error("Expected down step to return the down SQL")
+	}
+}
+
+func TestParseMigrationFilename(t *testing.T) {
+	tests := []struct {
+		filename      string
+		wantVersion   int64
+		wantName      string
+		wantDirection Direction
+		wantErr       bool
+	}{
+		{filename: "001_create_users_table.sql", wantVersion: 1, wantName: "create_users_table", wantDirection: Up},
+		{filename: "001_create_users_table.up.sql", wantVersion: 1, wantName: "create_users_table", wantDirection: Up},
+		{filename: "012_add_index.down.sql", wantVersion: 12, wantName: "add_index", wantDirection: Down},
+		{filename: "create_users.sql", wantErr: true},
+		{filename: "000_zero.sql", wantErr: true},
+		{filename: "001_.up.sql", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			version, name, direction, err := parseMigrationFilename(tt.filename)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseMigrationFilename() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if version != tt.wantVersion || name != tt.wantName || direction != tt.wantDirection {
+				t.Errorf("parseMigrationFilename() = (%d, %s, %s), want (%d, %s, %s)",
+					version, name, direction, tt.wantVersion, tt.wantName, tt.wantDirection)
+			}
+		})
+	}
+}
@@ -2,56 +2,691 @@ package database
 
 import (
 	"context"
+	"crypto/sha256"
+	"database/sql"
 	"embed"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io/fs"
 	"log"
-	"path/filepath"
+	"math"
+	"path"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 //go:embed migrations/*.sql
 var migrationsFS embed.FS
 
-// MigrateFromFiles runs all SQL migrations from the migrations directory
+// schemaMigrationsTable records every migration that has been applied to the database
+const schemaMigrationsTable = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		checksum TEXT NOT NULL,
+		applied_at DATETIME NOT NULL
+	);
+`
+
+// ErrModuleUnavailable is returned when a migration needs an SQLite module the database was built without
+var ErrModuleUnavailable = errors.New("sqlite module unavailable")
+
+// Migration represents a single versioned migration with its optional rollback
+type Migration struct {
+	Version  int64
+	Name     string
+	Filename string
+	UpSQL    string
+	DownSQL  string
+	Checksum string
+	// Requires lists the SQLite modules (e.g. fts5) declared with a "-- Requires:" header.
+	// When one is missing the migration is skipped and stays pending instead of failing.
+	Requires []string
+}
+
+// HasDown reports whether the migration can be rolled back
+func (m Migration) HasDown() bool {
+	return strings.TrimSpace(m.DownSQL) != ""
+}
+
+// AppliedMigration represents a row in the schema_migrations table
+type AppliedMigration struct {
+	Version   int64
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// Direction is the direction in which a migration is executed
+type Direction int
+
+const (
+	// Up applies a migration
+	Up Direction = iota
+	// Down rolls a migration back
+	Down
+)
+
+// String returns the lowercase name of the direction
+func (d Direction) String() string {
+	if d == Down {
+		return "down"
+	}
+	return "up"
+}
+
+// MigrationStep is a migration scheduled to run in a given direction
+type MigrationStep struct {
+	Migration
+	Direction Direction
+}
+
+// SQL returns the statements executed by the step
+func (s MigrationStep) SQL() string {
+	if s.Direction == Down {
+		return s.DownSQL
+	}
+	return s.UpSQL
+}
+
+// MigrationStatus describes an available or applied migration
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// LatestVersion is the MigrateTo target that applies every available migration
+const LatestVersion int64 = math.MaxInt64
+
+// MigrateFromFiles applies all pending SQL migrations from the embedded migrations directory
 func (db *DB) MigrateFromFiles(ctx context.Context) error {
+	return db.migrateTo(ctx, migrationsFS, LatestVersion)
+}
+
+// MigrateTo applies or rolls back migrations until version is the latest applied one.
+// A version of 0 rolls back every migration.
+func (db *DB) MigrateTo(ctx context.Context, version int64) error {
+	if version < 0 {
+		return fmt.Errorf("invalid target version %d", version)
+	}
+	return db.migrateTo(ctx, migrationsFS, version)
+}
+
+// Rollback rolls back the most recently applied migrations, newest first
+func (db *DB) Rollback(ctx context.Context, steps int) error {
+	if steps < 1 {
+		return fmt.Errorf("rollback steps must be at least 1, got %d", steps)
+	}
+	return db.rollback(ctx, migrationsFS, steps)
+}
+
+// Status returns every known migration in version order along with whether it has been applied.
+// It never writes to the database.
+func (db *DB) Status(ctx context.Context) ([]MigrationStatus, error) {
+	migrations, applied, err := db.readState(ctx, migrationsFS)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	known := make(map[int64]bool, len(migrations))
+	for _, m := range migrations {
+		known[m.Version] = true
+		am, ok := applied[m.Version]
+		statuses = append(statuses, MigrationStatus{Migration: m, Applied: ok, AppliedAt: am.AppliedAt})
+	}
+
+	// Applied versions whose files are gone are still reported
+	for version, am := range applied {
+		if !known[version] {
+			statuses = append(statuses, MigrationStatus{
+				Migration: Migration{Version: version, Name: am.Name, Checksum: am.Checksum},
+				Applied:   true,
+				AppliedAt: am.AppliedAt,
+			})
+		}
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+
+	return statuses, nil
+}
+
+// PlanMigrateTo returns the steps MigrateTo would execute for version without running them
+func (db *DB) PlanMigrateTo(ctx context.Context, version int64) ([]MigrationStep, error) {
+	if version < 0 {
+		return nil, fmt.Errorf("invalid target version %d", version)
+	}
+
+	migrations, applied, err := db.readState(ctx, migrationsFS)
+	if err != nil {
+		return nil, err
+	}
+
+	return planMigrateTo(migrations, applied, version)
+}
+
+// PlanRollback returns the steps Rollback would execute without running them
+func (db *DB) PlanRollback(ctx context.Context, steps int) ([]MigrationStep, error) {
+	if steps < 1 {
+		return nil, fmt.Errorf("rollback steps must be at least 1, got %d", steps)
+	}
+
+	migrations, applied, err := db.readState(ctx, migrationsFS)
+	if err != nil {
+		return nil, err
+	}
+
+	return planRollback(migrations, applied, steps)
+}
+
+// migrateTo brings the schema to the target version using the migrations in fsys
+func (db *DB) migrateTo(ctx context.Context, fsys fs.FS, target int64) error {
 	log.Println("Running database migrations from files...")
 
-	// Read all migration files
-	entries, err := fs.ReadDir(migrationsFS, "migrations")
+	migrations, applied, err := db.loadState(ctx, fsys)
 	if err != nil {
-		return fmt.Errorf("failed to read migrations directory: %w", err)
+		return err
 	}
 
-	// Sort migration files by name (which includes version number)
-	var migrationFiles []string
-	for _, entry := range entries {
-		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".sql") {
-			migrationFiles = append(migrationFiles, entry.Name())
+	steps, err := planMigrateTo(migrations, applied, target)
+	if err != nil {
+		return err
+	}
+
+	return db.runSteps(ctx, steps)
+}
+
+// rollback rolls back the last steps applied migrations using the migrations in fsys
+func (db *DB) rollback(ctx context.Context, fsys fs.FS, steps int) error {
+	log.Printf("Rolling back %d migration(s)...", steps)
+
+	migrations, applied, err := db.loadState(ctx, fsys)
+	if err != nil {
+		return err
+	}
+
+	plan, err := planRollback(migrations, applied, steps)
+	if err != nil {
+		return err
+	}
+
+	return db.runSteps(ctx, plan)
+}
+
+// loadState creates the schema_migrations table if needed and reads the migration state
+func (db *DB) loadState(ctx context.Context, fsys fs.FS) ([]Migration, map[int64]AppliedMigration, error) {
+	if err := db.ensureMigrationsTable(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	return db.readState(ctx, fsys)
+}
+
+// readState reads the migration files and the applied versions, verifying their checksums.
+// A missing schema_migrations table is treated as no migrations applied.
+func (db *DB) readState(ctx context.Context, fsys fs.FS) ([]Migration, map[int64]AppliedMigration, error) {
+	migrations, err := loadMigrations(fsys)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	applied, err := db.appliedMigrations(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Refuse to run anything if an applied migration was edited afterwards
+	if err := verifyChecksums(migrations, applied); err != nil {
+		return nil, nil, err
+	}
+
+	return migrations, applied, nil
+}
+
+// runSteps executes the planned steps in order
+func (db *DB) runSteps(ctx context.Context, steps []MigrationStep) error {
+	if len(steps) == 0 {
+		log.Println("Database schema is up to date")
+		return nil
+	}
+
+	executed := 0
+	for _, step := range steps {
+		if step.Direction == Down {
+			log.Printf("Rolling back migration: %03d_%s", step.Version, step.Name)
+			if err := db.revertMigration(ctx, step.Migration); err != nil {
+				return err
+			}
+			log.Printf("Successfully rolled back migration: %03d_%s", step.Version, step.Name)
+			executed++
+			continue
+		}
+
+		log.Printf("Applying migration: %s", step.Filename)
+		if err := db.applyMigration(ctx, step.Migration); err != nil {
+			if errors.Is(err, ErrModuleUnavailable) {
+				log.Printf("Skipping migration %s, it stays pending: %v", step.Filename, err)
+				continue
+			}
+			return err
 		}
+		log.Printf("Successfully applied migration: %s", step.Filename)
+		executed++
 	}
-	sort.Strings(migrationFiles)
 
-	// Execute each migration
-	for _, filename := range migrationFiles {
-		log.Printf("Applying migration: %s", filename)
+	log.Printf("All migrations completed successfully (%d executed)", executed)
+	return nil
+}
 
-		// Read migration file
-		content, err := fs.ReadFile(migrationsFS, filepath.Join("migrations", filename))
-		if err != nil {
-			return fmt.Errorf("failed to read migration file %s: %w", filename, err)
+// planMigrateTo returns the steps needed to reach the target version:
+// pending migrations up to target in ascending order, or applied migrations above target in descending order
+func planMigrateTo(migrations []Migration, applied map[int64]AppliedMigration, target int64) ([]MigrationStep, error) {
+	var steps []MigrationStep
+
+	// Roll back anything newer than the target, newest first
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if _, ok := applied[m.Version]; !ok || m.Version <= target {
+			continue
+		}
+		if !m.HasDown() {
+			return nil, fmt.Errorf("migration %s has no down migration", m.Filename)
 		}
+		steps = append(steps, MigrationStep{Migration: m, Direction: Down})
+	}
 
-		// Execute migration
-		_, err = db.ExecContext(ctx, string(content))
+	if err := checkUnknownAbove(migrations, applied, target); err != nil {
+		return nil, err
+	}
+
+	// Apply pending migrations up to the target, oldest first
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; ok || m.Version > target {
+			continue
+		}
+		steps = append(steps, MigrationStep{Migration: m, Direction: Up})
+	}
+
+	return steps, nil
+}
+
+// planRollback returns down steps for the most recently applied migrations
+func planRollback(migrations []Migration, applied map[int64]AppliedMigration, count int) ([]MigrationStep, error) {
+	byVersion := make(map[int64]Migration, len(migrations))
+	for _, m := range migrations {
+		byVersion[m.Version] = m
+	}
+
+	versions := make([]int64, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+	if count > len(versions) {
+		return nil, fmt.Errorf("cannot roll back %d migration(s): only %d applied", count, len(versions))
+	}
+
+	steps := make([]MigrationStep, 0, count)
+	for _, version := range versions[:count] {
+		m, ok := byVersion[version]
+		if !ok {
+			return nil, fmt.Errorf("applied migration %03d_%s has no matching file", version, applied[version].Name)
+		}
+		if !m.HasDown() {
+			return nil, fmt.Errorf("migration %s has no down migration", m.Filename)
+		}
+		steps = append(steps, MigrationStep{Migration: m, Direction: Down})
+	}
+
+	return steps, nil
+}
+
+// checkUnknownAbove fails when an applied version above target cannot be rolled back because its file is missing
+func checkUnknownAbove(migrations []Migration, applied map[int64]AppliedMigration, target int64) error {
+	known := make(map[int64]bool, len(migrations))
+	for _, m := range migrations {
+		known[m.Version] = true
+	}
+
+	for version, am := range applied {
+		if version > target && !known[version] {
+			return fmt.Errorf("applied migration %03d_%s has no matching file", version, am.Name)
+		}
+	}
+
+	return nil
+}
+
+// execer is implemented by *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// inMigrationTx runs fn, which executes a migration and records it, as a single unit. On SQLite
+// both happen in one transaction, so a failing statement leaves neither behind. D1 has no
+// interactive transactions: there fn runs statement by statement, and a migration that fails
+// halfway stays unrecorded with its earlier statements applied, to be fixed by hand.
+func (db *DB) inMigrationTx(ctx context.Context, fn func(execer) error) error {
+	if db.driver != "sqlite3" {
+		return fn(db.DB)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin migration transaction: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration: %w", err)
+	}
+	return nil
+}
+
+// applyMigration executes a migration and records it in schema_migrations
+func (db *DB) applyMigration(ctx context.Context, m Migration) error {
+	return db.inMigrationTx(ctx, func(ex execer) error {
+		if _, err := ex.ExecContext(ctx, m.UpSQL); err != nil {
+			if module := missingModule(err, m.Requires); module != "" {
+				return fmt.Errorf("%w: %s", ErrModuleUnavailable, module)
+			}
+			return fmt.Errorf("failed to execute migration %s: %w", m.Filename, err)
+		}
+
+		query := `
+			INSERT INTO schema_migrations (version, name, checksum, applied_at)
+			VALUES (?, ?, ?, ?)
+		`
+
+		appliedAt := time.Now().UTC().Format(time.RFC3339)
+		if _, err := ex.ExecContext(ctx, query, m.Version, m.Name, m.Checksum, appliedAt); err != nil {
+			return fmt.Errorf("failed to record migration %s: %w", m.Filename, err)
+		}
+
+		return nil
+	})
+}
+
+// revertMigration executes a migration's down statements and removes it from schema_migrations
+func (db *DB) revertMigration(ctx context.Context, m Migration) error {
+	return db.inMigrationTx(ctx, func(ex execer) error {
+		if _, err := ex.ExecContext(ctx, m.DownSQL); err != nil {
+			return fmt.Errorf("failed to roll back migration %03d_%s: %w", m.Version, m.Name, err)
+		}
+
+		if _, err := ex.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = ?`, m.Version); err != nil {
+			return fmt.Errorf("failed to unrecord migration %03d_%s: %w", m.Version, m.Name, err)
+		}
+
+		return nil
+	})
+}
+
+// ensureMigrationsTable creates the schema_migrations table if it does not exist
+func (db *DB) ensureMigrationsTable(ctx context.Context) error {
+	if _, err := db.ExecContext(ctx, schemaMigrationsTable); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+// migrationsTableExists reports whether the schema_migrations table has been created
+func (db *DB) migrationsTableExists(ctx context.Context) (bool, error) {
+	query := `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'`
+
+	var count int
+	if err := db.QueryRowContext(ctx, query).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check schema_migrations table: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// appliedMigrations returns the applied migrations keyed by version
+func (db *DB) appliedMigrations(ctx context.Context) (map[int64]AppliedMigration, error) {
+	applied := make(map[int64]AppliedMigration)
+
+	exists, err := db.migrationsTableExists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return applied, nil
+	}
+
+	query := `
+		SELECT version, name, checksum, applied_at
+		FROM schema_migrations
+		ORDER BY version
+	`
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		// Scan into interfaces: cfd1 returns numbers as float64 and timestamps as strings
+		var version, appliedAt interface{}
+		var am AppliedMigration
+		if err := rows.Scan(&version, &am.Name, &am.Checksum, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_migrations row: %w", err)
+		}
+
+		am.Version, err = toInt64(version)
 		if err != nil {
-			return fmt.Errorf("failed to execute migration %s: %w", filename, err)
+			return nil, fmt.Errorf("invalid version in schema_migrations: %w", err)
 		}
+		am.AppliedAt = toTime(appliedAt)
+
+		applied[am.Version] = am
+	}
 
-		log.Printf("Successfully applied migration: %s", filename)
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return applied, nil
+}
+
+// verifyChecksums ensures that no applied migration has changed on disk
+func verifyChecksums(migrations []Migration, applied map[int64]AppliedMigration) error {
+	known := make(map[int64]bool, len(migrations))
+	for _, m := range migrations {
+		known[m.Version] = true
+
+		am, ok := applied[m.Version]
+		if !ok {
+			continue
+		}
+		if am.Checksum != m.Checksum {
+			return fmt.Errorf("checksum mismatch for applied migration %s: recorded %s, file has %s",
+				m.Filename, am.Checksum, m.Checksum)
+		}
+	}
+
+	for version, am := range applied {
+		if !known[version] {
+			log.Printf("Warning: applied migration %03d_%s has no matching file", version, am.Name)
+		}
 	}
 
-	log.Println("All migrations completed successfully")
 	return nil
 }
+
+// loadMigrations reads and parses all migration files from the migrations directory of fsys.
+// Files are named <version>_<name>.up.sql and <version>_<name>.down.sql;
+// a plain <version>_<name>.sql file is treated as an up migration without rollback.
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	downFiles := make(map[int64]string)
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+
+		version, name, direction, err := parseMigrationFilename(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration file %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		}
+		if m.Name != name {
+			return nil, fmt.Errorf("migration version %03d has conflicting names %s and %s", version, m.Name, name)
+		}
+
+		if direction == Down {
+			if other, ok := downFiles[version]; ok {
+				return nil, fmt.Errorf("duplicate down migration version %03d: %s and %s", version, other, entry.Name())
+			}
+			downFiles[version] = entry.Name()
+			m.DownSQL = string(content)
+			continue
+		}
+
+		if m.Filename != "" {
+			return nil, fmt.Errorf("duplicate migration version %03d: %s and %s", version, m.Filename, entry.Name())
+		}
+
+		// The checksum covers the up statements only, which are what schema_migrations records
+		sum := sha256.Sum256(content)
+		m.Filename = entry.Name()
+		m.UpSQL = string(content)
+		m.Checksum = hex.EncodeToString(sum[:])
+		m.Requires = parseRequires(m.UpSQL)
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for version, m := range byVersion {
+		if m.Filename == "" {
+			return nil, fmt.Errorf("down migration %s has no matching up migration", downFiles[version])
+		}
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// parseMigrationFilename splits a name like 001_create_users_table.up.sql into its version, name and direction
+func parseMigrationFilename(filename string) (int64, string, Direction, error) {
+	base := strings.TrimSuffix(filename, ".sql")
+
+	direction := Up
+	switch {
+	case strings.HasSuffix(base, ".up"):
+		base = strings.TrimSuffix(base, ".up")
+	case strings.HasSuffix(base, ".down"):
+		base = strings.TrimSuffix(base, ".down")
+		direction = Down
+	}
+
+	prefix, name, ok := strings.Cut(base, "_")
+	if !ok || name == "" {
+		return 0, "", Up, fmt.Errorf("invalid migration filename %s: expected <version>_<name>.up.sql or <version>_<name>.down.sql", filename)
+	}
+
+	version, err := strconv.ParseInt(prefix, 10, 64)
+	if err != nil || version < 1 {
+		return 0, "", Up, fmt.Errorf("invalid migration filename %s: version must be a positive number", filename)
+	}
+
+	return version, name, direction, nil
+}
+
+// parseRequires returns the modules listed in "-- Requires: fts5, ..." header lines
+func parseRequires(sql string) []string {
+	var modules []string
+	for _, line := range strings.Split(sql, "\n") {
+		rest, ok := strings.CutPrefix(strings.TrimSpace(line), "-- Requires:")
+		if !ok {
+			continue
+		}
+		for _, module := range strings.Split(rest, ",") {
+			if module = strings.TrimSpace(module); module != "" {
+				modules = append(modules, module)
+			}
+		}
+	}
+	return modules
+}
+
+// missingModule returns the required module that err reports as missing, or an empty string.
+// Both mattn/go-sqlite3 and D1 report it as "no such module: <name>".
+func missingModule(err error, requires []string) string {
+	msg := err.Error()
+	for _, module := range requires {
+		if strings.Contains(msg, "no such module: "+module) {
+			return module
+		}
+	}
+	return ""
+}
+
+// toInt64 converts a scanned numeric value to int64 (cfd1 returns all numbers as float64)
+func toInt64(v interface{}) (int64, error) {
+	switch n := v.(type) {
+	case int64:
+		return n, nil
+	case float64:
+		return int64(n), nil
+	case []byte:
+		return strconv.ParseInt(string(n), 10, 64)
+	case string:
+		return strconv.ParseInt(n, 10, 64)
+	default:
+		return 0, fmt.Errorf("unsupported numeric type %T", v)
+	}
+}
+
+// toTime converts a scanned timestamp value to time.Time
+func toTime(v interface{}) time.Time {
+	switch t := v.(type) {
+	case time.Time:
+		return t
+	case []byte:
+		return toTime(string(t))
+	case string:
+		formats := []string{
+			time.RFC3339,
+			time.RFC3339Nano,
+			"2006-01-02 15:04:05",
+			"2006-01-02T15:04:05",
+		}
+		for _, format := range formats {
+			if parsed, err := time.Parse(format, t); err == nil {
+				return parsed
+			}
+		}
+	}
+	return time.Time{}
+}
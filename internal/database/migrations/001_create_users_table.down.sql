@@ -0,0 +1,7 @@
+-- Rollback: Create users table
+-- Created: 2026-10-16
+-- Description: Drop the users table and its indexes
+
+DROP INDEX IF EXISTS idx_users_created_at;
+DROP INDEX IF EXISTS idx_users_email;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,11 @@
+-- Rollback: Create rooms and user_rooms tables
+-- Created: 2026-10-16
+-- Description: Drop the user_rooms junction table and the rooms table
+
+DROP INDEX IF EXISTS idx_user_rooms_room_id;
+DROP INDEX IF EXISTS idx_user_rooms_user_id;
+DROP TABLE IF EXISTS user_rooms;
+
+DROP INDEX IF EXISTS idx_rooms_capacity;
+DROP INDEX IF EXISTS idx_rooms_name;
+DROP TABLE IF EXISTS rooms;
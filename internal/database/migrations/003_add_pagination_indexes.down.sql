@@ -0,0 +1,6 @@
+-- Rollback: Add pagination indexes
+-- Created: 2026-10-16
+-- Description: Drop the keyset pagination indexes
+
+DROP INDEX IF EXISTS idx_rooms_created_at_id;
+DROP INDEX IF EXISTS idx_users_created_at_id;
@@ -0,0 +1,13 @@
+-- Rollback: Create full-text search index
+-- Created: 2026-10-16
+-- Description: Drop the FTS5 search indexes and their triggers
+
+DROP TRIGGER IF EXISTS users_fts_after_update;
+DROP TRIGGER IF EXISTS users_fts_after_delete;
+DROP TRIGGER IF EXISTS users_fts_after_insert;
+DROP TABLE IF EXISTS users_fts;
+
+DROP TRIGGER IF EXISTS rooms_fts_after_update;
+DROP TRIGGER IF EXISTS rooms_fts_after_delete;
+DROP TRIGGER IF EXISTS rooms_fts_after_insert;
+DROP TABLE IF EXISTS rooms_fts;
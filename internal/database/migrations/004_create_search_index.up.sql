@@ -0,0 +1,51 @@
+-- Migration: Create full-text search index
+-- Created: 2026-10-16
+-- Description: FTS5 indexes over rooms(name, description) and users(name, email), kept in sync by triggers
+-- Requires: fts5
+
+-- External-content FTS5 tables: the text lives in rooms/users, the index only stores tokens
+CREATE VIRTUAL TABLE IF NOT EXISTS rooms_fts USING fts5(
+    name,
+    description,
+    content='rooms',
+    content_rowid='id'
+);
+
+CREATE VIRTUAL TABLE IF NOT EXISTS users_fts USING fts5(
+    name,
+    email,
+    content='users',
+    content_rowid='id'
+);
+
+-- Keep rooms_fts in sync with rooms
+CREATE TRIGGER IF NOT EXISTS rooms_fts_after_insert AFTER INSERT ON rooms BEGIN
+    INSERT INTO rooms_fts (rowid, name, description) VALUES (new.id, new.name, new.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS rooms_fts_after_delete AFTER DELETE ON rooms BEGIN
+    INSERT INTO rooms_fts (rooms_fts, rowid, name, description) VALUES ('delete', old.id, old.name, old.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS rooms_fts_after_update AFTER UPDATE OF name, description ON rooms BEGIN
+    INSERT INTO rooms_fts (rooms_fts, rowid, name, description) VALUES ('delete', old.id, old.name, old.description);
+    INSERT INTO rooms_fts (rowid, name, description) VALUES (new.id, new.name, new.description);
+END;
+
+-- Keep users_fts in sync with users
+CREATE TRIGGER IF NOT EXISTS users_fts_after_insert AFTER INSERT ON users BEGIN
+    INSERT INTO users_fts (rowid, name, email) VALUES (new.id, new.name, new.email);
+END;
+
+CREATE TRIGGER IF NOT EXISTS users_fts_after_delete AFTER DELETE ON users BEGIN
+    INSERT INTO users_fts (users_fts, rowid, name, email) VALUES ('delete', old.id, old.name, old.email);
+END;
+
+CREATE TRIGGER IF NOT EXISTS users_fts_after_update AFTER UPDATE OF name, email ON users BEGIN
+    INSERT INTO users_fts (users_fts, rowid, name, email) VALUES ('delete', old.id, old.name, old.email);
+    INSERT INTO users_fts (rowid, name, email) VALUES (new.id, new.name, new.email);
+END;
+
+-- Index the rows that existed before this migration
+INSERT INTO rooms_fts (rooms_fts) VALUES ('rebuild');
+INSERT INTO users_fts (users_fts) VALUES ('rebuild');
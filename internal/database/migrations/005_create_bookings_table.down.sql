@@ -0,0 +1,7 @@
+-- Rollback: Create bookings table
+-- Created: 2026-10-16
+-- Description: Drop the bookings table and its indexes
+
+DROP INDEX IF EXISTS idx_bookings_user_id;
+DROP INDEX IF EXISTS idx_bookings_room_starts_at;
+DROP TABLE IF EXISTS bookings;
@@ -0,0 +1,8 @@
+-- Rollback: Create recurring bookings
+-- Created: 2026-10-16
+-- Description: Drop the recurring booking tables and their indexes
+
+DROP TABLE IF EXISTS recurring_booking_exceptions;
+DROP INDEX IF EXISTS idx_recurring_bookings_user_id;
+DROP INDEX IF EXISTS idx_recurring_bookings_room_id;
+DROP TABLE IF EXISTS recurring_bookings;
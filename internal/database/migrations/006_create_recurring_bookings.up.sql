@@ -0,0 +1,38 @@
+-- Migration: Create recurring bookings
+-- Created: 2026-10-16
+-- Description: Room booking series stored as RFC 5545 recurrence rules, plus cancelled and moved occurrences
+
+CREATE TABLE IF NOT EXISTS recurring_bookings (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    room_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    title TEXT NOT NULL DEFAULT '',
+    -- First occurrence, UTC text like bookings; every occurrence has the same duration
+    starts_at DATETIME NOT NULL,
+    ends_at DATETIME NOT NULL,
+    -- IANA time zone whose wall-clock time occurrences keep across DST changes
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    rrule TEXT NOT NULL,
+    -- End of the last occurrence, NULL when the rule has neither COUNT nor UNTIL
+    last_ends_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (room_id) REFERENCES rooms(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_recurring_bookings_room_id ON recurring_bookings(room_id, starts_at);
+CREATE INDEX IF NOT EXISTS idx_recurring_bookings_user_id ON recurring_bookings(user_id);
+
+-- One row per cancelled (starts_at/ends_at NULL) or moved occurrence, keyed by the start the rule assigns it
+CREATE TABLE IF NOT EXISTS recurring_booking_exceptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    series_id INTEGER NOT NULL,
+    recurrence_id DATETIME NOT NULL,
+    starts_at DATETIME,
+    ends_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (series_id) REFERENCES recurring_bookings(id) ON DELETE CASCADE,
+    UNIQUE(series_id, recurrence_id),
+    CHECK ((starts_at IS NULL AND ends_at IS NULL) OR ends_at > starts_at)
+);
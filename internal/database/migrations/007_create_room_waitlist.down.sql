@@ -0,0 +1,7 @@
+-- Rollback: Create room waitlist
+-- Created: 2026-10-16
+-- Description: Drop the room waitlist table and its indexes
+
+DROP INDEX IF EXISTS idx_room_waitlist_user_id;
+DROP INDEX IF EXISTS idx_room_waitlist_room_id;
+DROP TABLE IF EXISTS room_waitlist;
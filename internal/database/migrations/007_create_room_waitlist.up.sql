@@ -0,0 +1,19 @@
+-- Migration: Create room waitlist
+-- Created: 2026-10-16
+-- Description: FIFO queue of users waiting for a seat in a full room; id gives the queue order
+
+CREATE TABLE IF NOT EXISTS room_waitlist (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    room_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (room_id) REFERENCES rooms(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE(room_id, user_id)
+);
+
+-- Promotion reads a room's queue in id order
+CREATE INDEX IF NOT EXISTS idx_room_waitlist_room_id ON room_waitlist(room_id, id);
+
+-- Create index on user_id for the foreign key
+CREATE INDEX IF NOT EXISTS idx_room_waitlist_user_id ON room_waitlist(user_id);
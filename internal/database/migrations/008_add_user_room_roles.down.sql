@@ -0,0 +1,6 @@
+-- Rollback: Add user room roles
+-- Created: 2026-10-16
+-- Description: Drop the role column of user_rooms and its index
+
+DROP INDEX IF EXISTS idx_user_rooms_room_role;
+ALTER TABLE user_rooms DROP COLUMN role;
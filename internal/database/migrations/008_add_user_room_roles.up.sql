@@ -0,0 +1,12 @@
+-- Migration: Add user room roles
+-- Created: 2026-10-16
+-- Description: Role of each user in a room (owner, moderator or member); the earliest member of every existing room becomes its owner
+
+ALTER TABLE user_rooms ADD COLUMN role TEXT NOT NULL DEFAULT 'member' CHECK (role IN ('owner', 'moderator', 'member'));
+
+UPDATE user_rooms
+SET role = 'owner'
+WHERE id IN (SELECT MIN(id) FROM user_rooms GROUP BY room_id);
+
+-- Owner checks look up the owners of a room
+CREATE INDEX IF NOT EXISTS idx_user_rooms_room_role ON user_rooms(room_id, role);
@@ -0,0 +1,13 @@
+-- Rollback: Create invitations
+-- Created: 2026-10-16
+-- Description: Drop the invitation and join request tables and their indexes
+
+DROP INDEX IF EXISTS idx_room_join_requests_user_id;
+DROP INDEX IF EXISTS idx_room_join_requests_pending;
+DROP TABLE IF EXISTS room_join_requests;
+
+DROP INDEX IF EXISTS idx_room_invitations_invited_by;
+DROP INDEX IF EXISTS idx_room_invitations_email;
+DROP INDEX IF EXISTS idx_room_invitations_user_id;
+DROP INDEX IF EXISTS idx_room_invitations_room_id;
+DROP TABLE IF EXISTS room_invitations;
@@ -0,0 +1,8 @@
+-- Rollback: Add room metadata
+-- Created: 2026-10-16
+-- Description: Drop room tags, amenities and attributes
+
+DROP TABLE IF EXISTS room_amenities;
+DROP TABLE IF EXISTS room_tags;
+DROP TABLE IF EXISTS tags;
+ALTER TABLE rooms DROP COLUMN attributes;
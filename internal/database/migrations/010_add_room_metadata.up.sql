@@ -0,0 +1,31 @@
+-- Migration: Add room metadata
+-- Created: 2026-10-16
+-- Description: Room tags (many-to-many), amenities from a fixed vocabulary and a free-form JSON attributes object
+
+ALTER TABLE rooms ADD COLUMN attributes TEXT NOT NULL DEFAULT '{}' CHECK (json_valid(attributes) AND json_type(attributes) = 'object');
+
+CREATE TABLE IF NOT EXISTS tags (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS room_tags (
+    room_id INTEGER NOT NULL,
+    tag_id INTEGER NOT NULL,
+    PRIMARY KEY (room_id, tag_id),
+    FOREIGN KEY (room_id) REFERENCES rooms(id) ON DELETE CASCADE,
+    FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
+);
+
+-- Tag filters look up the rooms of a tag
+CREATE INDEX IF NOT EXISTS idx_room_tags_tag_id ON room_tags(tag_id);
+
+CREATE TABLE IF NOT EXISTS room_amenities (
+    room_id INTEGER NOT NULL,
+    amenity TEXT NOT NULL CHECK (amenity IN ('projector', 'whiteboard', 'video')),
+    PRIMARY KEY (room_id, amenity),
+    FOREIGN KEY (room_id) REFERENCES rooms(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_room_amenities_amenity ON room_amenities(amenity);
@@ -0,0 +1,9 @@
+-- Rollback: Create locations
+-- Created: 2026-10-16
+-- Description: Drop sites, buildings and floors and the floor of each room
+
+DROP INDEX IF EXISTS idx_rooms_floor_id;
+ALTER TABLE rooms DROP COLUMN floor_id;
+DROP TABLE IF EXISTS floors;
+DROP TABLE IF EXISTS buildings;
+DROP TABLE IF EXISTS sites;
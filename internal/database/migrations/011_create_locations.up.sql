@@ -0,0 +1,38 @@
+-- Migration: Create locations
+-- Created: 2026-10-16
+-- Description: Sites, buildings and floors, and the floor of each room
+
+CREATE TABLE IF NOT EXISTS sites (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    address TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS buildings (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    site_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (site_id) REFERENCES sites(id) ON DELETE CASCADE,
+    UNIQUE(site_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS floors (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    building_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    level INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (building_id) REFERENCES buildings(id) ON DELETE CASCADE,
+    UNIQUE(building_id, level)
+);
+
+-- No REFERENCES clause: SQLite cannot drop a foreign key column, which the rollback needs.
+-- The repository clears floor_id when a floor, building or site is deleted.
+ALTER TABLE rooms ADD COLUMN floor_id INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_rooms_floor_id ON rooms(floor_id);
@@ -0,0 +1,9 @@
+-- Rollback: Create groups
+-- Created: 2026-10-16
+-- Description: Drop groups, their members and their room assignments
+
+DROP INDEX IF EXISTS idx_room_groups_group_id;
+DROP TABLE IF EXISTS room_groups;
+DROP INDEX IF EXISTS idx_group_members_user_id;
+DROP TABLE IF EXISTS group_members;
+DROP TABLE IF EXISTS groups;
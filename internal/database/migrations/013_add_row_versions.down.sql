@@ -0,0 +1,6 @@
+-- Rollback: Add row versions
+-- Created: 2026-10-16
+-- Description: Drop the version counters of users and rooms
+
+ALTER TABLE rooms DROP COLUMN version;
+ALTER TABLE users DROP COLUMN version;
@@ -0,0 +1,6 @@
+-- Migration: Add row versions
+-- Created: 2026-10-16
+-- Description: Version counters on users and rooms for optimistic concurrency (ETag and If-Match)
+
+ALTER TABLE users ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE rooms ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,5 @@
+-- Rollback: Dequeue seated users
+-- Created: 2026-10-16
+-- Description: Drop the trigger that removes seated users from waitlists
+
+DROP TRIGGER IF EXISTS user_rooms_dequeue_after_insert;
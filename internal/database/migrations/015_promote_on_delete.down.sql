@@ -0,0 +1,12 @@
+-- Rollback: Promote on delete
+-- Created: 2026-10-16
+-- Description: Drop the promotion trigger and restore the dequeue trigger of migration 014
+
+DROP TRIGGER IF EXISTS user_rooms_promote_after_delete;
+DROP TRIGGER IF EXISTS user_rooms_dequeue_after_insert;
+
+CREATE TRIGGER IF NOT EXISTS user_rooms_dequeue_after_insert AFTER INSERT ON user_rooms BEGIN
+    DELETE FROM room_waitlist WHERE room_id = new.room_id AND user_id = new.user_id;
+END;
+
+DROP TABLE IF EXISTS waitlist_promotions;
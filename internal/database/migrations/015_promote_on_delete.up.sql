@@ -0,0 +1,49 @@
+-- Migration: Promote on delete
+-- Created: 2026-10-16
+-- Description: Give a seat freed by removing a user from a room to its waitlist in the same statement
+
+-- Promotions waiting for the application to publish their waitlist.promoted events
+CREATE TABLE IF NOT EXISTS waitlist_promotions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    room_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    promoted_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_waitlist_promotions_room_id ON waitlist_promotions(room_id);
+
+-- Only queued users are seated while the room has a waitlist, so every dequeue is a promotion
+DROP TRIGGER IF EXISTS user_rooms_dequeue_after_insert;
+
+CREATE TRIGGER IF NOT EXISTS user_rooms_dequeue_after_insert AFTER INSERT ON user_rooms BEGIN
+    INSERT INTO waitlist_promotions (room_id, user_id, promoted_at)
+    SELECT room_id, user_id, new.created_at FROM room_waitlist WHERE room_id = new.room_id AND user_id = new.user_id;
+    DELETE FROM room_waitlist WHERE room_id = new.room_id AND user_id = new.user_id;
+END;
+
+-- Hand a room left without an owner to its longest-standing member, then fill the freed seats
+-- from the waitlist, first in, first out
+CREATE TRIGGER IF NOT EXISTS user_rooms_promote_after_delete AFTER DELETE ON user_rooms BEGIN
+    UPDATE user_rooms
+    SET role = 'owner'
+    WHERE id = (SELECT MIN(id) FROM user_rooms WHERE room_id = old.room_id)
+      AND NOT EXISTS (SELECT 1 FROM user_rooms WHERE room_id = old.room_id AND role = 'owner');
+    INSERT INTO user_rooms (user_id, room_id, role, created_at)
+    SELECT w.user_id, w.room_id,
+           CASE WHEN NOT EXISTS (SELECT 1 FROM user_rooms WHERE room_id = w.room_id)
+                 AND w.id = (SELECT MIN(f.id) FROM room_waitlist f
+                             WHERE f.room_id = w.room_id
+                               AND NOT EXISTS (SELECT 1 FROM user_rooms WHERE user_id = f.user_id AND room_id = f.room_id))
+                THEN 'owner' ELSE 'member' END,
+           CURRENT_TIMESTAMP
+    FROM room_waitlist w
+    WHERE w.room_id = old.room_id
+      AND NOT EXISTS (SELECT 1 FROM user_rooms WHERE user_id = w.user_id AND room_id = w.room_id)
+    ORDER BY w.id
+    LIMIT COALESCE((SELECT MAX(0, rooms.capacity - (SELECT COUNT(*) FROM (
+                        SELECT user_rooms.user_id FROM user_rooms WHERE user_rooms.room_id = rooms.id
+                        UNION
+                        SELECT gm.user_id FROM room_groups rg INNER JOIN group_members gm ON gm.group_id = rg.group_id
+                        WHERE rg.room_id = rooms.id)))
+                    FROM rooms WHERE rooms.id = old.room_id), 0);
+END;
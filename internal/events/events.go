@@ -0,0 +1,58 @@
+// Package events delivers domain events, such as a user being promoted from a waitlist, to in-process subscribers.
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Event types
+const (
+	// TypeWaitlistPromoted is published when a waitlisted user is assigned to a room; Data is a models.WaitlistPromotion
+	TypeWaitlistPromoted = "waitlist.promoted"
+)
+
+// Event is something that happened in the domain
+type Event struct {
+	Type string
+	Time time.Time
+	Data interface{}
+}
+
+// Publisher receives events
+type Publisher interface {
+	Publish(ctx context.Context, e Event)
+}
+
+// Handler processes a published event
+type Handler func(ctx context.Context, e Event)
+
+// Bus is a Publisher that calls every subscribed handler synchronously, in subscription order
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []Handler
+}
+
+// NewBus creates an event bus without subscribers
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers a handler for every event published after the call
+func (b *Bus) Subscribe(h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, h)
+}
+
+// Publish delivers e to all subscribers
+func (b *Bus) Publish(ctx context.Context, e Event) {
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+
+	for _, h := range handlers {
+		h(ctx, e)
+	}
+}
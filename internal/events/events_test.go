@@ -0,0 +1,24 @@
+package events
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBus_Publish(t *testing.T) {
+	bus := NewBus()
+	ctx := context.Background()
+
+	// Events published before subscribing are not replayed
+	bus.Publish(ctx, Event{Type: "ignored"})
+
+	var got []string
+	bus.Subscribe(func(ctx context.Context, e Event) { got = append(got, "first:"+e.Type) })
+	bus.Subscribe(func(ctx context.Context, e Event) { got = append(got, "second:"+e.Type) })
+
+	bus.Publish(ctx, Event{Type: TypeWaitlistPromoted})
+
+	if len(got) != 2 || got[0] != "first:"+TypeWaitlistPromoted || got[1] != "second:"+TypeWaitlistPromoted {
+		t.Errorf("Expected both handlers in order, got %v", got)
+	}
+}
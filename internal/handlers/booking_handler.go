@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"cloudflaredb/internal/models"
+	"cloudflaredb/internal/repository"
+)
+
+// BookingHandler handles HTTP requests for room bookings
+type BookingHandler struct {
+	repo *repository.BookingRepository
+}
+
+// NewBookingHandler creates a new booking handler
+func NewBookingHandler(repo *repository.BookingRepository) *BookingHandler {
+	return &BookingHandler{repo: repo}
+}
+
+// CreateBooking handles POST /rooms/{id}/bookings
+func (h *BookingHandler) CreateBooking(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := pathID(w, r, "id", "room")
+	if !ok {
+		return
+	}
+
+	var req models.CreateBookingRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	booking, err := h.repo.Create(r.Context(), roomID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrFullyBooked):
+			respondError(w, r, http.StatusConflict, CodeFullyBooked,
+				"Room has no free capacity for the whole requested time range")
+		case errors.Is(err, repository.ErrRoomNotFound):
+			respondError(w, r, http.StatusNotFound, CodeNotFound, "Room not found")
+		case errors.Is(err, repository.ErrUserNotFound):
+			respondError(w, r, http.StatusNotFound, CodeNotFound, "User not found")
+		default:
+			respondInternalError(w, r, "create booking", err)
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, booking)
+}
+
+// ListRoomBookings handles GET /rooms/{id}/bookings?from=&to=
+func (h *BookingHandler) ListRoomBookings(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := pathID(w, r, "id", "room")
+	if !ok {
+		return
+	}
+
+	q := newQueryParams(r.URL.Query())
+	from := q.Time("from")
+	to := q.Time("to")
+	if !from.IsZero() && !to.IsZero() && !to.After(from) {
+		q.errs = append(q.errs, FieldError{Field: "to", Message: "must be after from"})
+	}
+	if errs := q.Errors(); len(errs) > 0 {
+		respondValidationError(w, r, errs)
+		return
+	}
+
+	bookings, err := h.repo.ListByRoom(r.Context(), roomID, from, to)
+	if err != nil {
+		if errors.Is(err, repository.ErrRoomNotFound) {
+			respondError(w, r, http.StatusNotFound, CodeNotFound, "Room not found")
+			return
+		}
+		respondInternalError(w, r, "list bookings", err)
+		return
+	}
+
+	if bookings == nil {
+		bookings = []*models.Booking{}
+	}
+
+	respondJSON(w, http.StatusOK, bookings)
+}
+
+// DeleteBooking handles DELETE /bookings/{id}
+func (h *BookingHandler) DeleteBooking(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "booking")
+	if !ok {
+		return
+	}
+
+	if err := h.repo.Delete(r.Context(), id); err != nil {
+		if errors.Is(err, repository.ErrBookingNotFound) {
+			respondError(w, r, http.StatusNotFound, CodeNotFound, "Booking not found")
+			return
+		}
+		respondInternalError(w, r, "delete booking", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cloudflaredb/internal/models"
+	"cloudflaredb/internal/repository"
+)
+
+func TestBookingHandler_CreateAndList(t *testing.T) {
+	db := setupTestDBForRooms(t)
+	defer db.Close()
+
+	handler := NewBookingHandler(repository.NewBookingRepository(db))
+	router := NewRouter((&API{Bookings: handler}).Routes()...)
+
+	if _, err := db.Exec(`INSERT INTO users (email, name) VALUES ('a@example.com', 'A'), ('b@example.com', 'B');
+		INSERT INTO rooms (name, capacity) VALUES ('Booth', 1);`); err != nil {
+		t.Fatalf("Failed to seed data: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name:       "valid booking",
+			path:       "/rooms/1/bookings",
+			body:       `{"user_id": 1, "title": "Focus", "starts_at": "2026-01-05T09:00:00Z", "ends_at": "2026-01-05T10:00:00Z"}`,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "overlapping booking",
+			path:       "/rooms/1/bookings",
+			body:       `{"user_id": 2, "starts_at": "2026-01-05T09:30:00Z", "ends_at": "2026-01-05T10:30:00Z"}`,
+			wantStatus: http.StatusConflict,
+			wantCode:   CodeFullyBooked,
+		},
+		{
+			name:       "ends before start",
+			path:       "/rooms/1/bookings",
+			body:       `{"user_id": 2, "starts_at": "2026-01-05T11:00:00Z", "ends_at": "2026-01-05T10:00:00Z"}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeValidationFailed,
+		},
+		{
+			name:       "missing room",
+			path:       "/rooms/99/bookings",
+			body:       `{"user_id": 1, "starts_at": "2026-01-05T11:00:00Z", "ends_at": "2026-01-05T12:00:00Z"}`,
+			wantStatus: http.StatusNotFound,
+			wantCode:   CodeNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantCode != "" {
+				if p := decodeProblem(t, w); p.Code != tt.wantCode {
+					t.Errorf("Expected code %s, got %s", tt.wantCode, p.Code)
+				}
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/rooms/1/bookings?from=2026-01-05&to=2026-01-06", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var bookings []models.Booking
+	if err := json.NewDecoder(w.Body).Decode(&bookings); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(bookings) != 1 || bookings[0].Title != "Focus" {
+		t.Errorf("Expected the Focus booking, got %+v", bookings)
+	}
+}
+
+func TestBookingHandler_DeleteBooking(t *testing.T) {
+	db := setupTestDBForRooms(t)
+	defer db.Close()
+
+	handler := NewBookingHandler(repository.NewBookingRepository(db))
+	router := NewRouter((&API{Bookings: handler}).Routes()...)
+
+	if _, err := db.Exec(`INSERT INTO users (email, name) VALUES ('a@example.com', 'A');
+		INSERT INTO rooms (name, capacity) VALUES ('Booth', 1);
+		INSERT INTO bookings (room_id, user_id, starts_at, ends_at) VALUES (1, 1, '2026-01-05T09:00:00Z', '2026-01-05T10:00:00Z');`); err != nil {
+		t.Fatalf("Failed to seed data: %v", err)
+	}
+
+	for _, want := range []int{http.StatusNoContent, http.StatusNotFound} {
+		req := httptest.NewRequest(http.MethodDelete, "/bookings/1", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != want {
+			t.Errorf("Expected status %d, got %d", want, w.Code)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"cloudflaredb/internal/ical"
+	"cloudflaredb/internal/models"
+	"cloudflaredb/internal/repository"
+)
+
+// calendarUIDDomain scopes the UIDs of calendar events to this application
+const calendarUIDDomain = "cloudflaredb"
+
+// CalendarHandler serves iCalendar feeds of room assignments
+type CalendarHandler struct {
+	users *repository.UserRepository
+	rooms *repository.RoomRepository
+}
+
+// NewCalendarHandler creates a new calendar handler
+func NewCalendarHandler(users *repository.UserRepository, rooms *repository.RoomRepository) *CalendarHandler {
+	return &CalendarHandler{users: users, rooms: rooms}
+}
+
+// RoomCalendar handles GET /rooms/{id}/calendar.ics?tz=
+func (h *CalendarHandler) RoomCalendar(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := pathID(w, r, "id", "room")
+	if !ok {
+		return
+	}
+	loc, ok := calendarLocation(w, r)
+	if !ok {
+		return
+	}
+
+	room, err := h.rooms.GetByID(r.Context(), roomID)
+	if err != nil {
+		if errors.Is(err, repository.ErrRoomNotFound) {
+			respondError(w, r, http.StatusNotFound, CodeNotFound, "Room not found")
+			return
+		}
+		respondInternalError(w, r, "get room", err)
+		return
+	}
+
+	assignments, err := h.rooms.RoomAssignments(r.Context(), roomID)
+	if err != nil {
+		respondInternalError(w, r, "list room assignments", err)
+		return
+	}
+
+	cal := &ical.Calendar{Name: room.Name, Location: loc}
+	for _, a := range assignments {
+		cal.Events = append(cal.Events, assignmentEvent(a,
+			fmt.Sprintf("%s joined %s", a.UserName, a.RoomName),
+			fmt.Sprintf("%s <%s> was assigned to %s.", a.UserName, a.UserEmail, a.RoomName)))
+	}
+
+	respondCalendar(w, r, fmt.Sprintf("room-%d.ics", roomID), cal)
+}
+
+// UserCalendar handles GET /users/{id}/calendar.ics?tz=
+func (h *CalendarHandler) UserCalendar(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathID(w, r, "id", "user")
+	if !ok {
+		return
+	}
+	loc, ok := calendarLocation(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.users.GetByID(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			respondError(w, r, http.StatusNotFound, CodeNotFound, "User not found")
+			return
+		}
+		respondInternalError(w, r, "get user", err)
+		return
+	}
+
+	assignments, err := h.rooms.UserAssignments(r.Context(), userID)
+	if err != nil {
+		respondInternalError(w, r, "list user assignments", err)
+		return
+	}
+
+	cal := &ical.Calendar{Name: user.Name + " - Rooms", Location: loc}
+	for _, a := range assignments {
+		cal.Events = append(cal.Events, assignmentEvent(a,
+			"Joined "+a.RoomName,
+			fmt.Sprintf("%s was assigned to %s.", a.UserName, a.RoomName)))
+	}
+
+	respondCalendar(w, r, fmt.Sprintf("user-%d.ics", userID), cal)
+}
+
+// assignmentEvent renders an assignment as an instant event at the time it was made. The UID is
+// derived from the assignment ID, so it survives renames and changes only when a user is reassigned.
+func assignmentEvent(a *models.Assignment, summary, description string) ical.Event {
+	return ical.Event{
+		UID:         fmt.Sprintf("user-room-%d@%s", a.ID, calendarUIDDomain),
+		Stamp:       a.CreatedAt,
+		Start:       a.CreatedAt,
+		Summary:     summary,
+		Description: description,
+		Transparent: true,
+	}
+}
+
+// calendarLocation reads the tz query parameter, sending a validation problem when it is not an IANA time zone
+func calendarLocation(w http.ResponseWriter, r *http.Request) (*time.Location, bool) {
+	name := strings.TrimSpace(r.URL.Query().Get("tz"))
+	if name == "" {
+		return time.UTC, true
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		respondValidationError(w, r, []FieldError{{Field: "tz", Message: "must be an IANA time zone name"}})
+		return nil, false
+	}
+	return loc, true
+}
+
+// respondCalendar writes a calendar feed with an ETag, so polling clients get 304 until it changes
+func respondCalendar(w http.ResponseWriter, r *http.Request, filename string, cal *ical.Calendar) {
+	w.Header().Set("Content-Disposition", `inline; filename="`+filename+`"`)
+	respondWithETag(w, r, ical.ContentType, cal.Encode())
+}
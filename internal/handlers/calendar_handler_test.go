@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cloudflaredb/internal/ical"
+	"cloudflaredb/internal/repository"
+)
+
+func TestCalendarHandler_RoomCalendar(t *testing.T) {
+	db := setupTestDBForRooms(t)
+	defer db.Close()
+
+	handler := NewCalendarHandler(repository.NewUserRepository(db, repository.NewRoomRepository(db)), repository.NewRoomRepository(db))
+	router := NewRouter((&API{Calendars: handler}).Routes()...)
+
+	if _, err := db.Exec(`INSERT INTO users (email, name) VALUES ('ada@example.com', 'Ada');
+		INSERT INTO rooms (name, capacity) VALUES ('Lab', 5);
+		INSERT INTO user_rooms (user_id, room_id, created_at) VALUES (1, 1, '2026-01-05 08:30:00');`); err != nil {
+		t.Fatalf("Failed to seed data: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/rooms/1/calendar.ics", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != ical.ContentType {
+		t.Errorf("Expected content type %s, got %s", ical.ContentType, ct)
+	}
+
+	body := w.Body.String()
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"X-WR-CALNAME:Lab\r\n",
+		"UID:user-room-1@cloudflaredb\r\n",
+		"DTSTART:20260105T083000Z\r\n",
+		"SUMMARY:Ada joined Lab\r\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected feed to contain %q, got:\n%s", want, body)
+		}
+	}
+
+	// Polling with the ETag costs a 304 until the feed changes
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("Expected an ETag header")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/rooms/1/calendar.ics", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("Expected an empty 304, got %d with %d bytes", w.Code, w.Body.Len())
+	}
+
+	if _, err := db.Exec(`UPDATE rooms SET name = 'Lab 2' WHERE id = 1`); err != nil {
+		t.Fatalf("Failed to rename room: %v", err)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Errorf("Expected a new feed with a new ETag after a change, got %d", w.Code)
+	}
+}
+
+func TestCalendarHandler_UserCalendar(t *testing.T) {
+	db := setupTestDBForRooms(t)
+	defer db.Close()
+
+	handler := NewCalendarHandler(repository.NewUserRepository(db, repository.NewRoomRepository(db)), repository.NewRoomRepository(db))
+	router := NewRouter((&API{Calendars: handler}).Routes()...)
+
+	if _, err := db.Exec(`INSERT INTO users (email, name) VALUES ('ada@example.com', 'Ada');
+		INSERT INTO rooms (name, capacity) VALUES ('Lab', 5);
+		INSERT INTO user_rooms (user_id, room_id, created_at) VALUES (1, 1, '2026-07-01 08:30:00');`); err != nil {
+		t.Fatalf("Failed to seed data: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "local time zone", path: "/users/1/calendar.ics?tz=Europe/Berlin", wantStatus: http.StatusOK, wantBody: "DTSTART;TZID=Europe/Berlin:20260701T103000\r\n"},
+		{name: "invalid time zone", path: "/users/1/calendar.ics?tz=Nowhere/Special", wantStatus: http.StatusBadRequest},
+		{name: "missing user", path: "/users/99/calendar.ics", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantBody != "" && !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("Expected feed to contain %q, got:\n%s", tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"cloudflaredb/internal/validation"
+)
+
+// maxBodyBytes bounds the size of JSON request bodies
+const maxBodyBytes = 1 << 20
+
+// mergePatchContentType is the media type of JSON merge patches (RFC 7396), which PATCH routes take.
+// decodeRequest does not check the media type, so application/json works as well.
+const mergePatchContentType = "application/merge-patch+json"
+
+// decodeRequest decodes the JSON body of r into v and validates it. Bodies larger than maxBodyBytes,
+// malformed JSON and unknown fields are rejected, and every field failing validation is reported at
+// once. It sends the problem response and returns false when the request is rejected.
+func decodeRequest(w http.ResponseWriter, r *http.Request, v any) bool {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err == nil {
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(v)
+	}
+
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field == "" {
+			typeErr.Field = mistypedField(body, v)
+		}
+
+		switch {
+		case errors.As(err, &tooLarge):
+			respondError(w, r, http.StatusRequestEntityTooLarge, CodeBodyTooLarge,
+				"Request body must not exceed "+strconv.Itoa(maxBodyBytes)+" bytes")
+		case typeErr != nil && typeErr.Field != "":
+			respondValidationError(w, r, []FieldError{{Field: typeErr.Field, Message: "must be " + jsonType(typeErr.Type)}})
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			field, _ := strconv.Unquote(strings.TrimPrefix(err.Error(), "json: unknown field "))
+			respondValidationError(w, r, []FieldError{{Field: field, Message: "is not a known field"}})
+		default:
+			respondError(w, r, http.StatusBadRequest, CodeInvalidRequest, "Request body is not valid JSON")
+		}
+		return false
+	}
+
+	if errs := validation.Struct(v); len(errs) > 0 {
+		respondValidationError(w, r, errs)
+		return false
+	}
+	return true
+}
+
+// mistypedField returns the JSON key of the top-level field of v whose value in body has the wrong type.
+// encoding/json does not name the field when the error comes from an UnmarshalJSON method, such as
+// the one of models.Optional.
+func mistypedField(body []byte, v any) string {
+	var members map[string]json.RawMessage
+	if json.Unmarshal(body, &members) != nil {
+		return ""
+	}
+
+	t := reflect.Indirect(reflect.ValueOf(v)).Type()
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		member, ok := members[name]
+		if !ok {
+			continue
+		}
+		var typeErr *json.UnmarshalTypeError
+		if err := json.Unmarshal(member, reflect.New(t.Field(i).Type).Interface()); errors.As(err, &typeErr) {
+			return name
+		}
+	}
+	return ""
+}
+
+// jsonType names the JSON type that decodes into t, with an article
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	default:
+		return "a number"
+	}
+}
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cloudflaredb/internal/repository"
+)
+
+func TestDecodeRequest_Handlers(t *testing.T) {
+	db := setupTestDBForRooms(t)
+	defer db.Close()
+
+	rooms := repository.NewRoomRepository(db)
+	api := &API{
+		Bookings:    NewBookingHandler(repository.NewBookingRepository(db)),
+		Recurring:   NewRecurringBookingHandler(repository.NewRecurringBookingRepository(db)),
+		Groups:      NewGroupHandler(repository.NewGroupRepository(db, rooms)),
+		Invitations: NewInvitationHandler(repository.NewInvitationRepository(db, rooms), repository.NewJoinRequestRepository(db, rooms)),
+		Locations:   NewLocationHandler(repository.NewLocationRepository(db), rooms),
+	}
+	router := NewRouter(api.Routes()...)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantCode   string
+		wantErrors []FieldError
+	}{
+		{
+			name:       "booking with every failing field",
+			method:     http.MethodPost,
+			path:       "/rooms/1/bookings",
+			body:       `{"title": "` + strings.Repeat("a", 201) + `", "ends_at": "2026-01-05T10:00:00Z"}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeValidationFailed,
+			wantErrors: []FieldError{
+				{Field: "user_id", Message: "is required"},
+				{Field: "title", Message: "must be at most 200 characters"},
+				{Field: "starts_at", Message: "is required"},
+			},
+		},
+		{
+			name:       "booking ending before it starts",
+			method:     http.MethodPost,
+			path:       "/rooms/1/bookings",
+			body:       `{"user_id": 1, "starts_at": "2026-01-05T10:00:00Z", "ends_at": "2026-01-05T09:00:00Z"}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeValidationFailed,
+			wantErrors: []FieldError{{Field: "ends_at", Message: "must be after starts_at"}},
+		},
+		{
+			name:       "booking longer than the maximum",
+			method:     http.MethodPost,
+			path:       "/rooms/1/bookings",
+			body:       `{"user_id": 1, "starts_at": "2026-01-05T09:00:00Z", "ends_at": "2028-01-05T09:00:00Z"}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeValidationFailed,
+			wantErrors: []FieldError{{Field: "ends_at", Message: "must be at most 30 days after starts_at"}},
+		},
+		{
+			name:       "recurring booking with an unknown field",
+			method:     http.MethodPost,
+			path:       "/rooms/1/recurring-bookings",
+			body:       `{"user_id": 1, "rrule": "FREQ=DAILY", "count": 3}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeValidationFailed,
+			wantErrors: []FieldError{{Field: "count", Message: "is not a known field"}},
+		},
+		{
+			name:       "recurring booking without a rule",
+			method:     http.MethodPost,
+			path:       "/rooms/1/recurring-bookings",
+			body:       `{"user_id": 1, "starts_at": "2026-01-05T09:00:00Z", "ends_at": "2026-01-05T10:00:00Z", "timezone": "Mars/Olympus"}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeValidationFailed,
+			wantErrors: []FieldError{
+				{Field: "rrule", Message: "is required"},
+				{Field: "timezone", Message: "must be an IANA time zone name"},
+			},
+		},
+		{
+			name:       "recurring booking with too many excluded dates",
+			method:     http.MethodPost,
+			path:       "/rooms/1/recurring-bookings",
+			body:       `{"user_id": 1, "starts_at": "2026-01-05T09:00:00Z", "ends_at": "2026-01-05T10:00:00Z", "rrule": "FREQ=DAILY", "exdates": [` + strings.Repeat(`"2026-01-06T09:00:00Z", `, 100) + `"2026-01-06T09:00:00Z"]}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeValidationFailed,
+			wantErrors: []FieldError{{Field: "exdates", Message: "must be at most 100 items"}},
+		},
+		{
+			name:       "recurring booking excluding a far-off date",
+			method:     http.MethodPost,
+			path:       "/rooms/1/recurring-bookings",
+			body:       `{"user_id": 1, "starts_at": "2026-01-05T09:00:00Z", "ends_at": "2026-01-05T10:00:00Z", "rrule": "FREQ=DAILY", "exdates": ["9999-01-01T09:00:00Z"]}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeValidationFailed,
+			wantErrors: []FieldError{{Field: "exdates", Message: "must be less than a year after starts_at"}},
+		},
+		{
+			name:       "counted recurring booking excluding a far-off date",
+			method:     http.MethodPost,
+			path:       "/rooms/1/recurring-bookings",
+			body:       `{"user_id": 1, "starts_at": "2026-01-05T09:00:00Z", "ends_at": "2026-01-05T10:00:00Z", "rrule": "FREQ=DAILY;COUNT=1000000000", "exdates": ["9999-01-01T09:00:00Z"]}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeValidationFailed,
+			wantErrors: []FieldError{{Field: "exdates", Message: "must be less than 100 years after starts_at"}},
+		},
+		{
+			name:       "recurring booking excluding a date the rule skips",
+			method:     http.MethodPost,
+			path:       "/rooms/1/recurring-bookings",
+			body:       `{"user_id": 1, "starts_at": "2026-01-05T09:00:00Z", "ends_at": "2026-01-05T10:00:00Z", "rrule": "FREQ=WEEKLY", "exdates": ["2026-01-12T09:00:00Z", "2026-01-13T09:00:00Z"]}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeValidationFailed,
+			wantErrors: []FieldError{{Field: "exdates", Message: "must only contain occurrences of rrule"}},
+		},
+		{
+			name:       "moved occurrence without times",
+			method:     http.MethodPut,
+			path:       "/recurring-bookings/1/occurrences/2026-01-05T09:00:00Z",
+			body:       `{}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeValidationFailed,
+			wantErrors: []FieldError{
+				{Field: "starts_at", Message: "is required"},
+				{Field: "ends_at", Message: "is required"},
+			},
+		},
+		{
+			name:       "group without a name",
+			method:     http.MethodPost,
+			path:       "/groups",
+			body:       `{"name": "  ", "description": "Platform team"}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeValidationFailed,
+			wantErrors: []FieldError{{Field: "name", Message: "is required"}},
+		},
+		{
+			name:       "group member with a mistyped user",
+			method:     http.MethodPost,
+			path:       "/groups/1/members",
+			body:       `{"user_id": "3"}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeValidationFailed,
+			wantErrors: []FieldError{{Field: "user_id", Message: "must be an integer"}},
+		},
+		{
+			name:       "invitation with a role and both addressees",
+			method:     http.MethodPost,
+			path:       "/rooms/1/invitations",
+			body:       `{"invited_by": 1, "user_id": 2, "email": "guest@example.com", "role": "admin"}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeValidationFailed,
+			wantErrors: []FieldError{
+				{Field: "role", Message: "must be one of owner, moderator, member"},
+				{Field: "email", Message: "must not be set together with user_id"},
+			},
+		},
+		{
+			name:       "invitation accepted by token without one",
+			method:     http.MethodPost,
+			path:       "/invitations/accept",
+			body:       `{"user_id": 2}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeValidationFailed,
+			wantErrors: []FieldError{{Field: "token", Message: "is required"}},
+		},
+		{
+			name:       "join request with a long message",
+			method:     http.MethodPost,
+			path:       "/rooms/1/join-requests",
+			body:       `{"message": "` + strings.Repeat("a", 501) + `"}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeValidationFailed,
+			wantErrors: []FieldError{
+				{Field: "user_id", Message: "is required"},
+				{Field: "message", Message: "must be at most 500 characters"},
+			},
+		},
+		{
+			name:       "review without a reviewer",
+			method:     http.MethodPost,
+			path:       "/join-requests/1/approve",
+			body:       `{}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeValidationFailed,
+			wantErrors: []FieldError{{Field: "reviewed_by", Message: "is required"}},
+		},
+		{
+			name:       "floor with a mistyped level",
+			method:     http.MethodPut,
+			path:       "/floors/1",
+			body:       `{"level": "one"}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeValidationFailed,
+			wantErrors: []FieldError{{Field: "level", Message: "must be an integer"}},
+		},
+		{
+			name:       "oversized site",
+			method:     http.MethodPut,
+			path:       "/sites/1",
+			body:       `{"address": "` + strings.Repeat("a", maxBodyBytes) + `"}`,
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantCode:   CodeBodyTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			p := decodeProblem(t, w)
+			if p.Code != tt.wantCode {
+				t.Errorf("Expected code %s, got %s", tt.wantCode, p.Code)
+			}
+			if fmt.Sprint(p.Errors) != fmt.Sprint(tt.wantErrors) {
+				t.Errorf("Expected errors %v, got %v", tt.wantErrors, p.Errors)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// contentETag returns a strong entity tag derived from the response body
+func contentETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// versionETag returns the strong entity tag of a user or room version
+func versionETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header value matches etag. Weak tags compare
+// equal to their strong counterparts, as RFC 9110 requires for If-None-Match.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// notModified sets the ETag header and sends 304 Not Modified when the If-None-Match header of r
+// names etag. It reports whether the response was sent.
+func notModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+	if header := r.Header.Get("If-None-Match"); header != "" && etagMatches(header, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// respondWithETag writes body with an ETag header, or 304 Not Modified when the client already has it
+func respondWithETag(w http.ResponseWriter, r *http.Request, contentType string, body []byte) {
+	// Clients may keep the response but must revalidate it, which costs a 304 when nothing changed
+	w.Header().Set("Cache-Control", "no-cache")
+	if notModified(w, r, contentETag(body)) {
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// respondVersioned sends data as JSON with the ETag of version. GET requests whose If-None-Match
+// header names the ETag get 304 Not Modified instead.
+func respondVersioned(w http.ResponseWriter, r *http.Request, status int, version int64, data interface{}) {
+	etag := versionETag(version)
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		if notModified(w, r, etag) {
+			return
+		}
+	}
+	w.Header().Set("ETag", etag)
+	respondJSON(w, status, data)
+}
+
+// ifMatchVersion returns the version required by the If-Match header of r, or 0 when there is no
+// header or it is "*", which any current version satisfies. The header must name a single ETag
+// returned by respondVersioned; anything else cannot match, so it sends 412 Precondition Failed
+// and returns false.
+func ifMatchVersion(w http.ResponseWriter, r *http.Request, resource string) (int64, bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0, true
+	}
+
+	// Weak tags never match If-Match, so only a quoted version is accepted
+	value, opened := strings.CutPrefix(header, `"`)
+	value, closed := strings.CutSuffix(value, `"`)
+	version, err := strconv.ParseInt(value, 10, 64)
+	if !opened || !closed || err != nil || version <= 0 {
+		respondError(w, r, http.StatusPreconditionFailed, CodePreconditionFailed,
+			"If-Match does not name the current ETag of the "+resource)
+		return 0, false
+	}
+	return version, true
+}
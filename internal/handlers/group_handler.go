@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"cloudflaredb/internal/models"
+	"cloudflaredb/internal/repository"
+)
+
+// GroupHandler handles HTTP requests for groups and their room assignments
+type GroupHandler struct {
+	repo *repository.GroupRepository
+}
+
+// NewGroupHandler creates a new group handler
+func NewGroupHandler(repo *repository.GroupRepository) *GroupHandler {
+	return &GroupHandler{repo: repo}
+}
+
+// CreateGroup handles POST /groups
+func (h *GroupHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateGroupRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	group, err := h.repo.Create(r.Context(), &req)
+	if err != nil {
+		respondGroupError(w, r, "create group", err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, group)
+}
+
+// ListGroups handles GET /groups
+func (h *GroupHandler) ListGroups(w http.ResponseWriter, r *http.Request) {
+	groups, err := h.repo.List(r.Context())
+	if err != nil {
+		respondInternalError(w, r, "list groups", err)
+		return
+	}
+
+	if groups == nil {
+		groups = []*models.Group{}
+	}
+
+	respondJSON(w, http.StatusOK, groups)
+}
+
+// GetGroup handles GET /groups/{id}
+func (h *GroupHandler) GetGroup(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "group")
+	if !ok {
+		return
+	}
+
+	group, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		respondGroupError(w, r, "get group", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, group)
+}
+
+// UpdateGroup handles PUT /groups/{id}
+func (h *GroupHandler) UpdateGroup(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "group")
+	if !ok {
+		return
+	}
+
+	var req models.UpdateGroupRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	group, err := h.repo.Update(r.Context(), id, &req)
+	if err != nil {
+		respondGroupError(w, r, "update group", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, group)
+}
+
+// DeleteGroup handles DELETE /groups/{id}
+func (h *GroupHandler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "group")
+	if !ok {
+		return
+	}
+
+	if err := h.repo.Delete(r.Context(), id); err != nil {
+		respondGroupError(w, r, "delete group", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListMembers handles GET /groups/{id}/members
+func (h *GroupHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "group")
+	if !ok {
+		return
+	}
+
+	users, err := h.repo.Members(r.Context(), id)
+	if err != nil {
+		respondGroupError(w, r, "list group members", err)
+		return
+	}
+
+	if users == nil {
+		users = []*models.User{}
+	}
+
+	respondJSON(w, http.StatusOK, users)
+}
+
+// AddMember handles POST /groups/{id}/members
+func (h *GroupHandler) AddMember(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "group")
+	if !ok {
+		return
+	}
+
+	var req models.AddGroupMemberRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	member, err := h.repo.AddMember(r.Context(), id, req.UserID)
+	if err != nil {
+		respondGroupError(w, r, "add group member", err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, member)
+}
+
+// RemoveMember handles DELETE /groups/{id}/members/{userId}
+func (h *GroupHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "group")
+	if !ok {
+		return
+	}
+
+	userID, ok := pathID(w, r, "userId", "user")
+	if !ok {
+		return
+	}
+
+	if err := h.repo.RemoveMember(r.Context(), id, userID); err != nil {
+		respondGroupError(w, r, "remove group member", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListRoomGroups handles GET /rooms/{id}/groups
+func (h *GroupHandler) ListRoomGroups(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := pathID(w, r, "id", "room")
+	if !ok {
+		return
+	}
+
+	groups, err := h.repo.RoomGroups(r.Context(), roomID)
+	if err != nil {
+		respondGroupError(w, r, "list room groups", err)
+		return
+	}
+
+	if groups == nil {
+		groups = []*models.Group{}
+	}
+
+	respondJSON(w, http.StatusOK, groups)
+}
+
+// AssignGroupToRoom handles POST /rooms/{id}/groups
+func (h *GroupHandler) AssignGroupToRoom(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := pathID(w, r, "id", "room")
+	if !ok {
+		return
+	}
+
+	var req models.AssignGroupToRoomRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	assignment, err := h.repo.AssignToRoom(r.Context(), roomID, req.GroupID)
+	if err != nil {
+		respondGroupError(w, r, "assign group to room", err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, assignment)
+}
+
+// RemoveGroupFromRoom handles DELETE /rooms/{id}/groups/{groupId}
+func (h *GroupHandler) RemoveGroupFromRoom(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := pathID(w, r, "id", "room")
+	if !ok {
+		return
+	}
+
+	groupID, ok := pathID(w, r, "groupId", "group")
+	if !ok {
+		return
+	}
+
+	if err := h.repo.RemoveFromRoom(r.Context(), roomID, groupID); err != nil {
+		respondGroupError(w, r, "remove group from room", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// respondGroupError maps group repository errors to problem responses
+func respondGroupError(w http.ResponseWriter, r *http.Request, action string, err error) {
+	switch {
+	case errors.Is(err, repository.ErrRoomFull):
+		respondError(w, r, http.StatusConflict, CodeRoomFull,
+			"Not enough free seats for every member of the group; rooms with a waitlist only take users already in them")
+	case errors.Is(err, repository.ErrAlreadyGroupMember):
+		respondError(w, r, http.StatusConflict, CodeConflict, "User already in this group")
+	case errors.Is(err, repository.ErrGroupAlreadyAssigned):
+		respondError(w, r, http.StatusConflict, CodeConflict, "Group already assigned to this room")
+	case errors.Is(err, repository.ErrConflict):
+		respondError(w, r, http.StatusConflict, CodeConflict, "A group with this name already exists")
+	case errors.Is(err, repository.ErrGroupNotFound):
+		respondError(w, r, http.StatusNotFound, CodeNotFound, "Group not found")
+	case errors.Is(err, repository.ErrRoomNotFound):
+		respondError(w, r, http.StatusNotFound, CodeNotFound, "Room not found")
+	case errors.Is(err, repository.ErrUserNotFound):
+		respondError(w, r, http.StatusNotFound, CodeNotFound, "User not found")
+	case errors.Is(err, repository.ErrNotGroupMember):
+		respondError(w, r, http.StatusNotFound, CodeNotFound, "User not in this group")
+	case errors.Is(err, repository.ErrGroupNotAssigned):
+		respondError(w, r, http.StatusNotFound, CodeNotFound, "Group not assigned to this room")
+	default:
+		respondInternalError(w, r, action, err)
+	}
+}
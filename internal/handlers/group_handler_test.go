@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"cloudflaredb/internal/models"
+	"cloudflaredb/internal/repository"
+)
+
+func TestGroupHandler_RoomAssignment(t *testing.T) {
+	db := setupTestDBForRooms(t)
+	defer db.Close()
+
+	roomRepo := repository.NewRoomRepository(db)
+	userRepo := repository.NewUserRepository(db, roomRepo)
+	handler := NewGroupHandler(repository.NewGroupRepository(db, roomRepo))
+	roomHandler := NewRoomHandler(roomRepo)
+	router := NewRouter((&API{Groups: handler, Rooms: roomHandler}).Routes()...)
+
+	ctx := context.Background()
+	alice, _ := userRepo.Create(ctx, &models.CreateUserRequest{Email: "alice@example.com", Name: "Alice"})
+	bob, _ := userRepo.Create(ctx, &models.CreateUserRequest{Email: "bob@example.com", Name: "Bob"})
+	room, _ := roomRepo.Create(ctx, &models.CreateRoomRequest{Name: "Project Room", Capacity: 2})
+	booth, _ := roomRepo.Create(ctx, &models.CreateRoomRequest{Name: "Phone Booth", Capacity: 1})
+
+	do := func(method, path string, payload interface{}) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		if payload != nil {
+			json.NewEncoder(&body).Encode(payload)
+		}
+		req := httptest.NewRequest(method, path, &body)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/groups", models.CreateGroupRequest{})
+	if w.Code != http.StatusBadRequest || decodeProblem(t, w).Code != CodeValidationFailed {
+		t.Errorf("Expected a validation problem without a name, got %d", w.Code)
+	}
+
+	w = do(http.MethodPost, "/groups", models.CreateGroupRequest{Name: "Platform"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var group models.Group
+	json.NewDecoder(w.Body).Decode(&group)
+
+	membersPath := fmt.Sprintf("/groups/%d/members", group.ID)
+	for _, user := range []*models.User{alice, bob} {
+		w = do(http.MethodPost, membersPath, models.AddGroupMemberRequest{UserID: user.ID})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+	}
+	w = do(http.MethodPost, membersPath, models.AddGroupMemberRequest{UserID: 999})
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for an unknown user, got %d", http.StatusNotFound, w.Code)
+	}
+
+	// The whole group has to fit
+	w = do(http.MethodPost, fmt.Sprintf("/rooms/%d/groups", booth.ID), models.AssignGroupToRoomRequest{GroupID: group.ID})
+	if w.Code != http.StatusConflict || decodeProblem(t, w).Code != CodeRoomFull {
+		t.Errorf("Expected a %s problem, got %d", CodeRoomFull, w.Code)
+	}
+
+	roomGroupsPath := fmt.Sprintf("/rooms/%d/groups", room.ID)
+	w = do(http.MethodPost, roomGroupsPath, models.AssignGroupToRoomRequest{GroupID: group.ID})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	var groups []models.Group
+	w = do(http.MethodGet, roomGroupsPath, nil)
+	json.NewDecoder(w.Body).Decode(&groups)
+	if w.Code != http.StatusOK || len(groups) != 1 || groups[0].ID != group.ID {
+		t.Errorf("Expected the assigned group, got %d %+v", w.Code, groups)
+	}
+
+	// Members reach the room through the group
+	var rooms []models.Room
+	w = do(http.MethodGet, fmt.Sprintf("/users/%d/rooms", bob.ID), nil)
+	json.NewDecoder(w.Body).Decode(&rooms)
+	if w.Code != http.StatusOK || len(rooms) != 1 || rooms[0].ID != room.ID {
+		t.Errorf("Expected the room reached through the group, got %d %+v", w.Code, rooms)
+	}
+
+	w = do(http.MethodDelete, fmt.Sprintf("%s/%d", roomGroupsPath, group.ID), nil)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected status %d, got %d", http.StatusNoContent, w.Code)
+	}
+	w = do(http.MethodDelete, fmt.Sprintf("%s/%d", roomGroupsPath, group.ID), nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d once removed, got %d", http.StatusNotFound, w.Code)
+	}
+
+	w = do(http.MethodDelete, fmt.Sprintf("%s/%d", membersPath, alice.ID), nil)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected status %d, got %d", http.StatusNoContent, w.Code)
+	}
+
+	var members []models.User
+	w = do(http.MethodGet, membersPath, nil)
+	json.NewDecoder(w.Body).Decode(&members)
+	if len(members) != 1 || members[0].ID != bob.ID {
+		t.Errorf("Expected only Bob to be left, got %+v", members)
+	}
+}
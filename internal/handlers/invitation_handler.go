@@ -0,0 +1,317 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"cloudflaredb/internal/models"
+	"cloudflaredb/internal/repository"
+)
+
+// InvitationHandler handles HTTP requests for room invitations and join requests
+type InvitationHandler struct {
+	invitations  *repository.InvitationRepository
+	joinRequests *repository.JoinRequestRepository
+}
+
+// NewInvitationHandler creates a new invitation handler
+func NewInvitationHandler(invitations *repository.InvitationRepository, joinRequests *repository.JoinRequestRepository) *InvitationHandler {
+	return &InvitationHandler{invitations: invitations, joinRequests: joinRequests}
+}
+
+// CreateInvitation handles POST /rooms/{id}/invitations
+func (h *InvitationHandler) CreateInvitation(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := pathID(w, r, "id", "room")
+	if !ok {
+		return
+	}
+
+	var req models.CreateInvitationRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	inv, err := h.invitations.Create(r.Context(), roomID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotRoomOwner):
+			respondError(w, r, http.StatusForbidden, CodeForbidden, "Only owners of the room can invite users")
+		case errors.Is(err, repository.ErrAlreadyAssigned):
+			respondError(w, r, http.StatusConflict, CodeConflict, "User already assigned to this room")
+		case errors.Is(err, repository.ErrRoomNotFound):
+			respondError(w, r, http.StatusNotFound, CodeNotFound, "Room not found")
+		case errors.Is(err, repository.ErrUserNotFound):
+			respondError(w, r, http.StatusNotFound, CodeNotFound, "User not found")
+		default:
+			respondInternalError(w, r, "create invitation", err)
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, inv)
+}
+
+// ListRoomInvitations handles GET /rooms/{id}/invitations
+func (h *InvitationHandler) ListRoomInvitations(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := pathID(w, r, "id", "room")
+	if !ok {
+		return
+	}
+
+	invitations, err := h.invitations.ListByRoom(r.Context(), roomID)
+	if err != nil {
+		if errors.Is(err, repository.ErrRoomNotFound) {
+			respondError(w, r, http.StatusNotFound, CodeNotFound, "Room not found")
+			return
+		}
+		respondInternalError(w, r, "list invitations", err)
+		return
+	}
+
+	if invitations == nil {
+		invitations = []*models.Invitation{}
+	}
+
+	respondJSON(w, http.StatusOK, invitations)
+}
+
+// ListUserInvitations handles GET /users/{id}/invitations
+func (h *InvitationHandler) ListUserInvitations(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathID(w, r, "id", "user")
+	if !ok {
+		return
+	}
+
+	invitations, err := h.invitations.ListForUser(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			respondError(w, r, http.StatusNotFound, CodeNotFound, "User not found")
+			return
+		}
+		respondInternalError(w, r, "list invitations", err)
+		return
+	}
+
+	if invitations == nil {
+		invitations = []*models.Invitation{}
+	}
+
+	respondJSON(w, http.StatusOK, invitations)
+}
+
+// AcceptInvitation handles POST /invitations/{id}/accept, and POST /invitations/accept with a token
+func (h *InvitationHandler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	byToken := r.PathValue("id") == ""
+
+	var id int64
+	if !byToken {
+		var ok bool
+		if id, ok = pathID(w, r, "id", "invitation"); !ok {
+			return
+		}
+	}
+
+	var membership *models.UserRoom
+	var err error
+	if byToken {
+		var req models.AcceptInvitationByTokenRequest
+		if !decodeRequest(w, r, &req) {
+			return
+		}
+		membership, err = h.invitations.AcceptByToken(r.Context(), req.Token, req.UserID)
+	} else {
+		var req models.RespondInvitationRequest
+		if !decodeRequest(w, r, &req) {
+			return
+		}
+		membership, err = h.invitations.Accept(r.Context(), id, req.UserID)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrRoomFull):
+			respondError(w, r, http.StatusConflict, CodeRoomFull,
+				"Room has reached its capacity; the invitation stays open until a seat is free")
+		case errors.Is(err, repository.ErrAlreadyAssigned):
+			respondError(w, r, http.StatusConflict, CodeConflict, "User already assigned to this room")
+		default:
+			respondInvitationError(w, r, "accept invitation", err)
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, membership)
+}
+
+// DeclineInvitation handles POST /invitations/{id}/decline
+func (h *InvitationHandler) DeclineInvitation(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "invitation")
+	if !ok {
+		return
+	}
+
+	var req models.RespondInvitationRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	inv, err := h.invitations.Decline(r.Context(), id, req.UserID)
+	if err != nil {
+		respondInvitationError(w, r, "decline invitation", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, inv)
+}
+
+// respondInvitationError maps the errors of answering an invitation to problem responses
+func respondInvitationError(w http.ResponseWriter, r *http.Request, action string, err error) {
+	switch {
+	case errors.Is(err, repository.ErrInvitationNotFound):
+		respondError(w, r, http.StatusNotFound, CodeNotFound, "Invitation not found")
+	case errors.Is(err, repository.ErrUserNotFound):
+		respondError(w, r, http.StatusNotFound, CodeNotFound, "User not found")
+	case errors.Is(err, repository.ErrNotInvitee):
+		respondError(w, r, http.StatusForbidden, CodeForbidden, "Invitation is addressed to another user")
+	case errors.Is(err, repository.ErrInvitationExpired):
+		respondError(w, r, http.StatusGone, CodeExpired, "Invitation has expired")
+	case errors.Is(err, repository.ErrInvitationClosed):
+		respondError(w, r, http.StatusConflict, CodeConflict, "Invitation was already answered")
+	default:
+		respondInternalError(w, r, action, err)
+	}
+}
+
+// CreateJoinRequest handles POST /rooms/{id}/join-requests
+func (h *InvitationHandler) CreateJoinRequest(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := pathID(w, r, "id", "room")
+	if !ok {
+		return
+	}
+
+	var req models.CreateJoinRequestRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	joinRequest, err := h.joinRequests.Create(r.Context(), roomID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrJoinRequestPending):
+			respondError(w, r, http.StatusConflict, CodeConflict, "User already has a pending request to join this room")
+		case errors.Is(err, repository.ErrAlreadyAssigned):
+			respondError(w, r, http.StatusConflict, CodeConflict, "User already assigned to this room")
+		case errors.Is(err, repository.ErrRoomNotFound):
+			respondError(w, r, http.StatusNotFound, CodeNotFound, "Room not found")
+		case errors.Is(err, repository.ErrUserNotFound):
+			respondError(w, r, http.StatusNotFound, CodeNotFound, "User not found")
+		default:
+			respondInternalError(w, r, "create join request", err)
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, joinRequest)
+}
+
+// ListJoinRequests handles GET /rooms/{id}/join-requests?status=
+func (h *InvitationHandler) ListJoinRequests(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := pathID(w, r, "id", "room")
+	if !ok {
+		return
+	}
+
+	q := newQueryParams(r.URL.Query())
+	status := q.Enum("status", models.JoinRequestStatusPending, models.JoinRequestStatusApproved, models.JoinRequestStatusRejected)
+	if errs := q.Errors(); len(errs) > 0 {
+		respondValidationError(w, r, errs)
+		return
+	}
+
+	requests, err := h.joinRequests.ListByRoom(r.Context(), roomID, status)
+	if err != nil {
+		if errors.Is(err, repository.ErrRoomNotFound) {
+			respondError(w, r, http.StatusNotFound, CodeNotFound, "Room not found")
+			return
+		}
+		respondInternalError(w, r, "list join requests", err)
+		return
+	}
+
+	if requests == nil {
+		requests = []*models.JoinRequest{}
+	}
+
+	respondJSON(w, http.StatusOK, requests)
+}
+
+// ApproveJoinRequest handles POST /join-requests/{id}/approve
+func (h *InvitationHandler) ApproveJoinRequest(w http.ResponseWriter, r *http.Request) {
+	id, reviewerID, ok := h.reviewRequest(w, r)
+	if !ok {
+		return
+	}
+
+	membership, err := h.joinRequests.Approve(r.Context(), id, reviewerID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrRoomFull):
+			respondError(w, r, http.StatusConflict, CodeRoomFull,
+				"Room has reached its capacity; the request stays pending until a seat is free")
+		case errors.Is(err, repository.ErrAlreadyAssigned):
+			respondError(w, r, http.StatusConflict, CodeConflict, "User already assigned to this room")
+		default:
+			respondReviewError(w, r, "approve join request", err)
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, membership)
+}
+
+// RejectJoinRequest handles POST /join-requests/{id}/reject
+func (h *InvitationHandler) RejectJoinRequest(w http.ResponseWriter, r *http.Request) {
+	id, reviewerID, ok := h.reviewRequest(w, r)
+	if !ok {
+		return
+	}
+
+	joinRequest, err := h.joinRequests.Reject(r.Context(), id, reviewerID)
+	if err != nil {
+		respondReviewError(w, r, "reject join request", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, joinRequest)
+}
+
+// reviewRequest parses the join request ID and the reviewer of an approve or reject request.
+// It sends a problem response and returns false when either is invalid.
+func (h *InvitationHandler) reviewRequest(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	id, ok := pathID(w, r, "id", "join request")
+	if !ok {
+		return 0, 0, false
+	}
+
+	var req models.ReviewJoinRequestRequest
+	if !decodeRequest(w, r, &req) {
+		return 0, 0, false
+	}
+
+	return id, req.ReviewedBy, true
+}
+
+// respondReviewError maps the errors of reviewing a join request to problem responses
+func respondReviewError(w http.ResponseWriter, r *http.Request, action string, err error) {
+	switch {
+	case errors.Is(err, repository.ErrJoinRequestNotFound):
+		respondError(w, r, http.StatusNotFound, CodeNotFound, "Join request not found")
+	case errors.Is(err, repository.ErrNotRoomModerator):
+		respondError(w, r, http.StatusForbidden, CodeForbidden, "Only owners and moderators of the room can review join requests")
+	case errors.Is(err, repository.ErrJoinRequestClosed):
+		respondError(w, r, http.StatusConflict, CodeConflict, "Join request was already reviewed")
+	case errors.Is(err, repository.ErrUserNotFound):
+		respondError(w, r, http.StatusNotFound, CodeNotFound, "User not found")
+	default:
+		respondInternalError(w, r, action, err)
+	}
+}
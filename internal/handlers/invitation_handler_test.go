@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"cloudflaredb/internal/models"
+	"cloudflaredb/internal/repository"
+)
+
+func TestInvitationHandler_Invitations(t *testing.T) {
+	db := setupTestDBForRooms(t)
+	defer db.Close()
+
+	roomRepo := repository.NewRoomRepository(db)
+	userRepo := repository.NewUserRepository(db, roomRepo)
+	handler := NewInvitationHandler(repository.NewInvitationRepository(db, roomRepo), repository.NewJoinRequestRepository(db, roomRepo))
+	router := NewRouter((&API{Invitations: handler}).Routes()...)
+
+	ctx := context.Background()
+
+	owner, _ := userRepo.Create(ctx, &models.CreateUserRequest{Email: "owner@example.com", Name: "Owner"})
+	guest, _ := userRepo.Create(ctx, &models.CreateUserRequest{Email: "guest@example.com", Name: "Guest"})
+	room, _ := roomRepo.Create(ctx, &models.CreateRoomRequest{Name: "Team Room", Capacity: 5})
+	if _, err := roomRepo.AssignUserToRoom(ctx, owner.ID, room.ID, ""); err != nil {
+		t.Fatalf("Failed to assign owner: %v", err)
+	}
+
+	post := func(path string, payload interface{}) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(payload)
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	invitationsPath := fmt.Sprintf("/rooms/%d/invitations", room.ID)
+
+	// Validation
+	w := post(invitationsPath, models.CreateInvitationRequest{InvitedBy: owner.ID})
+	if w.Code != http.StatusBadRequest || decodeProblem(t, w).Code != CodeValidationFailed {
+		t.Errorf("Expected a validation problem without an invitee, got %d", w.Code)
+	}
+
+	// Only owners invite
+	w = post(invitationsPath, models.CreateInvitationRequest{InvitedBy: guest.ID, UserID: guest.ID})
+	if w.Code != http.StatusForbidden || decodeProblem(t, w).Code != CodeForbidden {
+		t.Errorf("Expected a %s problem for a non-owner, got %d", CodeForbidden, w.Code)
+	}
+
+	expiresAt := time.Now().Add(time.Hour)
+	w = post(invitationsPath, models.CreateInvitationRequest{InvitedBy: owner.ID, Email: "guest@example.com", ExpiresAt: &expiresAt})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var inv models.Invitation
+	json.NewDecoder(w.Body).Decode(&inv)
+	if inv.Token == "" || inv.Status != models.InvitationStatusPending {
+		t.Errorf("Unexpected invitation: %+v", inv)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/users/%d/invitations", guest.ID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var listed []models.Invitation
+	json.NewDecoder(w.Body).Decode(&listed)
+	if w.Code != http.StatusOK || len(listed) != 1 || listed[0].Token != "" {
+		t.Errorf("Expected the invitation without its token, got %d %+v", w.Code, listed)
+	}
+
+	// Someone else cannot accept by ID
+	w = post(fmt.Sprintf("/invitations/%d/accept", inv.ID), models.RespondInvitationRequest{UserID: owner.ID})
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d for another user, got %d", http.StatusForbidden, w.Code)
+	}
+
+	w = post("/invitations/accept", models.AcceptInvitationByTokenRequest{UserID: guest.ID, Token: inv.Token})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var membership models.UserRoom
+	json.NewDecoder(w.Body).Decode(&membership)
+	if membership.UserID != guest.ID || membership.Role != models.RoleMember {
+		t.Errorf("Unexpected membership: %+v", membership)
+	}
+
+	w = post(fmt.Sprintf("/invitations/%d/decline", inv.ID), models.RespondInvitationRequest{UserID: guest.ID})
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d for an answered invitation, got %d", http.StatusConflict, w.Code)
+	}
+	w = post("/invitations/999/decline", models.RespondInvitationRequest{UserID: guest.ID})
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for a missing invitation, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestInvitationHandler_JoinRequests(t *testing.T) {
+	db := setupTestDBForRooms(t)
+	defer db.Close()
+
+	roomRepo := repository.NewRoomRepository(db)
+	userRepo := repository.NewUserRepository(db, roomRepo)
+	handler := NewInvitationHandler(repository.NewInvitationRepository(db, roomRepo), repository.NewJoinRequestRepository(db, roomRepo))
+	router := NewRouter((&API{Invitations: handler}).Routes()...)
+
+	ctx := context.Background()
+
+	owner, _ := userRepo.Create(ctx, &models.CreateUserRequest{Email: "owner@example.com", Name: "Owner"})
+	guest, _ := userRepo.Create(ctx, &models.CreateUserRequest{Email: "guest@example.com", Name: "Guest"})
+	room, _ := roomRepo.Create(ctx, &models.CreateRoomRequest{Name: "Team Room", Capacity: 5})
+	if _, err := roomRepo.AssignUserToRoom(ctx, owner.ID, room.ID, ""); err != nil {
+		t.Fatalf("Failed to assign owner: %v", err)
+	}
+
+	post := func(path string, payload interface{}) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(payload)
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	joinPath := fmt.Sprintf("/rooms/%d/join-requests", room.ID)
+
+	w := post(joinPath, models.CreateJoinRequestRequest{UserID: guest.ID, Message: "Hi"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var joinRequest models.JoinRequest
+	json.NewDecoder(w.Body).Decode(&joinRequest)
+
+	if w := post(joinPath, models.CreateJoinRequestRequest{UserID: guest.ID}); w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d for a second pending request, got %d", http.StatusConflict, w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, joinPath+"?status=pending", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var pending []models.JoinRequest
+	json.NewDecoder(w.Body).Decode(&pending)
+	if w.Code != http.StatusOK || len(pending) != 1 {
+		t.Errorf("Expected one pending request, got %d %+v", w.Code, pending)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, joinPath+"?status=unknown", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an unknown status, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	approvePath := fmt.Sprintf("/join-requests/%d/approve", joinRequest.ID)
+	if w := post(approvePath, models.ReviewJoinRequestRequest{ReviewedBy: guest.ID}); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d for a non-moderator, got %d", http.StatusForbidden, w.Code)
+	}
+
+	w = post(approvePath, models.ReviewJoinRequestRequest{ReviewedBy: owner.ID})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	w = post(fmt.Sprintf("/join-requests/%d/reject", joinRequest.ID), models.ReviewJoinRequestRequest{ReviewedBy: owner.ID})
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d for a reviewed request, got %d", http.StatusConflict, w.Code)
+	}
+}
@@ -0,0 +1,350 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"cloudflaredb/internal/models"
+	"cloudflaredb/internal/repository"
+)
+
+// LocationHandler handles HTTP requests for sites, buildings and floors
+type LocationHandler struct {
+	repo  *repository.LocationRepository
+	rooms *repository.RoomRepository
+}
+
+// NewLocationHandler creates a new location handler
+func NewLocationHandler(repo *repository.LocationRepository, rooms *repository.RoomRepository) *LocationHandler {
+	return &LocationHandler{repo: repo, rooms: rooms}
+}
+
+// CreateSite handles POST /sites
+func (h *LocationHandler) CreateSite(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateSiteRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	site, err := h.repo.CreateSite(r.Context(), &req)
+	if err != nil {
+		respondLocationError(w, r, "create site", err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, site)
+}
+
+// ListSites handles GET /sites
+func (h *LocationHandler) ListSites(w http.ResponseWriter, r *http.Request) {
+	sites, err := h.repo.ListSites(r.Context())
+	if err != nil {
+		respondInternalError(w, r, "list sites", err)
+		return
+	}
+
+	if sites == nil {
+		sites = []*models.Site{}
+	}
+
+	respondJSON(w, http.StatusOK, sites)
+}
+
+// GetSite handles GET /sites/{id}
+func (h *LocationHandler) GetSite(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "site")
+	if !ok {
+		return
+	}
+
+	site, err := h.repo.GetSite(r.Context(), id)
+	if err != nil {
+		respondLocationError(w, r, "get site", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, site)
+}
+
+// UpdateSite handles PUT /sites/{id}
+func (h *LocationHandler) UpdateSite(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "site")
+	if !ok {
+		return
+	}
+
+	var req models.UpdateSiteRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	site, err := h.repo.UpdateSite(r.Context(), id, &req)
+	if err != nil {
+		respondLocationError(w, r, "update site", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, site)
+}
+
+// DeleteSite handles DELETE /sites/{id}
+func (h *LocationHandler) DeleteSite(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "site")
+	if !ok {
+		return
+	}
+
+	if err := h.repo.DeleteSite(r.Context(), id); err != nil {
+		respondLocationError(w, r, "delete site", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateBuilding handles POST /sites/{id}/buildings
+func (h *LocationHandler) CreateBuilding(w http.ResponseWriter, r *http.Request) {
+	siteID, ok := pathID(w, r, "id", "site")
+	if !ok {
+		return
+	}
+
+	var req models.CreateBuildingRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	building, err := h.repo.CreateBuilding(r.Context(), siteID, &req)
+	if err != nil {
+		respondLocationError(w, r, "create building", err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, building)
+}
+
+// ListBuildings handles GET /sites/{id}/buildings
+func (h *LocationHandler) ListBuildings(w http.ResponseWriter, r *http.Request) {
+	siteID, ok := pathID(w, r, "id", "site")
+	if !ok {
+		return
+	}
+
+	buildings, err := h.repo.ListBuildings(r.Context(), siteID)
+	if err != nil {
+		respondLocationError(w, r, "list buildings", err)
+		return
+	}
+
+	if buildings == nil {
+		buildings = []*models.Building{}
+	}
+
+	respondJSON(w, http.StatusOK, buildings)
+}
+
+// GetBuilding handles GET /buildings/{id}
+func (h *LocationHandler) GetBuilding(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "building")
+	if !ok {
+		return
+	}
+
+	building, err := h.repo.GetBuilding(r.Context(), id)
+	if err != nil {
+		respondLocationError(w, r, "get building", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, building)
+}
+
+// UpdateBuilding handles PUT /buildings/{id}
+func (h *LocationHandler) UpdateBuilding(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "building")
+	if !ok {
+		return
+	}
+
+	var req models.UpdateBuildingRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	building, err := h.repo.UpdateBuilding(r.Context(), id, &req)
+	if err != nil {
+		respondLocationError(w, r, "update building", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, building)
+}
+
+// DeleteBuilding handles DELETE /buildings/{id}
+func (h *LocationHandler) DeleteBuilding(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "building")
+	if !ok {
+		return
+	}
+
+	if err := h.repo.DeleteBuilding(r.Context(), id); err != nil {
+		respondLocationError(w, r, "delete building", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateFloor handles POST /buildings/{id}/floors
+func (h *LocationHandler) CreateFloor(w http.ResponseWriter, r *http.Request) {
+	buildingID, ok := pathID(w, r, "id", "building")
+	if !ok {
+		return
+	}
+
+	var req models.CreateFloorRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	floor, err := h.repo.CreateFloor(r.Context(), buildingID, &req)
+	if err != nil {
+		respondLocationError(w, r, "create floor", err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, floor)
+}
+
+// ListFloors handles GET /buildings/{id}/floors
+func (h *LocationHandler) ListFloors(w http.ResponseWriter, r *http.Request) {
+	buildingID, ok := pathID(w, r, "id", "building")
+	if !ok {
+		return
+	}
+
+	floors, err := h.repo.ListFloors(r.Context(), buildingID)
+	if err != nil {
+		respondLocationError(w, r, "list floors", err)
+		return
+	}
+
+	if floors == nil {
+		floors = []*models.Floor{}
+	}
+
+	respondJSON(w, http.StatusOK, floors)
+}
+
+// GetFloor handles GET /floors/{id}
+func (h *LocationHandler) GetFloor(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "floor")
+	if !ok {
+		return
+	}
+
+	floor, err := h.repo.GetFloor(r.Context(), id)
+	if err != nil {
+		respondLocationError(w, r, "get floor", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, floor)
+}
+
+// UpdateFloor handles PUT /floors/{id}
+func (h *LocationHandler) UpdateFloor(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "floor")
+	if !ok {
+		return
+	}
+
+	var req models.UpdateFloorRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	floor, err := h.repo.UpdateFloor(r.Context(), id, &req)
+	if err != nil {
+		respondLocationError(w, r, "update floor", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, floor)
+}
+
+// DeleteFloor handles DELETE /floors/{id}
+func (h *LocationHandler) DeleteFloor(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "floor")
+	if !ok {
+		return
+	}
+
+	if err := h.repo.DeleteFloor(r.Context(), id); err != nil {
+		respondLocationError(w, r, "delete floor", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListSiteRooms handles GET /sites/{id}/rooms
+func (h *LocationHandler) ListSiteRooms(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "site")
+	if !ok {
+		return
+	}
+
+	if _, err := h.repo.GetSite(r.Context(), id); err != nil {
+		respondLocationError(w, r, "get site", err)
+		return
+	}
+
+	listRooms(w, r, h.rooms, repository.RoomFilter{SiteID: id})
+}
+
+// ListBuildingRooms handles GET /buildings/{id}/rooms
+func (h *LocationHandler) ListBuildingRooms(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "building")
+	if !ok {
+		return
+	}
+
+	if _, err := h.repo.GetBuilding(r.Context(), id); err != nil {
+		respondLocationError(w, r, "get building", err)
+		return
+	}
+
+	listRooms(w, r, h.rooms, repository.RoomFilter{BuildingID: id})
+}
+
+// ListFloorRooms handles GET /floors/{id}/rooms
+func (h *LocationHandler) ListFloorRooms(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "floor")
+	if !ok {
+		return
+	}
+
+	if _, err := h.repo.GetFloor(r.Context(), id); err != nil {
+		respondLocationError(w, r, "get floor", err)
+		return
+	}
+
+	listRooms(w, r, h.rooms, repository.RoomFilter{FloorID: id})
+}
+
+// respondLocationError maps location repository errors to problem responses
+func respondLocationError(w http.ResponseWriter, r *http.Request, action string, err error) {
+	switch {
+	case errors.Is(err, repository.ErrSiteNotFound):
+		respondError(w, r, http.StatusNotFound, CodeNotFound, "Site not found")
+	case errors.Is(err, repository.ErrBuildingNotFound):
+		respondError(w, r, http.StatusNotFound, CodeNotFound, "Building not found")
+	case errors.Is(err, repository.ErrFloorNotFound):
+		respondError(w, r, http.StatusNotFound, CodeNotFound, "Floor not found")
+	case errors.Is(err, repository.ErrConflict):
+		respondError(w, r, http.StatusConflict, CodeConflict, "A location with this name or level already exists here")
+	default:
+		respondInternalError(w, r, action, err)
+	}
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"cloudflaredb/internal/models"
+	"cloudflaredb/internal/repository"
+)
+
+func TestLocationHandler_Hierarchy(t *testing.T) {
+	db := setupTestDBForRooms(t)
+	defer db.Close()
+
+	roomRepo := repository.NewRoomRepository(db)
+	handler := NewLocationHandler(repository.NewLocationRepository(db), roomRepo)
+	roomHandler := NewRoomHandler(roomRepo)
+	router := NewRouter((&API{Locations: handler, Rooms: roomHandler}).Routes()...)
+
+	do := func(method, path string, payload interface{}) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		if payload != nil {
+			json.NewEncoder(&body).Encode(payload)
+		}
+		req := httptest.NewRequest(method, path, &body)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/sites", models.CreateSiteRequest{})
+	if w.Code != http.StatusBadRequest || decodeProblem(t, w).Code != CodeValidationFailed {
+		t.Errorf("Expected a validation problem without a name, got %d", w.Code)
+	}
+
+	w = do(http.MethodPost, "/sites", models.CreateSiteRequest{Name: "Berlin"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var site models.Site
+	json.NewDecoder(w.Body).Decode(&site)
+
+	w = do(http.MethodPost, "/sites", models.CreateSiteRequest{Name: "Berlin"})
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d for a duplicate site, got %d", http.StatusConflict, w.Code)
+	}
+
+	w = do(http.MethodPost, "/sites/999/buildings", models.CreateBuildingRequest{Name: "North"})
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for an unknown site, got %d", http.StatusNotFound, w.Code)
+	}
+
+	w = do(http.MethodPost, fmt.Sprintf("/sites/%d/buildings", site.ID), models.CreateBuildingRequest{Name: "North"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var building models.Building
+	json.NewDecoder(w.Body).Decode(&building)
+
+	w = do(http.MethodPost, fmt.Sprintf("/buildings/%d/floors", building.ID), models.CreateFloorRequest{Name: "Ground"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var floor models.Floor
+	json.NewDecoder(w.Body).Decode(&floor)
+
+	w = do(http.MethodPost, "/rooms", models.CreateRoomRequest{Name: "Lab", Capacity: 4, FloorID: 999})
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for an unknown floor, got %d", http.StatusNotFound, w.Code)
+	}
+	w = do(http.MethodPost, "/rooms", models.CreateRoomRequest{Name: "Lab", Capacity: 4, FloorID: floor.ID})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	do(http.MethodPost, "/rooms", models.CreateRoomRequest{Name: "Annex", Capacity: 4})
+
+	var rooms []*models.Room
+	w = do(http.MethodGet, fmt.Sprintf("/buildings/%d/rooms", building.ID), nil)
+	json.NewDecoder(w.Body).Decode(&rooms)
+	if w.Code != http.StatusOK || len(rooms) != 1 || rooms[0].Name != "Lab" {
+		t.Errorf("Expected only the room of the building, got %d %+v", w.Code, rooms)
+	}
+
+	w = do(http.MethodGet, "/buildings/999/rooms", nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for an unknown building, got %d", http.StatusNotFound, w.Code)
+	}
+
+	w = do(http.MethodDelete, fmt.Sprintf("/sites/%d", site.ID), nil)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected status %d, got %d", http.StatusNoContent, w.Code)
+	}
+
+	w = do(http.MethodGet, fmt.Sprintf("/floors/%d", floor.ID), nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected the floor to be deleted with its site, got %d", w.Code)
+	}
+}
@@ -0,0 +1,364 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloudflaredb/internal/models"
+	"cloudflaredb/internal/validation"
+)
+
+// operation documents the bodies of a route for the OpenAPI specification
+type operation struct {
+	// Request is a value of the request body type; nil when the route takes no body
+	Request any
+	// Response is a value of the success response body type; nil when the route sends no body
+	Response any
+	// Status is the success status code
+	Status int
+	// Accepted is a value of the body sent with 202 Accepted when the request is queued instead
+	// of carried out; nil when the route never queues
+	Accepted any
+	// Paged marks lists that switch to a page envelope with the pagination parameters
+	Paged bool
+	// ContentType replaces application/json as the media type of the success response
+	ContentType string
+	// RequestContentType replaces application/json as the media type of the request body
+	RequestContentType string
+	// Versioned marks routes of versioned resources, which send an ETag and take If-None-Match
+	// on GET and If-Match on writes
+	Versioned bool
+}
+
+// healthStatus is the response body of GET /health
+type healthStatus struct {
+	Status string `json:"status"`
+}
+
+// operations documents every route of the API, keyed by "METHOD pattern"
+var operations = map[string]operation{
+	"GET /health":       {Response: healthStatus{}, Status: http.StatusOK},
+	"GET /openapi.json": {Response: map[string]any{}, Status: http.StatusOK},
+
+	// Users
+	"GET /users":                   {Response: []*models.User{}, Status: http.StatusOK, Paged: true},
+	"POST /users":                  {Request: models.CreateUserRequest{}, Response: models.User{}, Status: http.StatusCreated},
+	"GET /users/{id}":              {Response: models.User{}, Status: http.StatusOK, Versioned: true},
+	"PUT /users/{id}":              {Request: models.UpdateUserRequest{}, Response: models.User{}, Status: http.StatusOK, Versioned: true},
+	"PATCH /users/{id}":            {Request: models.PatchUserRequest{}, Response: models.User{}, Status: http.StatusOK, RequestContentType: mergePatchContentType, Versioned: true},
+	"DELETE /users/{id}":           {Status: http.StatusNoContent, Versioned: true},
+	"GET /users/{id}/rooms":        {Response: []*models.Room{}, Status: http.StatusOK},
+	"GET /users/{id}/calendar.ics": {Response: "", Status: http.StatusOK, ContentType: "text/calendar"},
+	"GET /users/{id}/invitations":  {Response: []*models.Invitation{}, Status: http.StatusOK},
+
+	// Rooms
+	"GET /rooms":                           {Response: []*models.Room{}, Status: http.StatusOK, Paged: true},
+	"POST /rooms":                          {Request: models.CreateRoomRequest{}, Response: models.Room{}, Status: http.StatusCreated},
+	"GET /rooms/available":                 {Response: []*models.RoomAvailability{}, Status: http.StatusOK},
+	"GET /rooms/{id}":                      {Response: models.Room{}, Status: http.StatusOK, Versioned: true},
+	"PUT /rooms/{id}":                      {Request: models.UpdateRoomRequest{}, Response: models.Room{}, Status: http.StatusOK, Versioned: true},
+	"PATCH /rooms/{id}":                    {Request: models.PatchRoomRequest{}, Response: models.Room{}, Status: http.StatusOK, RequestContentType: mergePatchContentType, Versioned: true},
+	"DELETE /rooms/{id}":                   {Status: http.StatusNoContent, Versioned: true},
+	"GET /rooms/{id}/users":                {Response: models.RoomWithUsers{}, Status: http.StatusOK},
+	"POST /rooms/{id}/users":               {Request: models.AssignUserToRoomRequest{}, Response: models.UserRoom{}, Status: http.StatusOK, Accepted: models.WaitlistEntry{}},
+	"PATCH /rooms/{id}/users/{userId}":     {Request: models.UpdateMembershipRequest{}, Response: models.UserRoom{}, Status: http.StatusOK},
+	"DELETE /rooms/{id}/users/{userId}":    {Status: http.StatusNoContent},
+	"GET /rooms/{id}/waitlist":             {Response: []*models.WaitlistEntry{}, Status: http.StatusOK},
+	"POST /rooms/{id}/waitlist":            {Request: userIDRequest{}, Response: models.WaitlistEntry{}, Status: http.StatusCreated},
+	"DELETE /rooms/{id}/waitlist/{userId}": {Status: http.StatusNoContent},
+	"GET /rooms/{id}/groups":               {Response: []*models.Group{}, Status: http.StatusOK},
+	"POST /rooms/{id}/groups":              {Request: models.AssignGroupToRoomRequest{}, Response: models.RoomGroup{}, Status: http.StatusCreated},
+	"DELETE /rooms/{id}/groups/{groupId}":  {Status: http.StatusNoContent},
+	"GET /rooms/{id}/calendar.ics":         {Response: "", Status: http.StatusOK, ContentType: "text/calendar"},
+
+	// Bookings
+	"GET /rooms/{id}/bookings":                                    {Response: []*models.Booking{}, Status: http.StatusOK},
+	"POST /rooms/{id}/bookings":                                   {Request: models.CreateBookingRequest{}, Response: models.Booking{}, Status: http.StatusCreated},
+	"DELETE /bookings/{id}":                                       {Status: http.StatusNoContent},
+	"GET /rooms/{id}/recurring-bookings":                          {Response: []*models.Occurrence{}, Status: http.StatusOK},
+	"POST /rooms/{id}/recurring-bookings":                         {Request: models.CreateRecurringBookingRequest{}, Response: models.RecurringBooking{}, Status: http.StatusCreated},
+	"GET /recurring-bookings/{id}":                                {Response: models.RecurringBooking{}, Status: http.StatusOK},
+	"DELETE /recurring-bookings/{id}":                             {Status: http.StatusNoContent},
+	"PUT /recurring-bookings/{id}/occurrences/{recurrence_id}":    {Request: models.MoveOccurrenceRequest{}, Response: models.Occurrence{}, Status: http.StatusOK},
+	"DELETE /recurring-bookings/{id}/occurrences/{recurrence_id}": {Status: http.StatusNoContent},
+
+	// Invitations and join requests
+	"GET /rooms/{id}/invitations":      {Response: []*models.Invitation{}, Status: http.StatusOK},
+	"POST /rooms/{id}/invitations":     {Request: models.CreateInvitationRequest{}, Response: models.Invitation{}, Status: http.StatusCreated},
+	"POST /invitations/accept":         {Request: models.AcceptInvitationByTokenRequest{}, Response: models.UserRoom{}, Status: http.StatusOK},
+	"POST /invitations/{id}/accept":    {Request: models.RespondInvitationRequest{}, Response: models.UserRoom{}, Status: http.StatusOK},
+	"POST /invitations/{id}/decline":   {Request: models.RespondInvitationRequest{}, Response: models.Invitation{}, Status: http.StatusOK},
+	"GET /rooms/{id}/join-requests":    {Response: []*models.JoinRequest{}, Status: http.StatusOK},
+	"POST /rooms/{id}/join-requests":   {Request: models.CreateJoinRequestRequest{}, Response: models.JoinRequest{}, Status: http.StatusCreated},
+	"POST /join-requests/{id}/approve": {Request: models.ReviewJoinRequestRequest{}, Response: models.UserRoom{}, Status: http.StatusOK},
+	"POST /join-requests/{id}/reject":  {Request: models.ReviewJoinRequestRequest{}, Response: models.JoinRequest{}, Status: http.StatusOK},
+
+	// Groups
+	"GET /groups":                          {Response: []*models.Group{}, Status: http.StatusOK},
+	"POST /groups":                         {Request: models.CreateGroupRequest{}, Response: models.Group{}, Status: http.StatusCreated},
+	"GET /groups/{id}":                     {Response: models.Group{}, Status: http.StatusOK},
+	"PUT /groups/{id}":                     {Request: models.UpdateGroupRequest{}, Response: models.Group{}, Status: http.StatusOK},
+	"DELETE /groups/{id}":                  {Status: http.StatusNoContent},
+	"GET /groups/{id}/members":             {Response: []*models.User{}, Status: http.StatusOK},
+	"POST /groups/{id}/members":            {Request: models.AddGroupMemberRequest{}, Response: models.GroupMember{}, Status: http.StatusCreated},
+	"DELETE /groups/{id}/members/{userId}": {Status: http.StatusNoContent},
+
+	// Locations
+	"GET /sites":                  {Response: []*models.Site{}, Status: http.StatusOK},
+	"POST /sites":                 {Request: models.CreateSiteRequest{}, Response: models.Site{}, Status: http.StatusCreated},
+	"GET /sites/{id}":             {Response: models.Site{}, Status: http.StatusOK},
+	"PUT /sites/{id}":             {Request: models.UpdateSiteRequest{}, Response: models.Site{}, Status: http.StatusOK},
+	"DELETE /sites/{id}":          {Status: http.StatusNoContent},
+	"GET /sites/{id}/buildings":   {Response: []*models.Building{}, Status: http.StatusOK},
+	"POST /sites/{id}/buildings":  {Request: models.CreateBuildingRequest{}, Response: models.Building{}, Status: http.StatusCreated},
+	"GET /sites/{id}/rooms":       {Response: []*models.Room{}, Status: http.StatusOK, Paged: true},
+	"GET /buildings/{id}":         {Response: models.Building{}, Status: http.StatusOK},
+	"PUT /buildings/{id}":         {Request: models.UpdateBuildingRequest{}, Response: models.Building{}, Status: http.StatusOK},
+	"DELETE /buildings/{id}":      {Status: http.StatusNoContent},
+	"GET /buildings/{id}/floors":  {Response: []*models.Floor{}, Status: http.StatusOK},
+	"POST /buildings/{id}/floors": {Request: models.CreateFloorRequest{}, Response: models.Floor{}, Status: http.StatusCreated},
+	"GET /buildings/{id}/rooms":   {Response: []*models.Room{}, Status: http.StatusOK, Paged: true},
+	"GET /floors/{id}":            {Response: models.Floor{}, Status: http.StatusOK},
+	"PUT /floors/{id}":            {Request: models.UpdateFloorRequest{}, Response: models.Floor{}, Status: http.StatusOK},
+	"DELETE /floors/{id}":         {Status: http.StatusNoContent},
+	"GET /floors/{id}/rooms":      {Response: []*models.Room{}, Status: http.StatusOK, Paged: true},
+
+	// Search
+	"GET /search": {Response: models.SearchResponse{}, Status: http.StatusOK},
+}
+
+// pathParamSchemas holds the schemas of path wildcards that are not integer IDs
+var pathParamSchemas = map[string]map[string]any{
+	"recurrence_id": {"type": "string", "format": "date-time"},
+}
+
+// wildcardPattern matches the wildcards of a route pattern
+var wildcardPattern = regexp.MustCompile(`\{(\w+)\}`)
+
+// OpenAPI handles GET /openapi.json
+func (a *API) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, openAPISpec(a.Routes()))
+}
+
+// openAPISpec builds an OpenAPI 3.1 document for the documented routes. Schemas are derived
+// from the request and response types, named after them and shared under components.
+func openAPISpec(routes []Route) map[string]any {
+	b := &schemaBuilder{schemas: map[string]any{}}
+	problem := map[string]any{
+		"description": "Error",
+		"content": map[string]any{
+			problemContentType: map[string]any{"schema": b.schema(reflect.TypeFor[Problem]())},
+		},
+	}
+
+	paths := map[string]any{}
+	for _, route := range routes {
+		op, ok := operations[route.Method+" "+route.Pattern]
+		if !ok {
+			continue
+		}
+
+		spec := map[string]any{
+			"summary":   route.Summary,
+			"responses": map[string]any{strconv.Itoa(op.Status): b.response(op), "default": problem},
+		}
+
+		var params []any
+		for _, m := range wildcardPattern.FindAllStringSubmatch(route.Pattern, -1) {
+			schema, ok := pathParamSchemas[m[1]]
+			if !ok {
+				schema = map[string]any{"type": "integer", "format": "int64"}
+			}
+			params = append(params, map[string]any{"name": m[1], "in": "path", "required": true, "schema": schema})
+		}
+		if op.Versioned {
+			params = append(params, conditionalParam(route.Method))
+			if route.Method == http.MethodGet {
+				spec["responses"].(map[string]any)["304"] = map[string]any{"description": http.StatusText(http.StatusNotModified)}
+			} else {
+				spec["responses"].(map[string]any)["412"] = problem
+			}
+		}
+		if op.Accepted != nil {
+			spec["responses"].(map[string]any)["202"] = b.response(operation{Response: op.Accepted, Status: http.StatusAccepted})
+		}
+		if params != nil {
+			spec["parameters"] = params
+		}
+
+		if op.Request != nil {
+			contentType := op.RequestContentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			spec["requestBody"] = map[string]any{
+				"required": true,
+				"content": map[string]any{
+					contentType: map[string]any{"schema": b.schema(reflect.TypeOf(op.Request))},
+				},
+			}
+		}
+
+		item, _ := paths[route.Pattern].(map[string]any)
+		if item == nil {
+			item = map[string]any{}
+			paths[route.Pattern] = item
+		}
+		item[strings.ToLower(route.Method)] = spec
+	}
+
+	return map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":   "CloudflareDB API",
+			"version": "1.0.0",
+		},
+		"paths":      paths,
+		"components": map[string]any{"schemas": b.schemas},
+	}
+}
+
+// conditionalParam documents the If-None-Match header of a GET or the If-Match header of a write
+// to a versioned resource
+func conditionalParam(method string) map[string]any {
+	if method == http.MethodGet {
+		return map[string]any{
+			"name": "If-None-Match", "in": "header", "schema": map[string]any{"type": "string"},
+			"description": "Answer 304 Not Modified when the resource still has one of these ETags",
+		}
+	}
+	return map[string]any{
+		"name": "If-Match", "in": "header", "schema": map[string]any{"type": "string"},
+		"description": "Fail with 412 Precondition Failed unless the resource still has this ETag",
+	}
+}
+
+// schemaBuilder derives JSON schemas from Go types, collecting named structs as components
+type schemaBuilder struct {
+	schemas map[string]any
+}
+
+var (
+	timeType       = reflect.TypeFor[time.Time]()
+	rawMessageType = reflect.TypeFor[json.RawMessage]()
+	optionalType   = reflect.TypeFor[validation.Optional]()
+)
+
+// response describes the success response of op
+func (b *schemaBuilder) response(op operation) map[string]any {
+	resp := map[string]any{"description": http.StatusText(op.Status)}
+	if op.Response == nil {
+		return resp
+	}
+
+	schema := b.schema(reflect.TypeOf(op.Response))
+	if op.Paged {
+		// Lists are bare arrays unless cursor pagination or include_total asks for an envelope
+		schema = map[string]any{"oneOf": []any{
+			schema,
+			map[string]any{"allOf": []any{b.schema(reflect.TypeFor[models.CursorPage]()), map[string]any{"properties": map[string]any{"items": schema}}}},
+			map[string]any{"allOf": []any{b.schema(reflect.TypeFor[models.OffsetPage]()), map[string]any{"properties": map[string]any{"items": schema}}}},
+		}}
+	}
+
+	contentType := op.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	resp["content"] = map[string]any{contentType: map[string]any{"schema": schema}}
+	if op.Versioned {
+		resp["headers"] = map[string]any{
+			"ETag": map[string]any{"description": "Entity tag of the current version", "schema": map[string]any{"type": "string"}},
+		}
+	}
+	return resp
+}
+
+// schema returns the JSON schema of t, as a reference for named structs
+func (b *schemaBuilder) schema(t reflect.Type) map[string]any {
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case rawMessageType:
+		return map[string]any{}
+	}
+	if t.Implements(optionalType) {
+		// Merge patch members hold their value or null
+		value, _ := t.FieldByName("Value")
+		return map[string]any{"anyOf": []any{b.schema(value.Type), map[string]any{"type": "null"}}}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return b.schema(t.Elem())
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.object(t)
+		}
+		if _, ok := b.schemas[t.Name()]; !ok {
+			b.schemas[t.Name()] = nil // placeholder for recursive types
+			b.schemas[t.Name()] = b.object(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+	default:
+		// Interfaces hold any JSON value
+		return map[string]any{}
+	}
+}
+
+// object returns the schema of a struct with the properties encoding/json would produce
+func (b *schemaBuilder) object(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	b.addProperties(properties, t)
+	return map[string]any{"type": "object", "properties": properties}
+}
+
+// addProperties adds the JSON fields of struct t to properties, flattening embedded structs
+func (b *schemaBuilder) addProperties(properties map[string]any, t reflect.Type) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if f.Anonymous && name == "" {
+			embedded := f.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				b.addProperties(properties, embedded)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		properties[name] = b.schema(f.Type)
+	}
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPI_CoversEveryRoute(t *testing.T) {
+	router := NewRouter((&API{}).Routes()...)
+	spec := openAPISpec(router.Routes())
+	paths := spec["paths"].(map[string]any)
+
+	registered := map[string]bool{}
+	for _, route := range router.Routes() {
+		key := route.Method + " " + route.Pattern
+		registered[key] = true
+
+		item, _ := paths[route.Pattern].(map[string]any)
+		if _, ok := item[strings.ToLower(route.Method)]; !ok {
+			t.Errorf("Route %s has no entry in the OpenAPI specification", key)
+		}
+	}
+
+	for key := range operations {
+		if !registered[key] {
+			t.Errorf("OpenAPI entry %s does not match a registered route", key)
+		}
+	}
+}
+
+func TestOpenAPI_Serve(t *testing.T) {
+	router := NewRouter((&API{}).Routes()...)
+
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var spec struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]map[string]struct {
+			Parameters []struct {
+				Name string `json:"name"`
+				In   string `json:"in"`
+			} `json:"parameters"`
+			Responses   map[string]any `json:"responses"`
+			RequestBody struct {
+				Content map[string]struct {
+					Schema map[string]any `json:"schema"`
+				} `json:"content"`
+			} `json:"requestBody"`
+		} `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]map[string]any `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&spec); err != nil {
+		t.Fatalf("Failed to decode specification: %v", err)
+	}
+
+	if spec.OpenAPI != "3.1.0" {
+		t.Errorf("Expected OpenAPI 3.1.0, got %q", spec.OpenAPI)
+	}
+
+	createUser := spec.Paths["/users"]["post"].RequestBody.Content["application/json"].Schema
+	if createUser["$ref"] != "#/components/schemas/CreateUserRequest" {
+		t.Errorf("Expected POST /users to take a CreateUserRequest, got %v", createUser)
+	}
+
+	// Merge patch members may be null
+	patchRoom := spec.Paths["/rooms/{id}"]["patch"].RequestBody.Content[mergePatchContentType].Schema
+	if patchRoom["$ref"] != "#/components/schemas/PatchRoomRequest" {
+		t.Errorf("Expected PATCH /rooms/{id} to take a PatchRoomRequest merge patch, got %v", patchRoom)
+	}
+	description := spec.Components.Schemas["PatchRoomRequest"].Properties["description"]
+	if anyOf, _ := description["anyOf"].([]any); len(anyOf) != 2 {
+		t.Errorf("Expected description to be a string or null, got %v", description)
+	}
+
+	// Versioned resources take conditional request headers
+	putUser := spec.Paths["/users/{id}"]["put"]
+	if len(putUser.Parameters) != 2 || putUser.Parameters[1].Name != "If-Match" || putUser.Responses["412"] == nil {
+		t.Errorf("Expected PUT /users/{id} to take If-Match and fail with 412, got %+v", putUser)
+	}
+	if getRoom := spec.Paths["/rooms/{id}"]["get"]; getRoom.Responses["304"] == nil {
+		t.Errorf("Expected GET /rooms/{id} to answer 304, got %v", getRoom.Responses)
+	}
+
+	params := spec.Paths["/rooms/{id}/users/{userId}"]["delete"].Parameters
+	if len(params) != 2 || params[0].Name != "id" || params[1].Name != "userId" || params[1].In != "path" {
+		t.Errorf("Expected the id and userId path parameters, got %+v", params)
+	}
+
+	// Embedded structs are flattened like encoding/json does
+	roomWithUsers := spec.Components.Schemas["RoomWithUsers"].Properties
+	if roomWithUsers["name"]["type"] != "string" || roomWithUsers["users"]["type"] != "array" {
+		t.Errorf("Expected RoomWithUsers to have the room fields and users, got %v", roomWithUsers)
+	}
+	if created := spec.Components.Schemas["User"].Properties["created_at"]; created["format"] != "date-time" {
+		t.Errorf("Expected created_at to be a date-time, got %v", created)
+	}
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"cloudflaredb/internal/models"
+	"cloudflaredb/internal/repository"
+)
+
+const (
+	// DefaultPageSize is used when a list request has no valid limit
+	DefaultPageSize = 10
+	// MaxPageSize caps the limit of a list request
+	MaxPageSize = 100
+)
+
+// pageParams holds the pagination parameters of a list request
+type pageParams struct {
+	Limit  int
+	Offset int
+	// Cursor is true when the client asked for cursor pagination with the after parameter
+	Cursor bool
+	After  *repository.Cursor
+	// IncludeTotal asks for the number of matching rows, which also wraps offset lists in an envelope
+	IncludeTotal bool
+}
+
+// parsePageParams reads limit, offset and after from the query string.
+// Invalid limit and offset values fall back to the defaults; an undecodable cursor is a field error.
+// Passing after (even empty, for the first page) switches the list to cursor pagination.
+func parsePageParams(q *queryParams) pageParams {
+	p := pageParams{Limit: DefaultPageSize}
+
+	if l, err := strconv.Atoi(q.values.Get("limit")); err == nil && l > 0 {
+		p.Limit = min(l, MaxPageSize)
+	}
+
+	if o, err := strconv.Atoi(q.values.Get("offset")); err == nil && o >= 0 {
+		p.Offset = o
+	}
+
+	p.IncludeTotal = q.Bool("include_total")
+
+	if q.values.Has("after") {
+		p.Cursor = true
+		if token := q.String("after"); token != "" {
+			after, err := repository.DecodeCursor(token)
+			if err != nil {
+				q.errs = append(q.errs, FieldError{Field: "after", Message: "is not a valid cursor"})
+			}
+			p.After = after
+		}
+	}
+
+	return p
+}
+
+// listOptions combines the pagination and sort parameters into repository list options.
+// Cursor pages fetch one extra row so the handler can tell whether another page follows.
+func listOptions(q *queryParams, page pageParams, sortable []string) repository.ListOptions {
+	opts := repository.ListOptions{Sort: q.Sort(sortable), Limit: page.Limit, Offset: page.Offset}
+
+	if page.Cursor {
+		if len(opts.Sort) > 0 {
+			q.errs = append(q.errs, FieldError{Field: "sort", Message: "cannot be combined with cursor pagination"})
+		}
+		opts.Limit = page.Limit + 1
+		opts.Offset = 0
+		opts.After = page.After
+	}
+
+	return opts
+}
+
+// respondPage sends a page of items fetched with listOptions, as a bare array (offset pagination),
+// an OffsetPage (offset pagination with include_total) or a CursorPage (cursor pagination).
+// A negative total means it was not requested. RFC 8288 Link headers point to the adjacent pages.
+func respondPage[T any](w http.ResponseWriter, r *http.Request, page pageParams, items []T, total int, cursorOf func(T) repository.Cursor) {
+	if items == nil {
+		items = []T{}
+	}
+
+	if page.Cursor {
+		result := models.CursorPage{Items: items}
+		if len(items) > page.Limit {
+			items = items[:page.Limit]
+			result.Items = items
+			result.HasMore = true
+			result.NextCursor = cursorOf(items[len(items)-1]).Encode()
+			setLinks(w, r, page.Limit, map[string]url.Values{
+				"next": {"after": {result.NextCursor}},
+			})
+		}
+		if total >= 0 {
+			result.Total = &total
+		}
+		respondJSON(w, http.StatusOK, result)
+		return
+	}
+
+	links := make(map[string]url.Values)
+	if page.Offset > 0 {
+		links["first"] = url.Values{"offset": {"0"}}
+		links["prev"] = url.Values{"offset": {strconv.Itoa(max(page.Offset-page.Limit, 0))}}
+	}
+	// Without a total, a full page may be followed by another one
+	if (total >= 0 && page.Offset+len(items) < total) || (total < 0 && len(items) == page.Limit) {
+		links["next"] = url.Values{"offset": {strconv.Itoa(page.Offset + page.Limit)}}
+	}
+	if total > 0 {
+		last := (total - 1) / page.Limit * page.Limit
+		links["last"] = url.Values{"offset": {strconv.Itoa(last)}}
+	}
+	setLinks(w, r, page.Limit, links)
+
+	if !page.IncludeTotal {
+		respondJSON(w, http.StatusOK, items)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.OffsetPage{
+		Items:  items,
+		Total:  total,
+		Limit:  page.Limit,
+		Offset: page.Offset,
+	})
+}
+
+// linkRelations fixes the order of relations in Link headers
+var linkRelations = []string{"first", "prev", "next", "last"}
+
+// setLinks sets a Link header with one entry per relation.
+// Each target is the request URL with the given query parameters replaced and the effective limit.
+func setLinks(w http.ResponseWriter, r *http.Request, limit int, links map[string]url.Values) {
+	var entries []string
+	for _, rel := range linkRelations {
+		params, ok := links[rel]
+		if !ok {
+			continue
+		}
+
+		q := r.URL.Query()
+		for name, values := range params {
+			q[name] = values
+		}
+		q.Set("limit", strconv.Itoa(limit))
+
+		target := url.URL{Path: r.URL.Path, RawQuery: q.Encode()}
+		entries = append(entries, "<"+target.String()+`>; rel="`+rel+`"`)
+	}
+
+	if len(entries) > 0 {
+		w.Header().Set("Link", strings.Join(entries, ", "))
+	}
+}
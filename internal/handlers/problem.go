@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"cloudflaredb/internal/validation"
+)
+
+// Problem codes are stable, machine-readable identifiers for error responses
+const (
+	CodeInvalidRequest     = "invalid_request"
+	CodeValidationFailed   = "validation_failed"
+	CodeNotFound           = "not_found"
+	CodeConflict           = "conflict"
+	CodeRoomFull           = "room_full"
+	CodeFullyBooked        = "fully_booked"
+	CodeLastOwner          = "last_owner"
+	CodeForbidden          = "forbidden"
+	CodeExpired            = "expired"
+	CodeMethodNotAllowed   = "method_not_allowed"
+	CodeBodyTooLarge       = "body_too_large"
+	CodePreconditionFailed = "precondition_failed"
+	CodeInternal           = "internal_error"
+)
+
+// problemTypePrefix namespaces the problem type URIs
+const problemTypePrefix = "urn:cloudflaredb:problem:"
+
+// problemContentType is the media type of RFC 7807 responses
+const problemContentType = "application/problem+json"
+
+// codeTitles holds the short, human-readable summary for each problem code
+var codeTitles = map[string]string{
+	CodeInvalidRequest:     "Invalid request",
+	CodeValidationFailed:   "Validation failed",
+	CodeNotFound:           "Resource not found",
+	CodeConflict:           "Conflict with current state",
+	CodeRoomFull:           "Room is full",
+	CodeFullyBooked:        "Room is fully booked",
+	CodeLastOwner:          "Room must keep an owner",
+	CodeForbidden:          "Not permitted",
+	CodeExpired:            "Resource has expired",
+	CodeMethodNotAllowed:   "Method not allowed",
+	CodeBodyTooLarge:       "Request body too large",
+	CodePreconditionFailed: "Precondition failed",
+	CodeInternal:           "Internal server error",
+}
+
+// Problem is an RFC 7807 problem details response body
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes why a single request field was rejected
+type FieldError = validation.FieldError
+
+// newProblem builds a problem for the request with the given status, code and detail
+func newProblem(r *http.Request, status int, code, detail string) *Problem {
+	title, ok := codeTitles[code]
+	if !ok {
+		title = http.StatusText(status)
+	}
+
+	return &Problem{
+		Type:      problemTypePrefix + code,
+		Title:     title,
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		Code:      code,
+		RequestID: RequestIDFromContext(r.Context()),
+	}
+}
+
+// writeProblem sends a problem as application/problem+json
+func writeProblem(w http.ResponseWriter, p *Problem) {
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// respondError sends a problem response; detail must be safe to show to clients
+func respondError(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+	writeProblem(w, newProblem(r, status, code, detail))
+}
+
+// respondValidationError sends a 400 problem listing every rejected field
+func respondValidationError(w http.ResponseWriter, r *http.Request, errs []FieldError) {
+	p := newProblem(r, http.StatusBadRequest, CodeValidationFailed, "One or more fields are invalid")
+	p.Errors = errs
+	writeProblem(w, p)
+}
+
+// respondInternalError logs err with the request ID and sends a redacted 500 problem
+func respondInternalError(w http.ResponseWriter, r *http.Request, action string, err error) {
+	log.Printf("request_id=%s %s %s: %s: %v", RequestIDFromContext(r.Context()), r.Method, r.URL.Path, action, err)
+	respondError(w, r, http.StatusInternalServerError, CodeInternal,
+		"An unexpected error occurred; quote the request ID when reporting it")
+}
+
+// NotFound sends a 404 problem for unknown routes
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	respondError(w, r, http.StatusNotFound, CodeNotFound, "No resource matches the requested path")
+}
+
+// MethodNotAllowed sends a 405 problem for unsupported methods on a known route
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	respondError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed,
+		"Method "+r.Method+" is not supported for this resource")
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cloudflaredb/internal/repository"
+)
+
+// decodeProblem checks the problem content type and decodes the response body
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) Problem {
+	t.Helper()
+
+	if ct := w.Header().Get("Content-Type"); ct != problemContentType {
+		t.Fatalf("Expected content type %s, got %s", problemContentType, ct)
+	}
+
+	var p Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatalf("Failed to unmarshal problem: %v", err)
+	}
+	return p
+}
+
+func TestProblem_ValidationErrors(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	handler := RequestID(http.HandlerFunc(NewUserHandler(repository.NewUserRepository(db, repository.NewRoomRepository(db))).CreateUser))
+
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{}`))
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	p := decodeProblem(t, w)
+	if p.Code != CodeValidationFailed {
+		t.Errorf("Expected code %s, got %s", CodeValidationFailed, p.Code)
+	}
+	if p.Type != problemTypePrefix+CodeValidationFailed {
+		t.Errorf("Unexpected type %s", p.Type)
+	}
+	if p.Status != http.StatusBadRequest || p.Instance != "/users" {
+		t.Errorf("Unexpected status/instance: %d %s", p.Status, p.Instance)
+	}
+	if p.RequestID == "" || p.RequestID != w.Header().Get(RequestIDHeader) {
+		t.Errorf("Expected request ID %q in body, got %q", w.Header().Get(RequestIDHeader), p.RequestID)
+	}
+	if len(p.Errors) != 2 || p.Errors[0].Field != "email" || p.Errors[1].Field != "name" {
+		t.Errorf("Unexpected field errors: %+v", p.Errors)
+	}
+}
+
+func TestProblem_InternalErrorIsRedacted(t *testing.T) {
+	db := setupTestDB(t)
+	handler := NewUserHandler(repository.NewUserRepository(db, repository.NewRoomRepository(db)))
+	router := NewRouter((&API{Users: handler}).Routes()...)
+	db.Close()
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+
+	p := decodeProblem(t, w)
+	if p.Code != CodeInternal {
+		t.Errorf("Expected code %s, got %s", CodeInternal, p.Code)
+	}
+	if strings.Contains(w.Body.String(), "database is closed") {
+		t.Errorf("Driver error leaked into response: %s", w.Body.String())
+	}
+}
+
+func TestProblem_RoomFull(t *testing.T) {
+	db := setupTestDBForRooms(t)
+	defer db.Close()
+
+	roomRepo := repository.NewRoomRepository(db)
+	handler := NewGroupHandler(repository.NewGroupRepository(db, roomRepo))
+	router := NewRouter((&API{Groups: handler, Rooms: NewRoomHandler(roomRepo)}).Routes()...)
+
+	if _, err := db.Exec(`INSERT INTO users (email, name) VALUES ('a@example.com', 'A'), ('b@example.com', 'B');
+		INSERT INTO rooms (name, capacity) VALUES ('Booth', 1);
+		INSERT INTO user_rooms (user_id, room_id) VALUES (1, 1);
+		INSERT INTO groups (name) VALUES ('Pair');
+		INSERT INTO group_members (group_id, user_id) VALUES (1, 2);`); err != nil {
+		t.Fatalf("Failed to seed data: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/rooms/1/groups", bytes.NewReader([]byte(`{"group_id": 1}`)))
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("Expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+	if p := decodeProblem(t, w); p.Code != CodeRoomFull {
+		t.Errorf("Expected code %s, got %s", CodeRoomFull, p.Code)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestIDFromContext(r.Context())
+	}))
+
+	tests := []struct {
+		name   string
+		header string
+		reused bool
+	}{
+		{name: "client ID reused", header: "abc-123", reused: true},
+		{name: "missing ID generated", header: "", reused: false},
+		{name: "invalid ID replaced", header: "has space", reused: false},
+		{name: "oversized ID replaced", header: strings.Repeat("a", maxRequestIDLength+1), reused: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(RequestIDHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			got := w.Header().Get(RequestIDHeader)
+			if got == "" || got != seen {
+				t.Fatalf("Expected response header to match context ID, got %q and %q", got, seen)
+			}
+			if (got == tt.header) != tt.reused {
+				t.Errorf("Expected reused=%v, got ID %q for header %q", tt.reused, got, tt.header)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloudflaredb/internal/models"
+	"cloudflaredb/internal/repository"
+)
+
+// attributeParamPrefix marks query parameters filtering on room attributes, e.g. attr.floor=3
+const attributeParamPrefix = "attr."
+
+// queryParams reads typed values from a query string and collects the field errors of invalid ones
+type queryParams struct {
+	values url.Values
+	errs   []FieldError
+}
+
+func newQueryParams(values url.Values) *queryParams {
+	return &queryParams{values: values}
+}
+
+// String returns the trimmed value of name
+func (q *queryParams) String(name string) string {
+	return strings.TrimSpace(q.values.Get(name))
+}
+
+// Strings returns the trimmed, non-empty values of a repeatable parameter
+func (q *queryParams) Strings(name string) []string {
+	var values []string
+	for _, v := range q.values[name] {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// PositiveInt returns the value of name, or 0 when it is absent; other values must be integers >= 1
+func (q *queryParams) PositiveInt(name string) int {
+	s := q.String(name)
+	if s == "" {
+		return 0
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		q.errs = append(q.errs, FieldError{Field: name, Message: "must be a positive integer"})
+		return 0
+	}
+	return n
+}
+
+// Bool returns the value of name as a boolean, or false when it is absent
+func (q *queryParams) Bool(name string) bool {
+	s := q.String(name)
+	if s == "" {
+		return false
+	}
+
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		q.errs = append(q.errs, FieldError{Field: name, Message: "must be true or false"})
+		return false
+	}
+	return b
+}
+
+// Enum returns the value of name, or "" when it is absent; other values must be one of allowed
+func (q *queryParams) Enum(name string, allowed ...string) string {
+	s := q.String(name)
+	if s == "" || slices.Contains(allowed, s) {
+		return s
+	}
+
+	q.errs = append(q.errs, FieldError{Field: name, Message: "must be one of " + strings.Join(allowed, ", ")})
+	return ""
+}
+
+// Time returns the value of name as an RFC 3339 timestamp or YYYY-MM-DD date, or the zero time when it is absent
+func (q *queryParams) Time(name string) time.Time {
+	s := q.String(name)
+	if s == "" {
+		return time.Time{}
+	}
+
+	for _, layout := range []string{time.RFC3339Nano, time.DateOnly} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	q.errs = append(q.errs, FieldError{Field: name, Message: "must be an RFC 3339 timestamp or a YYYY-MM-DD date"})
+	return time.Time{}
+}
+
+// Sort parses the sort parameter against the sortable columns
+func (q *queryParams) Sort(allowed []string) []repository.SortField {
+	fields, err := repository.ParseSort(q.String("sort"), allowed)
+	if err != nil {
+		q.errs = append(q.errs, FieldError{Field: "sort", Message: "must be a comma-separated list of " +
+			strings.Join(allowed, ", ") + ", each optionally prefixed with -"})
+		return nil
+	}
+	return fields
+}
+
+// Errors returns the field errors collected so far
+func (q *queryParams) Errors() []FieldError {
+	return q.errs
+}
+
+// parseUserFilter reads the user list filters from the query string
+func parseUserFilter(q *queryParams) repository.UserFilter {
+	return repository.UserFilter{
+		NameContains:  q.String("name_contains"),
+		Email:         q.String("email"),
+		CreatedAfter:  q.Time("created_after"),
+		CreatedBefore: q.Time("created_before"),
+	}
+}
+
+// parseRoomFilter reads the room list filters from the query string
+func parseRoomFilter(q *queryParams) repository.RoomFilter {
+	f := repository.RoomFilter{
+		NameContains:  q.String("name_contains"),
+		MinCapacity:   q.PositiveInt("min_capacity"),
+		MaxCapacity:   q.PositiveInt("max_capacity"),
+		CreatedAfter:  q.Time("created_after"),
+		CreatedBefore: q.Time("created_before"),
+	}
+	f.FloorID = int64(q.PositiveInt("floor_id"))
+	f.BuildingID = int64(q.PositiveInt("building_id"))
+	f.SiteID = int64(q.PositiveInt("site_id"))
+	if f.MinCapacity > 0 && f.MaxCapacity > 0 && f.MinCapacity > f.MaxCapacity {
+		q.errs = append(q.errs, FieldError{Field: "max_capacity", Message: "must not be lower than min_capacity"})
+	}
+	parseRoomMetadataFilter(q, &f)
+	return f
+}
+
+// parseRoomMetadataFilter reads the repeatable tag and amenity filters and the attr.<key> filters
+func parseRoomMetadataFilter(q *queryParams, f *repository.RoomFilter) {
+	f.Tags = q.Strings("tag")
+	f.Amenities = q.Strings("amenity")
+	for _, amenity := range f.Amenities {
+		if !models.ValidAmenity(amenity) {
+			q.errs = append(q.errs, FieldError{Field: "amenity", Message: "must be one of " + strings.Join(models.Amenities, ", ")})
+			break
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(q.values)) {
+		key, ok := strings.CutPrefix(name, attributeParamPrefix)
+		if !ok {
+			continue
+		}
+		if !validAttributeKey(key) {
+			q.errs = append(q.errs, FieldError{Field: name, Message: "attribute keys may only contain letters, digits, - and _"})
+			continue
+		}
+		if f.Attributes == nil {
+			f.Attributes = make(map[string]string)
+		}
+		f.Attributes[key] = q.String(name)
+	}
+}
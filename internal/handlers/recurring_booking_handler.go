@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"cloudflaredb/internal/models"
+	"cloudflaredb/internal/repository"
+)
+
+// maxOccurrenceWindow bounds the time window of an occurrence listing
+const maxOccurrenceWindow = 366 * 24 * time.Hour
+
+// RecurringBookingHandler handles HTTP requests for recurring bookings and their occurrences
+type RecurringBookingHandler struct {
+	repo *repository.RecurringBookingRepository
+}
+
+// NewRecurringBookingHandler creates a new recurring booking handler
+func NewRecurringBookingHandler(repo *repository.RecurringBookingRepository) *RecurringBookingHandler {
+	return &RecurringBookingHandler{repo: repo}
+}
+
+// CreateRecurringBooking handles POST /rooms/{id}/recurring-bookings
+func (h *RecurringBookingHandler) CreateRecurringBooking(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := pathID(w, r, "id", "room")
+	if !ok {
+		return
+	}
+
+	var req models.CreateRecurringBookingRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	series, err := h.repo.Create(r.Context(), roomID, &req)
+	if err != nil {
+		var conflict *repository.OccurrenceConflictError
+		switch {
+		case errors.As(err, &conflict):
+			respondError(w, r, http.StatusConflict, CodeFullyBooked,
+				"Room has no free capacity for the occurrence starting at "+conflict.StartsAt.UTC().Format(time.RFC3339))
+		case errors.Is(err, repository.ErrRoomNotFound):
+			respondError(w, r, http.StatusNotFound, CodeNotFound, "Room not found")
+		case errors.Is(err, repository.ErrUserNotFound):
+			respondError(w, r, http.StatusNotFound, CodeNotFound, "User not found")
+		default:
+			respondInternalError(w, r, "create recurring booking", err)
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, series)
+}
+
+// ListRoomOccurrences handles GET /rooms/{id}/recurring-bookings?from=&to=
+func (h *RecurringBookingHandler) ListRoomOccurrences(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := pathID(w, r, "id", "room")
+	if !ok {
+		return
+	}
+
+	q := newQueryParams(r.URL.Query())
+	from := q.Time("from")
+	to := q.Time("to")
+	if q.String("from") == "" {
+		q.errs = append(q.errs, FieldError{Field: "from", Message: "is required"})
+	}
+	if q.String("to") == "" {
+		q.errs = append(q.errs, FieldError{Field: "to", Message: "is required"})
+	}
+	if !from.IsZero() && !to.IsZero() {
+		if !to.After(from) {
+			q.errs = append(q.errs, FieldError{Field: "to", Message: "must be after from"})
+		} else if to.Sub(from) > maxOccurrenceWindow {
+			q.errs = append(q.errs, FieldError{Field: "to", Message: "must be at most 366 days after from"})
+		}
+	}
+	if errs := q.Errors(); len(errs) > 0 {
+		respondValidationError(w, r, errs)
+		return
+	}
+
+	occurrences, err := h.repo.ListOccurrences(r.Context(), roomID, from, to)
+	if err != nil {
+		if errors.Is(err, repository.ErrRoomNotFound) {
+			respondError(w, r, http.StatusNotFound, CodeNotFound, "Room not found")
+			return
+		}
+		respondInternalError(w, r, "list occurrences", err)
+		return
+	}
+
+	if occurrences == nil {
+		occurrences = []*models.Occurrence{}
+	}
+
+	respondJSON(w, http.StatusOK, occurrences)
+}
+
+// GetRecurringBooking handles GET /recurring-bookings/{id}
+func (h *RecurringBookingHandler) GetRecurringBooking(w http.ResponseWriter, r *http.Request) {
+	id, _, ok := seriesPath(w, r)
+	if !ok {
+		return
+	}
+
+	series, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrRecurringBookingNotFound) {
+			respondError(w, r, http.StatusNotFound, CodeNotFound, "Recurring booking not found")
+			return
+		}
+		respondInternalError(w, r, "get recurring booking", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, series)
+}
+
+// DeleteRecurringBooking handles DELETE /recurring-bookings/{id}
+func (h *RecurringBookingHandler) DeleteRecurringBooking(w http.ResponseWriter, r *http.Request) {
+	id, _, ok := seriesPath(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.Delete(r.Context(), id); err != nil {
+		if errors.Is(err, repository.ErrRecurringBookingNotFound) {
+			respondError(w, r, http.StatusNotFound, CodeNotFound, "Recurring booking not found")
+			return
+		}
+		respondInternalError(w, r, "delete recurring booking", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CancelOccurrence handles DELETE /recurring-bookings/{id}/occurrences/{recurrence_id}
+func (h *RecurringBookingHandler) CancelOccurrence(w http.ResponseWriter, r *http.Request) {
+	id, recurrenceID, ok := seriesPath(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.CancelOccurrence(r.Context(), id, recurrenceID); err != nil {
+		respondOccurrenceError(w, r, "cancel occurrence", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// MoveOccurrence handles PUT /recurring-bookings/{id}/occurrences/{recurrence_id}
+func (h *RecurringBookingHandler) MoveOccurrence(w http.ResponseWriter, r *http.Request) {
+	id, recurrenceID, ok := seriesPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.MoveOccurrenceRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	occ, err := h.repo.MoveOccurrence(r.Context(), id, recurrenceID, &req)
+	if err != nil {
+		respondOccurrenceError(w, r, "move occurrence", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, occ)
+}
+
+// respondOccurrenceError maps the errors of single-occurrence operations to problem responses
+func respondOccurrenceError(w http.ResponseWriter, r *http.Request, action string, err error) {
+	switch {
+	case errors.Is(err, repository.ErrRecurringBookingNotFound):
+		respondError(w, r, http.StatusNotFound, CodeNotFound, "Recurring booking not found")
+	case errors.Is(err, repository.ErrOccurrenceNotFound):
+		respondError(w, r, http.StatusNotFound, CodeNotFound, "Occurrence not found")
+	case errors.Is(err, repository.ErrFullyBooked):
+		respondError(w, r, http.StatusConflict, CodeFullyBooked,
+			"Room has no free capacity for the whole requested time range")
+	default:
+		respondInternalError(w, r, action, err)
+	}
+}
+
+// seriesPath parses /recurring-bookings/{id} and /recurring-bookings/{id}/occurrences/{recurrence_id},
+// where the recurrence ID is an RFC 3339 timestamp. It sends a 400 problem when either is invalid.
+func seriesPath(w http.ResponseWriter, r *http.Request) (int64, time.Time, bool) {
+	id, ok := pathID(w, r, "id", "recurring booking")
+	if !ok {
+		return 0, time.Time{}, false
+	}
+
+	var recurrenceID time.Time
+	if value := r.PathValue("recurrence_id"); value != "" {
+		var err error
+		if recurrenceID, err = time.Parse(time.RFC3339, value); err != nil {
+			respondError(w, r, http.StatusBadRequest, CodeInvalidRequest, "Invalid recurrence ID, expected an RFC 3339 timestamp")
+			return 0, time.Time{}, false
+		}
+	}
+
+	return id, recurrenceID, true
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cloudflaredb/internal/models"
+	"cloudflaredb/internal/repository"
+)
+
+func TestRecurringBookingHandler_Create(t *testing.T) {
+	db := setupTestDBForRooms(t)
+	defer db.Close()
+
+	handler := NewRecurringBookingHandler(repository.NewRecurringBookingRepository(db))
+	router := NewRouter((&API{Recurring: handler}).Routes()...)
+
+	if _, err := db.Exec(`INSERT INTO users (email, name) VALUES ('a@example.com', 'A');
+		INSERT INTO rooms (name, capacity) VALUES ('Booth', 1);`); err != nil {
+		t.Fatalf("Failed to seed data: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantFields []string
+	}{
+		{
+			name:       "weekly series",
+			body:       `{"user_id": 1, "title": "Standup", "starts_at": "2026-01-05T09:00:00+01:00", "ends_at": "2026-01-05T09:30:00+01:00", "timezone": "Europe/Berlin", "rrule": "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=6", "exdates": ["2026-01-07T09:00:00+01:00"]}`,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "overlapping series",
+			body:       `{"user_id": 1, "starts_at": "2026-01-14T08:00:00Z", "ends_at": "2026-01-14T09:00:00Z", "rrule": "FREQ=DAILY"}`,
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "invalid rule and time zone",
+			body:       `{"user_id": 1, "starts_at": "2026-01-05T09:00:00Z", "ends_at": "2026-01-05T10:00:00Z", "timezone": "Mars/Olympus", "rrule": "FREQ=YEARLY"}`,
+			wantStatus: http.StatusBadRequest,
+			wantFields: []string{"timezone", "rrule"},
+		},
+		{
+			name:       "start outside the rule",
+			body:       `{"user_id": 1, "starts_at": "2026-01-06T09:00:00Z", "ends_at": "2026-01-06T10:00:00Z", "rrule": "FREQ=WEEKLY;BYDAY=MO"}`,
+			wantStatus: http.StatusBadRequest,
+			wantFields: []string{"starts_at"},
+		},
+		{
+			name:       "exdate outside the rule",
+			body:       `{"user_id": 1, "starts_at": "2026-01-05T09:00:00Z", "ends_at": "2026-01-05T10:00:00Z", "rrule": "FREQ=WEEKLY", "exdates": ["2026-01-13T09:00:00Z"]}`,
+			wantStatus: http.StatusBadRequest,
+			wantFields: []string{"exdates"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/rooms/1/recurring-bookings", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if len(tt.wantFields) > 0 {
+				p := decodeProblem(t, w)
+				if len(p.Errors) != len(tt.wantFields) {
+					t.Fatalf("Expected errors for %v, got %+v", tt.wantFields, p.Errors)
+				}
+				for i, field := range tt.wantFields {
+					if p.Errors[i].Field != field {
+						t.Errorf("Expected error %d on %s, got %s", i, field, p.Errors[i].Field)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRecurringBookingHandler_ListAndCancel(t *testing.T) {
+	db := setupTestDBForRooms(t)
+	defer db.Close()
+
+	handler := NewRecurringBookingHandler(repository.NewRecurringBookingRepository(db))
+	router := NewRouter((&API{Recurring: handler}).Routes()...)
+
+	if _, err := db.Exec(`INSERT INTO users (email, name) VALUES ('a@example.com', 'A');
+		INSERT INTO rooms (name, capacity) VALUES ('Booth', 1);
+		INSERT INTO recurring_bookings (room_id, user_id, title, starts_at, ends_at, rrule)
+		VALUES (1, 1, 'Review', '2026-01-05T15:00:00Z', '2026-01-05T16:00:00Z', 'FREQ=WEEKLY');`); err != nil {
+		t.Fatalf("Failed to seed data: %v", err)
+	}
+
+	list := func() []models.Occurrence {
+		req := httptest.NewRequest(http.MethodGet, "/rooms/1/recurring-bookings?from=2026-01-01&to=2026-02-01", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		var occurrences []models.Occurrence
+		if err := json.Unmarshal(w.Body.Bytes(), &occurrences); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		return occurrences
+	}
+
+	if got := list(); len(got) != 4 {
+		t.Fatalf("Expected 4 Monday occurrences in January, got %d", len(got))
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, "/recurring-bookings/1/occurrences/2026-01-12T15:00:00Z", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusNoContent, w.Code, w.Body.String())
+	}
+	if got := list(); len(got) != 3 {
+		t.Errorf("Expected 3 occurrences after cancelling one, got %d", len(got))
+	}
+
+	// The window is required and bounded
+	for _, query := range []string{"", "from=2026-01-01", "from=2026-01-01&to=2027-06-01"} {
+		req := httptest.NewRequest(http.MethodGet, "/rooms/1/recurring-bookings?"+query, nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%q: expected status %d, got %d", query, http.StatusBadRequest, w.Code)
+		}
+	}
+}
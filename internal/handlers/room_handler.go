@@ -1,16 +1,18 @@
 package handlers
 
 import (
-	"encoding/json"
-	"fmt"
+	"errors"
 	"net/http"
-	"strconv"
-	"strings"
 
 	"cloudflaredb/internal/models"
 	"cloudflaredb/internal/repository"
 )
 
+// userIDRequest is the payload of endpoints that only take a user
+type userIDRequest struct {
+	UserID int64 `json:"user_id" validate:"required"`
+}
+
 // RoomHandler handles HTTP requests for room operations
 type RoomHandler struct {
 	repo *repository.RoomRepository
@@ -24,136 +26,210 @@ func NewRoomHandler(repo *repository.RoomRepository) *RoomHandler {
 // CreateRoom handles POST /rooms
 func (h *RoomHandler) CreateRoom(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateRoomRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
-		return
-	}
-
-	if req.Name == "" {
-		respondError(w, http.StatusBadRequest, "Room name is required")
-		return
-	}
-
-	if req.Capacity < 1 {
-		respondError(w, http.StatusBadRequest, "Capacity must be at least 1")
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
 	room, err := h.repo.Create(r.Context(), &req)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create room: %v", err))
+		if errors.Is(err, repository.ErrFloorNotFound) {
+			respondError(w, r, http.StatusNotFound, CodeNotFound, "Floor not found")
+			return
+		}
+		respondInternalError(w, r, "create room", err)
 		return
 	}
 
-	respondJSON(w, http.StatusCreated, room)
+	respondVersioned(w, r, http.StatusCreated, room.Version, room)
 }
 
 // GetRoom handles GET /rooms/{id}
 func (h *RoomHandler) GetRoom(w http.ResponseWriter, r *http.Request) {
-	idStr := strings.TrimPrefix(r.URL.Path, "/rooms/")
-	// Remove any trailing path segments
-	if idx := strings.Index(idStr, "/"); idx != -1 {
-		idStr = idStr[:idx]
-	}
-
-	id, err := strconv.ParseInt(idStr, 10, 64)
-	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid room ID")
+	id, ok := pathID(w, r, "id", "room")
+	if !ok {
 		return
 	}
 
 	room, err := h.repo.GetByID(r.Context(), id)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			respondError(w, http.StatusNotFound, "Room not found")
+		if errors.Is(err, repository.ErrNotFound) {
+			respondError(w, r, http.StatusNotFound, CodeNotFound, "Room not found")
 			return
 		}
-		respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get room: %v", err))
+		respondInternalError(w, r, "get room", err)
 		return
 	}
 
-	respondJSON(w, http.StatusOK, room)
+	respondVersioned(w, r, http.StatusOK, room.Version, room)
 }
 
 // ListRooms handles GET /rooms
 func (h *RoomHandler) ListRooms(w http.ResponseWriter, r *http.Request) {
-	limit := 10
-	offset := 0
+	listRooms(w, r, h.repo, repository.RoomFilter{})
+}
 
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
-		}
+// listRooms responds with a page of the rooms matching the query string, narrowed to the
+// location set in scope
+func listRooms(w http.ResponseWriter, r *http.Request, repo *repository.RoomRepository, scope repository.RoomFilter) {
+	q := newQueryParams(r.URL.Query())
+	page := parsePageParams(q)
+	filter := parseRoomFilter(q)
+	opts := listOptions(q, page, repository.RoomSortColumns)
+	if errs := q.Errors(); len(errs) > 0 {
+		respondValidationError(w, r, errs)
+		return
+	}
+
+	if scope.FloorID > 0 {
+		filter.FloorID = scope.FloorID
+	}
+	if scope.BuildingID > 0 {
+		filter.BuildingID = scope.BuildingID
+	}
+	if scope.SiteID > 0 {
+		filter.SiteID = scope.SiteID
 	}
 
-	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
-		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
-			offset = o
+	rooms, err := repo.List(r.Context(), filter, opts)
+	if err != nil {
+		respondInternalError(w, r, "list rooms", err)
+		return
+	}
+
+	total := -1
+	if page.IncludeTotal {
+		if total, err = repo.Count(r.Context(), filter); err != nil {
+			respondInternalError(w, r, "count rooms", err)
+			return
 		}
 	}
 
-	rooms, err := h.repo.List(r.Context(), limit, offset)
+	respondPage(w, r, page, rooms, total, func(r *models.Room) repository.Cursor {
+		return repository.Cursor{CreatedAt: r.CreatedAt, ID: r.ID}
+	})
+}
+
+// AvailableRooms handles GET /rooms/available?min_free_seats=&limit=&tag=&amenity=&attr.<key>=
+func (h *RoomHandler) AvailableRooms(w http.ResponseWriter, r *http.Request) {
+	q := newQueryParams(r.URL.Query())
+	minFreeSeats := q.PositiveInt("min_free_seats")
+	limit := q.PositiveInt("limit")
+	var filter repository.RoomFilter
+	parseRoomMetadataFilter(q, &filter)
+	if errs := q.Errors(); len(errs) > 0 {
+		respondValidationError(w, r, errs)
+		return
+	}
+
+	if minFreeSeats == 0 {
+		minFreeSeats = 1
+	}
+	if limit == 0 {
+		limit = DefaultPageSize
+	}
+	limit = min(limit, MaxPageSize)
+
+	rooms, err := h.repo.Available(r.Context(), minFreeSeats, limit, filter)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list rooms: %v", err))
+		respondInternalError(w, r, "list available rooms", err)
 		return
 	}
 
+	if rooms == nil {
+		rooms = []*models.RoomAvailability{}
+	}
+
 	respondJSON(w, http.StatusOK, rooms)
 }
 
-// UpdateRoom handles PUT /rooms/{id}
+// UpdateRoom handles PUT /rooms/{id}, replacing every field of the room. An If-Match header makes
+// the update conditional on the room still having the given ETag.
 func (h *RoomHandler) UpdateRoom(w http.ResponseWriter, r *http.Request) {
-	idStr := strings.TrimPrefix(r.URL.Path, "/rooms/")
-	// Remove any trailing path segments
-	if idx := strings.Index(idStr, "/"); idx != -1 {
-		idStr = idStr[:idx]
+	id, ok := pathID(w, r, "id", "room")
+	if !ok {
+		return
+	}
+	version, ok := ifMatchVersion(w, r, "room")
+	if !ok {
+		return
 	}
 
-	id, err := strconv.ParseInt(idStr, 10, 64)
+	var req models.UpdateRoomRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	room, err := h.repo.Update(r.Context(), id, version, &req)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid room ID")
+		respondRoomUpdateError(w, r, "update room", err)
 		return
 	}
 
-	var req models.UpdateRoomRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	respondVersioned(w, r, http.StatusOK, room.Version, room)
+}
+
+// PatchRoom handles PATCH /rooms/{id} with a JSON merge patch, changing only the fields it contains
+func (h *RoomHandler) PatchRoom(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "room")
+	if !ok {
+		return
+	}
+	version, ok := ifMatchVersion(w, r, "room")
+	if !ok {
+		return
+	}
+
+	var req models.PatchRoomRequest
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
-	room, err := h.repo.Update(r.Context(), id, &req)
+	room, err := h.repo.Patch(r.Context(), id, version, &req)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			respondError(w, http.StatusNotFound, "Room not found")
-			return
-		}
-		respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to update room: %v", err))
+		respondRoomUpdateError(w, r, "patch room", err)
 		return
 	}
 
-	respondJSON(w, http.StatusOK, room)
+	respondVersioned(w, r, http.StatusOK, room.Version, room)
+}
+
+// respondRoomUpdateError maps room update errors to problem responses
+func respondRoomUpdateError(w http.ResponseWriter, r *http.Request, action string, err error) {
+	switch {
+	case errors.Is(err, repository.ErrVersionMismatch):
+		respondError(w, r, http.StatusPreconditionFailed, CodePreconditionFailed, "Room was modified since the If-Match ETag")
+	case errors.Is(err, repository.ErrCapacityBelowOccupancy):
+		respondError(w, r, http.StatusConflict, CodeConflict, "Capacity cannot be lower than the number of users assigned to the room")
+	case errors.Is(err, repository.ErrFloorNotFound):
+		respondError(w, r, http.StatusNotFound, CodeNotFound, "Floor not found")
+	case errors.Is(err, repository.ErrNotFound):
+		respondError(w, r, http.StatusNotFound, CodeNotFound, "Room not found")
+	default:
+		respondInternalError(w, r, action, err)
+	}
 }
 
 // DeleteRoom handles DELETE /rooms/{id}
 func (h *RoomHandler) DeleteRoom(w http.ResponseWriter, r *http.Request) {
-	idStr := strings.TrimPrefix(r.URL.Path, "/rooms/")
-	// Remove any trailing path segments
-	if idx := strings.Index(idStr, "/"); idx != -1 {
-		idStr = idStr[:idx]
+	id, ok := pathID(w, r, "id", "room")
+	if !ok {
+		return
 	}
-
-	id, err := strconv.ParseInt(idStr, 10, 64)
-	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid room ID")
+	version, ok := ifMatchVersion(w, r, "room")
+	if !ok {
 		return
 	}
 
-	if err := h.repo.Delete(r.Context(), id); err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			respondError(w, http.StatusNotFound, "Room not found")
+	if err := h.repo.Delete(r.Context(), id, version); err != nil {
+		if errors.Is(err, repository.ErrVersionMismatch) {
+			respondError(w, r, http.StatusPreconditionFailed, CodePreconditionFailed, "Room was modified since the If-Match ETag")
+			return
+		}
+		if errors.Is(err, repository.ErrNotFound) {
+			respondError(w, r, http.StatusNotFound, CodeNotFound, "Room not found")
 			return
 		}
-		respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete room: %v", err))
+		respondInternalError(w, r, "delete room", err)
 		return
 	}
 
@@ -162,130 +238,228 @@ func (h *RoomHandler) DeleteRoom(w http.ResponseWriter, r *http.Request) {
 
 // GetRoomUsers handles GET /rooms/{id}/users
 func (h *RoomHandler) GetRoomUsers(w http.ResponseWriter, r *http.Request) {
-	// Extract room ID from path: /rooms/{id}/users
-	path := strings.TrimPrefix(r.URL.Path, "/rooms/")
-	parts := strings.Split(path, "/")
-
-	if len(parts) < 2 {
-		respondError(w, http.StatusBadRequest, "Invalid path")
-		return
-	}
-
-	id, err := strconv.ParseInt(parts[0], 10, 64)
-	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid room ID")
+	id, ok := pathID(w, r, "id", "room")
+	if !ok {
 		return
 	}
 
 	roomWithUsers, err := h.repo.GetRoomWithUsers(r.Context(), id)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			respondError(w, http.StatusNotFound, "Room not found")
+		if errors.Is(err, repository.ErrNotFound) {
+			respondError(w, r, http.StatusNotFound, CodeNotFound, "Room not found")
 			return
 		}
-		respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get room users: %v", err))
+		respondInternalError(w, r, "get room users", err)
 		return
 	}
 
 	respondJSON(w, http.StatusOK, roomWithUsers)
 }
 
-// AssignUserToRoom handles POST /rooms/{id}/users
+// AssignUserToRoom handles POST /rooms/{id}/users.
+// When the room has no free seat the user is queued on its waitlist and the entry is returned with 202 Accepted.
 func (h *RoomHandler) AssignUserToRoom(w http.ResponseWriter, r *http.Request) {
-	// Extract room ID from path: /rooms/{id}/users
-	path := strings.TrimPrefix(r.URL.Path, "/rooms/")
-	parts := strings.Split(path, "/")
+	roomID, ok := pathID(w, r, "id", "room")
+	if !ok {
+		return
+	}
 
-	if len(parts) < 2 {
-		respondError(w, http.StatusBadRequest, "Invalid path")
+	var req models.AssignUserToRoomRequest
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
-	roomID, err := strconv.ParseInt(parts[0], 10, 64)
+	membership, entry, err := h.repo.AssignOrWaitlist(r.Context(), req.UserID, roomID, req.Role)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid room ID")
+		switch {
+		case errors.Is(err, repository.ErrAlreadyAssigned):
+			respondError(w, r, http.StatusConflict, CodeConflict, "User already assigned to this room")
+		case errors.Is(err, repository.ErrAlreadyWaitlisted):
+			respondError(w, r, http.StatusConflict, CodeConflict, "User already on the waitlist of this room")
+		case errors.Is(err, repository.ErrRoomNotFound):
+			respondError(w, r, http.StatusNotFound, CodeNotFound, "Room not found")
+		case errors.Is(err, repository.ErrUserNotFound):
+			respondError(w, r, http.StatusNotFound, CodeNotFound, "User not found")
+		default:
+			respondInternalError(w, r, "assign user to room", err)
+		}
 		return
 	}
 
-	var req struct {
-		UserID int64 `json:"user_id"`
+	if entry != nil {
+		respondJSON(w, http.StatusAccepted, entry)
+		return
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	respondJSON(w, http.StatusOK, membership)
+}
+
+// UpdateMembership handles PATCH /rooms/{id}/users/{userId}, which changes the user's role
+func (h *RoomHandler) UpdateMembership(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := pathID(w, r, "id", "room")
+	if !ok {
 		return
 	}
 
-	if req.UserID == 0 {
-		respondError(w, http.StatusBadRequest, "User ID is required")
+	userID, ok := pathID(w, r, "userId", "user")
+	if !ok {
 		return
 	}
 
-	if err := h.repo.AssignUserToRoom(r.Context(), req.UserID, roomID); err != nil {
-		respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to assign user to room: %v", err))
+	var req models.UpdateMembershipRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	membership, err := h.repo.UpdateMembershipRole(r.Context(), roomID, userID, req.Role)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotAssigned):
+			respondError(w, r, http.StatusNotFound, CodeNotFound, "User not assigned to this room")
+		case errors.Is(err, repository.ErrLastOwner):
+			respondError(w, r, http.StatusConflict, CodeLastOwner, "Room must keep at least one owner; make another member owner first")
+		default:
+			respondInternalError(w, r, "update membership", err)
+		}
 		return
 	}
 
-	respondJSON(w, http.StatusOK, map[string]string{"message": "User assigned to room successfully"})
+	respondJSON(w, http.StatusOK, membership)
 }
 
-// RemoveUserFromRoom handles DELETE /rooms/{roomId}/users/{userId}
-func (h *RoomHandler) RemoveUserFromRoom(w http.ResponseWriter, r *http.Request) {
-	// Extract IDs from path: /rooms/{roomId}/users/{userId}
-	path := strings.TrimPrefix(r.URL.Path, "/rooms/")
-	parts := strings.Split(path, "/")
+// JoinWaitlist handles POST /rooms/{id}/waitlist.
+// A user who gets a seat right away is reported with status "assigned".
+func (h *RoomHandler) JoinWaitlist(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := pathID(w, r, "id", "room")
+	if !ok {
+		return
+	}
 
-	if len(parts) < 3 {
-		respondError(w, http.StatusBadRequest, "Invalid path")
+	var req userIDRequest
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
-	roomID, err := strconv.ParseInt(parts[0], 10, 64)
+	entry, err := h.repo.JoinWaitlist(r.Context(), roomID, req.UserID)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid room ID")
+		switch {
+		case errors.Is(err, repository.ErrAlreadyWaitlisted):
+			respondError(w, r, http.StatusConflict, CodeConflict, "User already on the waitlist of this room")
+		case errors.Is(err, repository.ErrAlreadyAssigned):
+			respondError(w, r, http.StatusConflict, CodeConflict, "User already assigned to this room")
+		case errors.Is(err, repository.ErrRoomNotFound):
+			respondError(w, r, http.StatusNotFound, CodeNotFound, "Room not found")
+		case errors.Is(err, repository.ErrUserNotFound):
+			respondError(w, r, http.StatusNotFound, CodeNotFound, "User not found")
+		default:
+			respondInternalError(w, r, "join waitlist", err)
+		}
 		return
 	}
 
-	userID, err := strconv.ParseInt(parts[2], 10, 64)
+	respondJSON(w, http.StatusCreated, entry)
+}
+
+// GetWaitlist handles GET /rooms/{id}/waitlist
+func (h *RoomHandler) GetWaitlist(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := pathID(w, r, "id", "room")
+	if !ok {
+		return
+	}
+
+	entries, err := h.repo.Waitlist(r.Context(), roomID)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		if errors.Is(err, repository.ErrRoomNotFound) {
+			respondError(w, r, http.StatusNotFound, CodeNotFound, "Room not found")
+			return
+		}
+		respondInternalError(w, r, "get waitlist", err)
 		return
 	}
 
-	if err := h.repo.RemoveUserFromRoom(r.Context(), userID, roomID); err != nil {
-		if strings.Contains(err.Error(), "not assigned") {
-			respondError(w, http.StatusNotFound, "User not assigned to this room")
+	if entries == nil {
+		entries = []*models.WaitlistEntry{}
+	}
+
+	respondJSON(w, http.StatusOK, entries)
+}
+
+// LeaveWaitlist handles DELETE /rooms/{id}/waitlist/{userId}
+func (h *RoomHandler) LeaveWaitlist(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := pathID(w, r, "id", "room")
+	if !ok {
+		return
+	}
+
+	userID, ok := pathID(w, r, "userId", "user")
+	if !ok {
+		return
+	}
+
+	if err := h.repo.LeaveWaitlist(r.Context(), roomID, userID); err != nil {
+		if errors.Is(err, repository.ErrNotWaitlisted) {
+			respondError(w, r, http.StatusNotFound, CodeNotFound, "User not on the waitlist of this room")
 			return
 		}
-		respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to remove user from room: %v", err))
+		respondInternalError(w, r, "leave waitlist", err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// GetUserRooms handles GET /users/{id}/rooms
-func (h *RoomHandler) GetUserRooms(w http.ResponseWriter, r *http.Request) {
-	// Extract user ID from path: /users/{id}/rooms
-	path := strings.TrimPrefix(r.URL.Path, "/users/")
-	parts := strings.Split(path, "/")
+// RemoveUserFromRoom handles DELETE /rooms/{id}/users/{userId}
+func (h *RoomHandler) RemoveUserFromRoom(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := pathID(w, r, "id", "room")
+	if !ok {
+		return
+	}
 
-	if len(parts) < 2 {
-		respondError(w, http.StatusBadRequest, "Invalid path")
+	userID, ok := pathID(w, r, "userId", "user")
+	if !ok {
 		return
 	}
 
-	userID, err := strconv.ParseInt(parts[0], 10, 64)
-	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid user ID")
+	if err := h.repo.RemoveUserFromRoom(r.Context(), userID, roomID); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotAssigned):
+			respondError(w, r, http.StatusNotFound, CodeNotFound, "User not assigned to this room")
+		case errors.Is(err, repository.ErrLastOwner):
+			respondError(w, r, http.StatusConflict, CodeLastOwner, "Room must keep at least one owner; make another member owner first")
+		default:
+			respondInternalError(w, r, "remove user from room", err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetUserRooms handles GET /users/{id}/rooms
+func (h *RoomHandler) GetUserRooms(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathID(w, r, "id", "user")
+	if !ok {
 		return
 	}
 
 	rooms, err := h.repo.GetUserRooms(r.Context(), userID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get user rooms: %v", err))
+		respondInternalError(w, r, "get user rooms", err)
 		return
 	}
 
 	respondJSON(w, http.StatusOK, rooms)
 }
+
+// validAttributeKey reports whether key can be used to filter on a top-level room attribute
+func validAttributeKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, c := range key {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -30,7 +31,8 @@ func setupTestDBForRooms(t *testing.T) *sql.DB {
 		email TEXT NOT NULL UNIQUE,
 		name TEXT NOT NULL,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		version INTEGER NOT NULL DEFAULT 1
 	);
 
 	CREATE TABLE rooms (
@@ -39,18 +41,197 @@ func setupTestDBForRooms(t *testing.T) *sql.DB {
 		description TEXT NOT NULL DEFAULT '',
 		capacity INTEGER NOT NULL DEFAULT 1,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		attributes TEXT NOT NULL DEFAULT '{}' CHECK (json_valid(attributes) AND json_type(attributes) = 'object'),
+		floor_id INTEGER,
+		version INTEGER NOT NULL DEFAULT 1
+	);
+
+	CREATE TABLE sites (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		address TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
 
+	CREATE TABLE buildings (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		site_id INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(site_id, name)
+	);
+
+	CREATE TABLE floors (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		building_id INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		level INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(building_id, level)
+	);
+
+	CREATE TABLE tags (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE room_tags (
+		room_id INTEGER NOT NULL,
+		tag_id INTEGER NOT NULL,
+		PRIMARY KEY (room_id, tag_id)
+	);
+
+	CREATE TABLE room_amenities (
+		room_id INTEGER NOT NULL,
+		amenity TEXT NOT NULL CHECK (amenity IN ('projector', 'whiteboard', 'video')),
+		PRIMARY KEY (room_id, amenity)
+	);
+
 	CREATE TABLE user_rooms (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		user_id INTEGER NOT NULL,
 		room_id INTEGER NOT NULL,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		role TEXT NOT NULL DEFAULT 'member' CHECK (role IN ('owner', 'moderator', 'member')),
 		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
 		FOREIGN KEY (room_id) REFERENCES rooms(id) ON DELETE CASCADE,
 		UNIQUE(user_id, room_id)
 	);
+
+	CREATE TABLE bookings (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		room_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		title TEXT NOT NULL DEFAULT '',
+		starts_at DATETIME NOT NULL,
+		ends_at DATETIME NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		CHECK (ends_at > starts_at)
+	);
+
+	CREATE TABLE recurring_bookings (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		room_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		title TEXT NOT NULL DEFAULT '',
+		starts_at DATETIME NOT NULL,
+		ends_at DATETIME NOT NULL,
+		timezone TEXT NOT NULL DEFAULT 'UTC',
+		rrule TEXT NOT NULL,
+		last_ends_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE recurring_booking_exceptions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		series_id INTEGER NOT NULL,
+		recurrence_id DATETIME NOT NULL,
+		starts_at DATETIME,
+		ends_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(series_id, recurrence_id)
+	);
+
+	CREATE TABLE groups (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		description TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE group_members (
+		group_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (group_id, user_id)
+	);
+
+	CREATE TABLE room_groups (
+		room_id INTEGER NOT NULL,
+		group_id INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (room_id, group_id)
+	);
+
+	CREATE TABLE room_waitlist (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		room_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(room_id, user_id)
+	);
+
+	CREATE TABLE waitlist_promotions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		room_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		promoted_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TRIGGER user_rooms_dequeue_after_insert AFTER INSERT ON user_rooms BEGIN
+		INSERT INTO waitlist_promotions (room_id, user_id, promoted_at)
+		SELECT room_id, user_id, new.created_at FROM room_waitlist WHERE room_id = new.room_id AND user_id = new.user_id;
+		DELETE FROM room_waitlist WHERE room_id = new.room_id AND user_id = new.user_id;
+	END;
+
+	CREATE TRIGGER user_rooms_promote_after_delete AFTER DELETE ON user_rooms BEGIN
+		UPDATE user_rooms
+		SET role = 'owner'
+		WHERE id = (SELECT MIN(id) FROM user_rooms WHERE room_id = old.room_id)
+		  AND NOT EXISTS (SELECT 1 FROM user_rooms WHERE room_id = old.room_id AND role = 'owner');
+		INSERT INTO user_rooms (user_id, room_id, role, created_at)
+		SELECT w.user_id, w.room_id,
+		       CASE WHEN NOT EXISTS (SELECT 1 FROM user_rooms WHERE room_id = w.room_id)
+		             AND w.id = (SELECT MIN(f.id) FROM room_waitlist f
+		                         WHERE f.room_id = w.room_id
+		                           AND NOT EXISTS (SELECT 1 FROM user_rooms WHERE user_id = f.user_id AND room_id = f.room_id))
+		            THEN 'owner' ELSE 'member' END,
+		       CURRENT_TIMESTAMP
+		FROM room_waitlist w
+		WHERE w.room_id = old.room_id
+		  AND NOT EXISTS (SELECT 1 FROM user_rooms WHERE user_id = w.user_id AND room_id = w.room_id)
+		ORDER BY w.id
+		LIMIT COALESCE((SELECT MAX(0, rooms.capacity - (SELECT COUNT(*) FROM (
+		                    SELECT user_rooms.user_id FROM user_rooms WHERE user_rooms.room_id = rooms.id
+		                    UNION
+		                    SELECT gm.user_id FROM room_groups rg INNER JOIN group_members gm ON gm.group_id = rg.group_id
+		                    WHERE rg.room_id = rooms.id)))
+		                FROM rooms WHERE rooms.id = old.room_id), 0);
+	END;
+
+	CREATE TABLE room_invitations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		room_id INTEGER NOT NULL,
+		invited_by INTEGER NOT NULL,
+		user_id INTEGER,
+		email TEXT,
+		role TEXT NOT NULL DEFAULT 'member' CHECK (role IN ('owner', 'moderator', 'member')),
+		token_hash TEXT NOT NULL UNIQUE,
+		status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined')),
+		expires_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		responded_at DATETIME,
+		CHECK ((user_id IS NULL) != (email IS NULL))
+	);
+
+	CREATE TABLE room_join_requests (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		room_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		message TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+		reviewed_by INTEGER,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		reviewed_at DATETIME
+	);
+
+	CREATE UNIQUE INDEX idx_room_join_requests_pending ON room_join_requests(room_id, user_id) WHERE status = 'pending';
 	`
 
 	if _, err := db.Exec(schema); err != nil {
@@ -66,6 +247,7 @@ func TestRoomHandler_CreateRoom(t *testing.T) {
 
 	repo := repository.NewRoomRepository(db)
 	handler := NewRoomHandler(repo)
+	router := NewRouter((&API{Rooms: handler}).Routes()...)
 
 	tests := []struct {
 		name           string
@@ -108,6 +290,38 @@ func TestRoomHandler_CreateRoom(t *testing.T) {
 			expectedStatus: http.StatusBadRequest,
 			checkResponse:  nil,
 		},
+		{
+			name: "with metadata",
+			requestBody: models.CreateRoomRequest{
+				Name:       "Studio",
+				Capacity:   4,
+				Tags:       []string{"Recording"},
+				Amenities:  []string{models.AmenityVideo},
+				Attributes: json.RawMessage(`{"floor": 2}`),
+			},
+			expectedStatus: http.StatusCreated,
+			checkResponse: func(t *testing.T, body []byte) {
+				var room models.Room
+				if err := json.Unmarshal(body, &room); err != nil {
+					t.Errorf("Failed to unmarshal response: %v", err)
+				}
+				if len(room.Tags) != 1 || room.Tags[0] != "recording" || len(room.Amenities) != 1 || string(room.Attributes) != `{"floor":2}` {
+					t.Errorf("Unexpected metadata: %+v", room)
+				}
+			},
+		},
+		{
+			name:           "unknown amenity",
+			requestBody:    `{"name": "Studio", "capacity": 4, "amenities": ["sauna"]}`,
+			expectedStatus: http.StatusBadRequest,
+			checkResponse:  nil,
+		},
+		{
+			name:           "attributes not an object",
+			requestBody:    `{"name": "Studio", "capacity": 4, "attributes": [1, 2]}`,
+			expectedStatus: http.StatusBadRequest,
+			checkResponse:  nil,
+		},
 	}
 
 	for _, tt := range tests {
@@ -127,7 +341,7 @@ func TestRoomHandler_CreateRoom(t *testing.T) {
 			req := httptest.NewRequest(http.MethodPost, "/rooms", bytes.NewReader(body))
 			w := httptest.NewRecorder()
 
-			handler.CreateRoom(w, req)
+			router.ServeHTTP(w, req)
 
 			if w.Code != tt.expectedStatus {
 				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
@@ -146,6 +360,7 @@ func TestRoomHandler_GetRoom(t *testing.T) {
 
 	repo := repository.NewRoomRepository(db)
 	handler := NewRoomHandler(repo)
+	router := NewRouter((&API{Rooms: handler}).Routes()...)
 
 	// Create a test room
 	ctx := context.Background()
@@ -185,7 +400,7 @@ func TestRoomHandler_GetRoom(t *testing.T) {
 			req := httptest.NewRequest(http.MethodGet, "/rooms/"+tt.roomID, nil)
 			w := httptest.NewRecorder()
 
-			handler.GetRoom(w, req)
+			router.ServeHTTP(w, req)
 
 			if w.Code != tt.expectedStatus {
 				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
@@ -210,14 +425,21 @@ func TestRoomHandler_ListRooms(t *testing.T) {
 
 	repo := repository.NewRoomRepository(db)
 	handler := NewRoomHandler(repo)
+	router := NewRouter((&API{Rooms: handler}).Routes()...)
 
 	// Create test rooms
 	ctx := context.Background()
 	for i := 1; i <= 5; i++ {
-		_, err := repo.Create(ctx, &models.CreateRoomRequest{
-			Name:     "Room " + string(rune(i)),
-			Capacity: i * 10,
-		})
+		req := &models.CreateRoomRequest{
+			Name:       "Room " + string(rune(i)),
+			Capacity:   i * 10,
+			Attributes: json.RawMessage(fmt.Sprintf(`{"floor": %d}`, i)),
+		}
+		if i%2 == 0 {
+			req.Tags = []string{"even"}
+			req.Amenities = []string{models.AmenityProjector}
+		}
+		_, err := repo.Create(ctx, req)
 		if err != nil {
 			t.Fatalf("Failed to create test room: %v", err)
 		}
@@ -247,6 +469,23 @@ func TestRoomHandler_ListRooms(t *testing.T) {
 			expectedStatus: http.StatusOK,
 			expectedCount:  3,
 		},
+		{
+			name:           "tag",
+			queryParams:    "?tag=even",
+			expectedStatus: http.StatusOK,
+			expectedCount:  2,
+		},
+		{
+			name:           "amenity and attribute",
+			queryParams:    "?amenity=projector&attr.floor=4",
+			expectedStatus: http.StatusOK,
+			expectedCount:  1,
+		},
+		{
+			name:           "unknown amenity",
+			queryParams:    "?amenity=sauna",
+			expectedStatus: http.StatusBadRequest,
+		},
 	}
 
 	for _, tt := range tests {
@@ -254,7 +493,7 @@ func TestRoomHandler_ListRooms(t *testing.T) {
 			req := httptest.NewRequest(http.MethodGet, "/rooms"+tt.queryParams, nil)
 			w := httptest.NewRecorder()
 
-			handler.ListRooms(w, req)
+			router.ServeHTTP(w, req)
 
 			if w.Code != tt.expectedStatus {
 				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
@@ -273,13 +512,76 @@ func TestRoomHandler_ListRooms(t *testing.T) {
 	}
 }
 
+func TestRoomHandler_ListRoomsCursor(t *testing.T) {
+	db := setupTestDBForRooms(t)
+	defer db.Close()
+
+	repo := repository.NewRoomRepository(db)
+	handler := NewRoomHandler(repo)
+	router := NewRouter((&API{Rooms: handler}).Routes()...)
+
+	ctx := context.Background()
+	for i := 1; i <= 5; i++ {
+		if _, err := repo.Create(ctx, &models.CreateRoomRequest{Name: fmt.Sprintf("Room %d", i), Capacity: 10}); err != nil {
+			t.Fatalf("Failed to create test room: %v", err)
+		}
+	}
+
+	// Walk every page and collect the room IDs in order
+	var ids []int64
+	query := "?after=&limit=2"
+	for pages := 0; pages < 5; pages++ {
+		req := httptest.NewRequest(http.MethodGet, "/rooms"+query, nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var page struct {
+			Items      []*models.Room `json:"items"`
+			NextCursor string         `json:"next_cursor"`
+			HasMore    bool           `json:"has_more"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		for _, room := range page.Items {
+			ids = append(ids, room.ID)
+		}
+
+		if !page.HasMore {
+			if page.NextCursor != "" {
+				t.Errorf("Expected no next_cursor on the last page, got %q", page.NextCursor)
+			}
+			break
+		}
+		query = "?limit=2&after=" + page.NextCursor
+	}
+
+	if fmt.Sprint(ids) != "[5 4 3 2 1]" {
+		t.Errorf("Expected rooms [5 4 3 2 1], got %v", ids)
+	}
+
+	// An undecodable cursor is rejected
+	req := httptest.NewRequest(http.MethodGet, "/rooms?after=garbage", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an invalid cursor, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
 func TestRoomHandler_AssignUserToRoom(t *testing.T) {
 	db := setupTestDBForRooms(t)
 	defer db.Close()
 
 	roomRepo := repository.NewRoomRepository(db)
-	userRepo := repository.NewUserRepository(db)
+	userRepo := repository.NewUserRepository(db, roomRepo)
 	handler := NewRoomHandler(roomRepo)
+	router := NewRouter((&API{Rooms: handler}).Routes()...)
 
 	ctx := context.Background()
 
@@ -303,12 +605,18 @@ func TestRoomHandler_AssignUserToRoom(t *testing.T) {
 	req := httptest.NewRequest(http.MethodPost, "/rooms/1/users", bytes.NewReader(body))
 	w := httptest.NewRecorder()
 
-	handler.AssignUserToRoom(w, req)
+	router.ServeHTTP(w, req)
 
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
 	}
 
+	var membership models.UserRoom
+	json.NewDecoder(w.Body).Decode(&membership)
+	if membership.UserID != user.ID || membership.Role != models.RoleOwner {
+		t.Errorf("Expected the first user to become owner, got %+v", membership)
+	}
+
 	// Verify assignment
 	rooms, err := roomRepo.GetUserRooms(ctx, user.ID)
 	if err != nil {
@@ -318,6 +626,348 @@ func TestRoomHandler_AssignUserToRoom(t *testing.T) {
 	if len(rooms) != 1 || rooms[0].ID != room.ID {
 		t.Error("User not properly assigned to room")
 	}
+
+	// An unknown user is not found, even though foreign keys are not enforced
+	req = httptest.NewRequest(http.MethodPost, "/rooms/1/users", bytes.NewReader([]byte(`{"user_id": 9999}`)))
+	w = httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for an unknown user, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestRoomHandler_AssignUserToFullRoom(t *testing.T) {
+	db := setupTestDBForRooms(t)
+	defer db.Close()
+
+	roomRepo := repository.NewRoomRepository(db)
+	userRepo := repository.NewUserRepository(db, roomRepo)
+	handler := NewRoomHandler(roomRepo)
+	router := NewRouter((&API{Rooms: handler}).Routes()...)
+
+	ctx := context.Background()
+
+	user1, _ := userRepo.Create(ctx, &models.CreateUserRequest{Email: "user1@example.com", Name: "User 1"})
+	user2, _ := userRepo.Create(ctx, &models.CreateUserRequest{Email: "user2@example.com", Name: "User 2"})
+
+	room, _ := roomRepo.Create(ctx, &models.CreateRoomRequest{
+		Name:     "Phone Booth",
+		Capacity: 1,
+	})
+
+	if _, err := roomRepo.AssignUserToRoom(ctx, user1.ID, room.ID, ""); err != nil {
+		t.Fatalf("Failed to assign user to room: %v", err)
+	}
+
+	body, _ := json.Marshal(map[string]int64{"user_id": user2.ID})
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/rooms/%d/users", room.ID), bytes.NewReader(body))
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+	}
+
+	var entry models.WaitlistEntry
+	if err := json.NewDecoder(w.Body).Decode(&entry); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if entry.UserID != user2.ID || entry.Status != models.WaitlistStatusWaiting || entry.Position != 1 {
+		t.Errorf("Expected user %d waiting at position 1, got %+v", user2.ID, entry)
+	}
+
+	waitlist, err := roomRepo.Waitlist(ctx, room.ID)
+	if err != nil || len(waitlist) != 1 || waitlist[0].UserID != user2.ID {
+		t.Fatalf("Expected user %d on the waitlist, got %v (%v)", user2.ID, waitlist, err)
+	}
+
+	// Queuing the same user twice is a conflict
+	req = httptest.NewRequest(http.MethodPost, fmt.Sprintf("/rooms/%d/users", room.ID), bytes.NewReader(body))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+}
+
+func TestRoomHandler_Waitlist(t *testing.T) {
+	db := setupTestDBForRooms(t)
+	defer db.Close()
+
+	roomRepo := repository.NewRoomRepository(db)
+	userRepo := repository.NewUserRepository(db, roomRepo)
+	handler := NewRoomHandler(roomRepo)
+	router := NewRouter((&API{Rooms: handler}).Routes()...)
+
+	ctx := context.Background()
+
+	user1, _ := userRepo.Create(ctx, &models.CreateUserRequest{Email: "user1@example.com", Name: "User 1"})
+	user2, _ := userRepo.Create(ctx, &models.CreateUserRequest{Email: "user2@example.com", Name: "User 2"})
+
+	room, _ := roomRepo.Create(ctx, &models.CreateRoomRequest{
+		Name:     "Phone Booth",
+		Capacity: 1,
+	})
+
+	if _, err := roomRepo.AssignUserToRoom(ctx, user1.ID, room.ID, ""); err != nil {
+		t.Fatalf("Failed to assign user to room: %v", err)
+	}
+
+	join := func(userID int64) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]int64{"user_id": userID})
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/rooms/%d/waitlist", room.ID), bytes.NewReader(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := join(user2.ID)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	var entry models.WaitlistEntry
+	json.NewDecoder(w.Body).Decode(&entry)
+	if entry.Status != models.WaitlistStatusWaiting || entry.Position != 1 {
+		t.Errorf("Expected waiting at position 1, got %+v", entry)
+	}
+
+	if w := join(user2.ID); w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d for a duplicate, got %d", http.StatusConflict, w.Code)
+	}
+	if w := join(9999); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for a missing user, got %d", http.StatusNotFound, w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/rooms/%d/waitlist", room.ID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var entries []models.WaitlistEntry
+	json.NewDecoder(w.Body).Decode(&entries)
+	if w.Code != http.StatusOK || len(entries) != 1 || entries[0].UserID != user2.ID {
+		t.Errorf("Expected user %d on the waitlist, got %d %+v", user2.ID, w.Code, entries)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/rooms/%d/waitlist/%d", room.ID, user2.ID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected status %d, got %d", http.StatusNoContent, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d when not waitlisted, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestRoomHandler_UpdateMembership(t *testing.T) {
+	db := setupTestDBForRooms(t)
+	defer db.Close()
+
+	roomRepo := repository.NewRoomRepository(db)
+	userRepo := repository.NewUserRepository(db, roomRepo)
+	handler := NewRoomHandler(roomRepo)
+	router := NewRouter((&API{Rooms: handler}).Routes()...)
+
+	ctx := context.Background()
+
+	owner, _ := userRepo.Create(ctx, &models.CreateUserRequest{Email: "owner@example.com", Name: "Owner"})
+	member, _ := userRepo.Create(ctx, &models.CreateUserRequest{Email: "member@example.com", Name: "Member"})
+	room, _ := roomRepo.Create(ctx, &models.CreateRoomRequest{Name: "Team Room", Capacity: 5})
+
+	for _, id := range []int64{owner.ID, member.ID} {
+		if _, err := roomRepo.AssignUserToRoom(ctx, id, room.ID, ""); err != nil {
+			t.Fatalf("Failed to assign user to room: %v", err)
+		}
+	}
+
+	patch := func(userID int64, role string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(models.UpdateMembershipRequest{Role: role})
+		req := httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/rooms/%d/users/%d", room.ID, userID), bytes.NewReader(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := patch(member.ID, models.RoleModerator)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var membership models.UserRoom
+	json.NewDecoder(w.Body).Decode(&membership)
+	if membership.Role != models.RoleModerator {
+		t.Errorf("Expected role %s, got %+v", models.RoleModerator, membership)
+	}
+
+	if w := patch(owner.ID, models.RoleMember); w.Code != http.StatusConflict || decodeProblem(t, w).Code != CodeLastOwner {
+		t.Errorf("Expected a %s problem when demoting the last owner, got %d", CodeLastOwner, w.Code)
+	}
+	if w := patch(member.ID, "admin"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an invalid role, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := patch(9999, models.RoleMember); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for a non-member, got %d", http.StatusNotFound, w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/rooms/%d/users/%d", room.ID, owner.ID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d when removing the last owner, got %d", http.StatusConflict, w.Code)
+	}
+}
+
+func TestRoomHandler_UpdateRoomBelowOccupancy(t *testing.T) {
+	db := setupTestDBForRooms(t)
+	defer db.Close()
+
+	roomRepo := repository.NewRoomRepository(db)
+	userRepo := repository.NewUserRepository(db, roomRepo)
+	handler := NewRoomHandler(roomRepo)
+	router := NewRouter((&API{Rooms: handler}).Routes()...)
+
+	ctx := context.Background()
+
+	room, _ := roomRepo.Create(ctx, &models.CreateRoomRequest{
+		Name:     "Team Room",
+		Capacity: 4,
+	})
+
+	for i := 0; i < 3; i++ {
+		user, _ := userRepo.Create(ctx, &models.CreateUserRequest{
+			Email: fmt.Sprintf("user%d@example.com", i),
+			Name:  fmt.Sprintf("User %d", i),
+		})
+		if _, err := roomRepo.AssignUserToRoom(ctx, user.ID, room.ID, ""); err != nil {
+			t.Fatalf("Failed to assign user to room: %v", err)
+		}
+	}
+
+	body, _ := json.Marshal(models.UpdateRoomRequest{Name: "Team Room", Capacity: 2})
+	req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/rooms/%d", room.ID), bytes.NewReader(body))
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+}
+
+func TestRoomHandler_PatchRoom(t *testing.T) {
+	db := setupTestDBForRooms(t)
+	defer db.Close()
+
+	roomRepo := repository.NewRoomRepository(db)
+	handler := NewRoomHandler(roomRepo)
+	router := NewRouter((&API{Rooms: handler}).Routes()...)
+
+	room, err := roomRepo.Create(context.Background(), &models.CreateRoomRequest{
+		Name:        "Team Room",
+		Description: "Sunny",
+		Capacity:    4,
+		Tags:        []string{"quiet"},
+		Attributes:  json.RawMessage(`{"floor": 3, "building": "north"}`),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test room: %v", err)
+	}
+	path := fmt.Sprintf("/rooms/%d", room.ID)
+
+	patch := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, path, bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", mergePatchContentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := patch(`{"description": null, "capacity": 6, "attributes": {"building": null, "wing": "east"}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var patched models.Room
+	if err := json.NewDecoder(w.Body).Decode(&patched); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if patched.Name != "Team Room" || patched.Description != "" || patched.Capacity != 6 || len(patched.Tags) != 1 {
+		t.Errorf("Expected only the description and capacity to change, got %+v", patched)
+	}
+	var attributes map[string]any
+	if err := json.Unmarshal(patched.Attributes, &attributes); err != nil || len(attributes) != 2 || attributes["wing"] != "east" {
+		t.Errorf("Expected the attributes to be merged, got %s", patched.Attributes)
+	}
+
+	if w := patch(`{"name": null, "capacity": 0}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	} else if p := decodeProblem(t, w); fmt.Sprint(p.Errors) != "[{name must not be null} {capacity must be at least 1}]" {
+		t.Errorf("Unexpected errors %v", p.Errors)
+	}
+
+	if w := patch(`{"floor_id": 99}`); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestRoomHandler_ConditionalRequests(t *testing.T) {
+	db := setupTestDBForRooms(t)
+	defer db.Close()
+
+	roomRepo := repository.NewRoomRepository(db)
+	handler := NewRoomHandler(roomRepo)
+	router := NewRouter((&API{Rooms: handler}).Routes()...)
+
+	room, err := roomRepo.Create(context.Background(), &models.CreateRoomRequest{Name: "Team Room", Capacity: 4})
+	if err != nil {
+		t.Fatalf("Failed to create test room: %v", err)
+	}
+	path := fmt.Sprintf("/rooms/%d", room.ID)
+
+	send := func(method, body, ifMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", mergePatchContentType)
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("If-None-Match", `"1"`)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified || w.Header().Get("ETag") != `"1"` {
+		t.Errorf("Expected status %d with ETag \"1\", got %d with %q", http.StatusNotModified, w.Code, w.Header().Get("ETag"))
+	}
+
+	if w := send(http.MethodPatch, `{"capacity": 6}`, `"1"`); w.Code != http.StatusOK || w.Header().Get("ETag") != `"2"` {
+		t.Fatalf("Expected status %d with ETag \"2\", got %d with %q: %s", http.StatusOK, w.Code, w.Header().Get("ETag"), w.Body.String())
+	}
+	if w := send(http.MethodPut, `{"name": "Stale", "capacity": 2}`, `"1"`); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected status %d, got %d: %s", http.StatusPreconditionFailed, w.Code, w.Body.String())
+	}
+	if w := send(http.MethodDelete, "", `"1"`); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected status %d, got %d: %s", http.StatusPreconditionFailed, w.Code, w.Body.String())
+	}
+	if w := send(http.MethodPatch, `{"capacity": 8}`, `"1"`); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected status %d, got %d: %s", http.StatusPreconditionFailed, w.Code, w.Body.String())
+	}
+
+	if w := send(http.MethodDelete, "", `"2"`); w.Code != http.StatusNoContent {
+		t.Errorf("Expected status %d, got %d: %s", http.StatusNoContent, w.Code, w.Body.String())
+	}
+	if w := send(http.MethodDelete, "", `"2"`); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for a deleted room, got %d", http.StatusNotFound, w.Code)
+	}
 }
 
 func TestRoomHandler_GetRoomUsers(t *testing.T) {
@@ -325,8 +975,9 @@ func TestRoomHandler_GetRoomUsers(t *testing.T) {
 	defer db.Close()
 
 	roomRepo := repository.NewRoomRepository(db)
-	userRepo := repository.NewUserRepository(db)
+	userRepo := repository.NewUserRepository(db, roomRepo)
 	handler := NewRoomHandler(roomRepo)
+	router := NewRouter((&API{Rooms: handler}).Routes()...)
 
 	ctx := context.Background()
 
@@ -347,13 +998,13 @@ func TestRoomHandler_GetRoomUsers(t *testing.T) {
 	})
 
 	// Assign users to room
-	roomRepo.AssignUserToRoom(ctx, user1.ID, room.ID)
-	roomRepo.AssignUserToRoom(ctx, user2.ID, room.ID)
+	roomRepo.AssignUserToRoom(ctx, user1.ID, room.ID, "")
+	roomRepo.AssignUserToRoom(ctx, user2.ID, room.ID, "")
 
 	req := httptest.NewRequest(http.MethodGet, "/rooms/1/users", nil)
 	w := httptest.NewRecorder()
 
-	handler.GetRoomUsers(w, req)
+	router.ServeHTTP(w, req)
 
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
@@ -368,3 +1019,59 @@ func TestRoomHandler_GetRoomUsers(t *testing.T) {
 		t.Errorf("Expected 2 users in room, got %d", len(roomWithUsers.Users))
 	}
 }
+
+func TestRoomHandler_AvailableRooms(t *testing.T) {
+	db := setupTestDBForRooms(t)
+	defer db.Close()
+
+	handler := NewRoomHandler(repository.NewRoomRepository(db))
+	router := NewRouter((&API{Rooms: handler}).Routes()...)
+
+	if _, err := db.Exec(`INSERT INTO users (email, name) VALUES ('a@example.com', 'A');
+		INSERT INTO rooms (name, capacity) VALUES ('Hall', 20), ('Office', 6);
+		INSERT INTO user_rooms (user_id, room_id) VALUES (1, 2);`); err != nil {
+		t.Fatalf("Failed to seed data: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/rooms/available?min_free_seats=5", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var rooms []models.RoomAvailability
+	if err := json.Unmarshal(w.Body.Bytes(), &rooms); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(rooms) != 2 || rooms[0].Name != "Office" || rooms[0].FreeSeats != 5 || rooms[0].Occupancy != 1 {
+		t.Errorf("Expected Office with 5 free seats first, got %+v", rooms)
+	}
+
+	if _, err := db.Exec(`INSERT INTO tags (name) VALUES ('quiet');
+		INSERT INTO room_tags (room_id, tag_id) VALUES (1, 1);`); err != nil {
+		t.Fatalf("Failed to tag room: %v", err)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/rooms/available?min_free_seats=5&tag=quiet", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	rooms = nil
+	json.Unmarshal(w.Body.Bytes(), &rooms)
+	if w.Code != http.StatusOK || len(rooms) != 1 || rooms[0].Name != "Hall" {
+		t.Errorf("Expected only the tagged Hall, got %d %+v", w.Code, rooms)
+	}
+
+	for _, query := range []string{"min_free_seats=0", "amenity=sauna", "attr.a.b=1"} {
+		req := httptest.NewRequest(http.MethodGet, "/rooms/available?"+query, nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", query, http.StatusBadRequest, w.Code)
+		}
+	}
+}
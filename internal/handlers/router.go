@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Route is a single API endpoint: a method and an http.ServeMux path pattern, such as
+// GET /rooms/{id}/users/{userId}, served by handler
+type Route struct {
+	Method  string
+	Pattern string
+	Summary string
+	Handler http.HandlerFunc
+}
+
+// Router dispatches requests to routes by method and path pattern. Paths that match no route
+// get a 404 problem, and paths that match only routes for other methods get a 405 problem
+// listing the supported methods in the Allow header.
+type Router struct {
+	mux     *http.ServeMux
+	routes  []Route
+	methods []string
+}
+
+// NewRouter creates a router serving routes
+func NewRouter(routes ...Route) *Router {
+	rt := &Router{mux: http.NewServeMux()}
+	for _, route := range routes {
+		rt.Handle(route)
+	}
+	return rt
+}
+
+// Handle registers a route. Like http.ServeMux, it panics when the route conflicts with a registered one.
+func (rt *Router) Handle(route Route) {
+	rt.mux.HandleFunc(route.Method+" "+route.Pattern, route.Handler)
+	rt.routes = append(rt.routes, route)
+
+	methods := []string{route.Method}
+	if route.Method == http.MethodGet {
+		// ServeMux serves HEAD requests with GET routes
+		methods = append(methods, http.MethodHead)
+	}
+	for _, method := range methods {
+		if !slices.Contains(rt.methods, method) {
+			rt.methods = append(rt.methods, method)
+		}
+	}
+	slices.Sort(rt.methods)
+}
+
+// Routes returns the registered routes in registration order
+func (rt *Router) Routes() []Route {
+	return slices.Clone(rt.routes)
+}
+
+// ServeHTTP dispatches the request to the route matching its method and path
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if _, pattern := rt.mux.Handler(r); pattern != "" {
+		rt.mux.ServeHTTP(w, r)
+		return
+	}
+
+	if allowed := rt.allowedMethods(r); len(allowed) > 0 {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		MethodNotAllowed(w, r)
+		return
+	}
+	NotFound(w, r)
+}
+
+// allowedMethods returns the methods that have a route matching the path of r
+func (rt *Router) allowedMethods(r *http.Request) []string {
+	var allowed []string
+	for _, method := range rt.methods {
+		probe := r.Clone(r.Context())
+		probe.Method = method
+		if _, pattern := rt.mux.Handler(probe); pattern != "" {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
+
+// pathID parses the path wildcard name, e.g. id in /rooms/{id}, as the ID of resource.
+// It sends a 400 problem naming the resource when the ID is invalid.
+func pathID(w http.ResponseWriter, r *http.Request, name, resource string) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue(name), 10, 64)
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, CodeInvalidRequest, "Invalid "+resource+" ID")
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouter_MethodNotAllowed(t *testing.T) {
+	router := NewRouter((&API{}).Routes()...)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+		allow  string
+	}{
+		{name: "collection", method: http.MethodPatch, path: "/rooms", status: http.StatusMethodNotAllowed, allow: "GET, HEAD, POST"},
+		{name: "item", method: http.MethodPost, path: "/rooms/1", status: http.StatusMethodNotAllowed, allow: "DELETE, GET, HEAD, PATCH, PUT"},
+		{name: "nested item", method: http.MethodGet, path: "/rooms/1/users/2", status: http.StatusMethodNotAllowed, allow: "DELETE, PATCH"},
+		{name: "literal segment", method: http.MethodPost, path: "/rooms/available", status: http.StatusMethodNotAllowed, allow: "DELETE, GET, HEAD, PATCH, PUT"},
+		{name: "unknown path", method: http.MethodGet, path: "/rooms/1/unknown", status: http.StatusNotFound},
+		{name: "trailing slash", method: http.MethodGet, path: "/users/", status: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d", tt.status, w.Code)
+			}
+			if got := w.Header().Get("Allow"); got != tt.allow {
+				t.Errorf("Expected Allow %q, got %q", tt.allow, got)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != problemContentType {
+				t.Errorf("Expected Content-Type %s, got %s", problemContentType, ct)
+			}
+		})
+	}
+}
+
+func TestRouter_PathValues(t *testing.T) {
+	var got []string
+	router := NewRouter(Route{
+		Method:  http.MethodDelete,
+		Pattern: "/rooms/{id}/users/{userId}",
+		Handler: func(w http.ResponseWriter, r *http.Request) {
+			got = []string{r.PathValue("id"), r.PathValue("userId")}
+		},
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/rooms/7/users/42", nil))
+
+	if len(got) != 2 || got[0] != "7" || got[1] != "42" {
+		t.Errorf("Expected path values [7 42], got %v", got)
+	}
+
+	if routes := router.Routes(); len(routes) != 1 || routes[0].Pattern != "/rooms/{id}/users/{userId}" {
+		t.Errorf("Expected the registered route, got %+v", routes)
+	}
+}
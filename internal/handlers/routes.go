@@ -0,0 +1,117 @@
+package handlers
+
+import "net/http"
+
+// API groups the handlers behind the HTTP API
+type API struct {
+	Users       *UserHandler
+	Rooms       *RoomHandler
+	Search      *SearchHandler
+	Bookings    *BookingHandler
+	Recurring   *RecurringBookingHandler
+	Calendars   *CalendarHandler
+	Invitations *InvitationHandler
+	Locations   *LocationHandler
+	Groups      *GroupHandler
+}
+
+// Routes returns the route table of the API. Handlers are method values, so the table
+// can be built from an empty API when only the methods and patterns are needed.
+func (a *API) Routes() []Route {
+	return []Route{
+		{http.MethodGet, "/health", "Health check", Health},
+		{http.MethodGet, "/openapi.json", "OpenAPI specification of the API", a.OpenAPI},
+
+		// Users
+		{http.MethodGet, "/users", "List users", a.Users.ListUsers},
+		{http.MethodPost, "/users", "Create a user", a.Users.CreateUser},
+		{http.MethodGet, "/users/{id}", "Get a user", a.Users.GetUser},
+		{http.MethodPut, "/users/{id}", "Replace a user", a.Users.UpdateUser},
+		{http.MethodPatch, "/users/{id}", "Update some fields of a user with a JSON merge patch", a.Users.PatchUser},
+		{http.MethodDelete, "/users/{id}", "Delete a user", a.Users.DeleteUser},
+		{http.MethodGet, "/users/{id}/rooms", "List the rooms of a user", a.Rooms.GetUserRooms},
+		{http.MethodGet, "/users/{id}/calendar.ics", "iCalendar feed of a user's room assignments", a.Calendars.UserCalendar},
+		{http.MethodGet, "/users/{id}/invitations", "List invitations addressed to a user", a.Invitations.ListUserInvitations},
+
+		// Rooms
+		{http.MethodGet, "/rooms", "List rooms", a.Rooms.ListRooms},
+		{http.MethodPost, "/rooms", "Create a room", a.Rooms.CreateRoom},
+		{http.MethodGet, "/rooms/available", "Find rooms with free seats", a.Rooms.AvailableRooms},
+		{http.MethodGet, "/rooms/{id}", "Get a room", a.Rooms.GetRoom},
+		{http.MethodPut, "/rooms/{id}", "Replace a room", a.Rooms.UpdateRoom},
+		{http.MethodPatch, "/rooms/{id}", "Update some fields of a room with a JSON merge patch", a.Rooms.PatchRoom},
+		{http.MethodDelete, "/rooms/{id}", "Delete a room", a.Rooms.DeleteRoom},
+		{http.MethodGet, "/rooms/{id}/users", "Get a room with its users", a.Rooms.GetRoomUsers},
+		{http.MethodPost, "/rooms/{id}/users", "Assign a user to a room", a.Rooms.AssignUserToRoom},
+		{http.MethodPatch, "/rooms/{id}/users/{userId}", "Change the role of a user in a room", a.Rooms.UpdateMembership},
+		{http.MethodDelete, "/rooms/{id}/users/{userId}", "Remove a user from a room", a.Rooms.RemoveUserFromRoom},
+		{http.MethodGet, "/rooms/{id}/waitlist", "List the waitlist of a room", a.Rooms.GetWaitlist},
+		{http.MethodPost, "/rooms/{id}/waitlist", "Join the waitlist of a room", a.Rooms.JoinWaitlist},
+		{http.MethodDelete, "/rooms/{id}/waitlist/{userId}", "Leave the waitlist of a room", a.Rooms.LeaveWaitlist},
+		{http.MethodGet, "/rooms/{id}/groups", "List the groups assigned to a room", a.Groups.ListRoomGroups},
+		{http.MethodPost, "/rooms/{id}/groups", "Assign a group to a room", a.Groups.AssignGroupToRoom},
+		{http.MethodDelete, "/rooms/{id}/groups/{groupId}", "Remove a group from a room", a.Groups.RemoveGroupFromRoom},
+		{http.MethodGet, "/rooms/{id}/calendar.ics", "iCalendar feed of a room's assignments", a.Calendars.RoomCalendar},
+
+		// Bookings
+		{http.MethodGet, "/rooms/{id}/bookings", "List the bookings of a room", a.Bookings.ListRoomBookings},
+		{http.MethodPost, "/rooms/{id}/bookings", "Book a room", a.Bookings.CreateBooking},
+		{http.MethodDelete, "/bookings/{id}", "Cancel a booking", a.Bookings.DeleteBooking},
+		{http.MethodGet, "/rooms/{id}/recurring-bookings", "List the recurring booking occurrences of a room", a.Recurring.ListRoomOccurrences},
+		{http.MethodPost, "/rooms/{id}/recurring-bookings", "Create a recurring booking", a.Recurring.CreateRecurringBooking},
+		{http.MethodGet, "/recurring-bookings/{id}", "Get a recurring booking", a.Recurring.GetRecurringBooking},
+		{http.MethodDelete, "/recurring-bookings/{id}", "Delete a recurring booking", a.Recurring.DeleteRecurringBooking},
+		{http.MethodPut, "/recurring-bookings/{id}/occurrences/{recurrence_id}", "Move an occurrence of a recurring booking", a.Recurring.MoveOccurrence},
+		{http.MethodDelete, "/recurring-bookings/{id}/occurrences/{recurrence_id}", "Cancel an occurrence of a recurring booking", a.Recurring.CancelOccurrence},
+
+		// Invitations and join requests
+		{http.MethodGet, "/rooms/{id}/invitations", "List the invitations of a room", a.Invitations.ListRoomInvitations},
+		{http.MethodPost, "/rooms/{id}/invitations", "Invite a user to a room", a.Invitations.CreateInvitation},
+		{http.MethodPost, "/invitations/accept", "Accept an invitation with its token", a.Invitations.AcceptInvitation},
+		{http.MethodPost, "/invitations/{id}/accept", "Accept an invitation", a.Invitations.AcceptInvitation},
+		{http.MethodPost, "/invitations/{id}/decline", "Decline an invitation", a.Invitations.DeclineInvitation},
+		{http.MethodGet, "/rooms/{id}/join-requests", "List the join requests of a room", a.Invitations.ListJoinRequests},
+		{http.MethodPost, "/rooms/{id}/join-requests", "Request to join a room", a.Invitations.CreateJoinRequest},
+		{http.MethodPost, "/join-requests/{id}/approve", "Approve a join request", a.Invitations.ApproveJoinRequest},
+		{http.MethodPost, "/join-requests/{id}/reject", "Reject a join request", a.Invitations.RejectJoinRequest},
+
+		// Groups
+		{http.MethodGet, "/groups", "List groups", a.Groups.ListGroups},
+		{http.MethodPost, "/groups", "Create a group", a.Groups.CreateGroup},
+		{http.MethodGet, "/groups/{id}", "Get a group", a.Groups.GetGroup},
+		{http.MethodPut, "/groups/{id}", "Update a group", a.Groups.UpdateGroup},
+		{http.MethodDelete, "/groups/{id}", "Delete a group", a.Groups.DeleteGroup},
+		{http.MethodGet, "/groups/{id}/members", "List the members of a group", a.Groups.ListMembers},
+		{http.MethodPost, "/groups/{id}/members", "Add a user to a group", a.Groups.AddMember},
+		{http.MethodDelete, "/groups/{id}/members/{userId}", "Remove a user from a group", a.Groups.RemoveMember},
+
+		// Locations
+		{http.MethodGet, "/sites", "List sites", a.Locations.ListSites},
+		{http.MethodPost, "/sites", "Create a site", a.Locations.CreateSite},
+		{http.MethodGet, "/sites/{id}", "Get a site", a.Locations.GetSite},
+		{http.MethodPut, "/sites/{id}", "Update a site", a.Locations.UpdateSite},
+		{http.MethodDelete, "/sites/{id}", "Delete a site", a.Locations.DeleteSite},
+		{http.MethodGet, "/sites/{id}/buildings", "List the buildings of a site", a.Locations.ListBuildings},
+		{http.MethodPost, "/sites/{id}/buildings", "Create a building", a.Locations.CreateBuilding},
+		{http.MethodGet, "/sites/{id}/rooms", "List the rooms of a site", a.Locations.ListSiteRooms},
+		{http.MethodGet, "/buildings/{id}", "Get a building", a.Locations.GetBuilding},
+		{http.MethodPut, "/buildings/{id}", "Update a building", a.Locations.UpdateBuilding},
+		{http.MethodDelete, "/buildings/{id}", "Delete a building", a.Locations.DeleteBuilding},
+		{http.MethodGet, "/buildings/{id}/floors", "List the floors of a building", a.Locations.ListFloors},
+		{http.MethodPost, "/buildings/{id}/floors", "Create a floor", a.Locations.CreateFloor},
+		{http.MethodGet, "/buildings/{id}/rooms", "List the rooms of a building", a.Locations.ListBuildingRooms},
+		{http.MethodGet, "/floors/{id}", "Get a floor", a.Locations.GetFloor},
+		{http.MethodPut, "/floors/{id}", "Update a floor", a.Locations.UpdateFloor},
+		{http.MethodDelete, "/floors/{id}", "Delete a floor", a.Locations.DeleteFloor},
+		{http.MethodGet, "/floors/{id}/rooms", "List the rooms of a floor", a.Locations.ListFloorRooms},
+
+		// Search
+		{http.MethodGet, "/search", "Search users and rooms", a.Search.Search},
+	}
+}
+
+// Health handles GET /health
+func Health(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"status":"healthy"}`))
+}
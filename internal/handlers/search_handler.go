@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+
+	"cloudflaredb/internal/models"
+	"cloudflaredb/internal/repository"
+)
+
+// SearchHandler handles HTTP requests for full-text search
+type SearchHandler struct {
+	users *repository.UserRepository
+	rooms *repository.RoomRepository
+}
+
+// NewSearchHandler creates a new search handler
+func NewSearchHandler(users *repository.UserRepository, rooms *repository.RoomRepository) *SearchHandler {
+	return &SearchHandler{users: users, rooms: rooms}
+}
+
+// Search handles GET /search?q=&type=&limit=
+func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
+	q := newQueryParams(r.URL.Query())
+	text := q.String("q")
+	kind := q.String("type")
+	limit := q.PositiveInt("limit")
+
+	if text == "" {
+		q.errs = append(q.errs, FieldError{Field: "q", Message: "is required"})
+	}
+	if kind != "" && kind != "rooms" && kind != "users" {
+		q.errs = append(q.errs, FieldError{Field: "type", Message: "must be rooms or users"})
+	}
+	if errs := q.Errors(); len(errs) > 0 {
+		respondValidationError(w, r, errs)
+		return
+	}
+
+	if limit == 0 {
+		limit = DefaultPageSize
+	}
+	limit = min(limit, MaxPageSize)
+
+	result := models.SearchResponse{
+		Query: text,
+		Mode:  models.SearchModeFullText,
+		Rooms: []*models.RoomSearchHit{},
+		Users: []*models.UserSearchHit{},
+	}
+
+	if kind != "users" {
+		rooms, mode, err := h.rooms.Search(r.Context(), text, limit)
+		if err != nil {
+			respondInternalError(w, r, "search rooms", err)
+			return
+		}
+		if len(rooms) > 0 {
+			result.Rooms = rooms
+		}
+		if mode == models.SearchModeBasic {
+			result.Mode = mode
+		}
+	}
+
+	if kind != "rooms" {
+		users, mode, err := h.users.Search(r.Context(), text, limit)
+		if err != nil {
+			respondInternalError(w, r, "search users", err)
+			return
+		}
+		if len(users) > 0 {
+			result.Users = users
+		}
+		if mode == models.SearchModeBasic {
+			result.Mode = mode
+		}
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
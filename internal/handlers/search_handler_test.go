@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"cloudflaredb/internal/models"
+	"cloudflaredb/internal/repository"
+)
+
+func TestSearchHandler_Search(t *testing.T) {
+	db := setupTestDBForRooms(t)
+	defer db.Close()
+
+	userRepo := repository.NewUserRepository(db, repository.NewRoomRepository(db))
+	roomRepo := repository.NewRoomRepository(db)
+	handler := NewSearchHandler(userRepo, roomRepo)
+	router := NewRouter((&API{Search: handler}).Routes()...)
+
+	ctx := context.Background()
+	if _, err := roomRepo.Create(ctx, &models.CreateRoomRequest{Name: "Aquarium", Description: "Has a projector", Capacity: 6}); err != nil {
+		t.Fatalf("Failed to create test room: %v", err)
+	}
+	if _, err := userRepo.Create(ctx, &models.CreateUserRequest{Email: "ada@example.com", Name: "Ada Lovelace"}); err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		queryParams    string
+		expectedStatus int
+		expectedRooms  int
+		expectedUsers  int
+	}{
+		{name: "rooms and users", queryParams: "?q=a", expectedStatus: http.StatusOK, expectedRooms: 1, expectedUsers: 1},
+		{name: "rooms only", queryParams: "?q=projector&type=rooms", expectedStatus: http.StatusOK, expectedRooms: 1},
+		{name: "users only", queryParams: "?q=lovelace&type=users", expectedStatus: http.StatusOK, expectedUsers: 1},
+		{name: "missing query", queryParams: "", expectedStatus: http.StatusBadRequest},
+		{name: "unknown type", queryParams: "?q=a&type=groups", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/search"+tt.queryParams, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var resp models.SearchResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if len(resp.Rooms) != tt.expectedRooms || len(resp.Users) != tt.expectedUsers {
+				t.Errorf("Expected %d rooms and %d users, got %d and %d",
+					tt.expectedRooms, tt.expectedUsers, len(resp.Rooms), len(resp.Users))
+			}
+			// The test schema has no FTS5 index
+			if resp.Mode != models.SearchModeBasic {
+				t.Errorf("Expected mode %s, got %s", models.SearchModeBasic, resp.Mode)
+			}
+		})
+	}
+}
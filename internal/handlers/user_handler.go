@@ -2,10 +2,8 @@ package handlers
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
 	"net/http"
-	"strconv"
-	"strings"
 
 	"cloudflaredb/internal/models"
 	"cloudflaredb/internal/repository"
@@ -24,120 +22,159 @@ func NewUserHandler(repo *repository.UserRepository) *UserHandler {
 // CreateUser handles POST /users
 func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateUserRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
-		return
-	}
-
-	if req.Email == "" || req.Name == "" {
-		respondError(w, http.StatusBadRequest, "Email and name are required")
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
 	user, err := h.repo.Create(r.Context(), &req)
 	if err != nil {
-		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
-			respondError(w, http.StatusConflict, "User with this email already exists")
+		if errors.Is(err, repository.ErrConflict) {
+			respondError(w, r, http.StatusConflict, CodeConflict, "User with this email already exists")
 			return
 		}
-		respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create user: %v", err))
+		respondInternalError(w, r, "create user", err)
 		return
 	}
 
-	respondJSON(w, http.StatusCreated, user)
+	respondVersioned(w, r, http.StatusCreated, user.Version, user)
 }
 
 // GetUser handles GET /users/{id}
 func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
-	idStr := strings.TrimPrefix(r.URL.Path, "/users/")
-	id, err := strconv.ParseInt(idStr, 10, 64)
-	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid user ID")
+	id, ok := pathID(w, r, "id", "user")
+	if !ok {
 		return
 	}
 
 	user, err := h.repo.GetByID(r.Context(), id)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			respondError(w, http.StatusNotFound, "User not found")
+		if errors.Is(err, repository.ErrNotFound) {
+			respondError(w, r, http.StatusNotFound, CodeNotFound, "User not found")
 			return
 		}
-		respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get user: %v", err))
+		respondInternalError(w, r, "get user", err)
 		return
 	}
 
-	respondJSON(w, http.StatusOK, user)
+	respondVersioned(w, r, http.StatusOK, user.Version, user)
 }
 
 // ListUsers handles GET /users
 func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
-	limit := 10
-	offset := 0
+	q := newQueryParams(r.URL.Query())
+	page := parsePageParams(q)
+	filter := parseUserFilter(q)
+	opts := listOptions(q, page, repository.UserSortColumns)
+	if errs := q.Errors(); len(errs) > 0 {
+		respondValidationError(w, r, errs)
+		return
+	}
 
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
-		}
+	users, err := h.repo.List(r.Context(), filter, opts)
+	if err != nil {
+		respondInternalError(w, r, "list users", err)
+		return
 	}
 
-	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
-		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
-			offset = o
+	total := -1
+	if page.IncludeTotal {
+		if total, err = h.repo.Count(r.Context(), filter); err != nil {
+			respondInternalError(w, r, "count users", err)
+			return
 		}
 	}
 
-	users, err := h.repo.List(r.Context(), limit, offset)
+	respondPage(w, r, page, users, total, func(u *models.User) repository.Cursor {
+		return repository.Cursor{CreatedAt: u.CreatedAt, ID: u.ID}
+	})
+}
+
+// UpdateUser handles PUT /users/{id}, replacing every field of the user. An If-Match header makes
+// the update conditional on the user still having the given ETag.
+func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "user")
+	if !ok {
+		return
+	}
+	version, ok := ifMatchVersion(w, r, "user")
+	if !ok {
+		return
+	}
+
+	var req models.UpdateUserRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	user, err := h.repo.Update(r.Context(), id, version, &req)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list users: %v", err))
+		respondUserUpdateError(w, r, "update user", err)
 		return
 	}
 
-	respondJSON(w, http.StatusOK, users)
+	respondVersioned(w, r, http.StatusOK, user.Version, user)
 }
 
-// UpdateUser handles PUT /users/{id}
-func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
-	idStr := strings.TrimPrefix(r.URL.Path, "/users/")
-	id, err := strconv.ParseInt(idStr, 10, 64)
-	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid user ID")
+// PatchUser handles PATCH /users/{id} with a JSON merge patch, changing only the fields it contains
+func (h *UserHandler) PatchUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "user")
+	if !ok {
+		return
+	}
+	version, ok := ifMatchVersion(w, r, "user")
+	if !ok {
 		return
 	}
 
-	var req models.UpdateUserRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	var req models.PatchUserRequest
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
-	user, err := h.repo.Update(r.Context(), id, &req)
+	user, err := h.repo.Patch(r.Context(), id, version, &req)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			respondError(w, http.StatusNotFound, "User not found")
-			return
-		}
-		respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to update user: %v", err))
+		respondUserUpdateError(w, r, "patch user", err)
 		return
 	}
 
-	respondJSON(w, http.StatusOK, user)
+	respondVersioned(w, r, http.StatusOK, user.Version, user)
+}
+
+// respondUserUpdateError maps user update errors to problem responses
+func respondUserUpdateError(w http.ResponseWriter, r *http.Request, action string, err error) {
+	switch {
+	case errors.Is(err, repository.ErrVersionMismatch):
+		respondError(w, r, http.StatusPreconditionFailed, CodePreconditionFailed, "User was modified since the If-Match ETag")
+	case errors.Is(err, repository.ErrConflict):
+		respondError(w, r, http.StatusConflict, CodeConflict, "User with this email already exists")
+	case errors.Is(err, repository.ErrNotFound):
+		respondError(w, r, http.StatusNotFound, CodeNotFound, "User not found")
+	default:
+		respondInternalError(w, r, action, err)
+	}
 }
 
 // DeleteUser handles DELETE /users/{id}
 func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
-	idStr := strings.TrimPrefix(r.URL.Path, "/users/")
-	id, err := strconv.ParseInt(idStr, 10, 64)
-	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid user ID")
+	id, ok := pathID(w, r, "id", "user")
+	if !ok {
+		return
+	}
+	version, ok := ifMatchVersion(w, r, "user")
+	if !ok {
 		return
 	}
 
-	if err := h.repo.Delete(r.Context(), id); err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			respondError(w, http.StatusNotFound, "User not found")
+	if err := h.repo.Delete(r.Context(), id, version); err != nil {
+		if errors.Is(err, repository.ErrVersionMismatch) {
+			respondError(w, r, http.StatusPreconditionFailed, CodePreconditionFailed, "User was modified since the If-Match ETag")
 			return
 		}
-		respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete user: %v", err))
+		if errors.Is(err, repository.ErrNotFound) {
+			respondError(w, r, http.StatusNotFound, CodeNotFound, "User not found")
+			return
+		}
+		respondInternalError(w, r, "delete user", err)
 		return
 	}
 
@@ -150,8 +187,3 @@ func respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(data)
 }
-
-// respondError sends an error response
-func respondError(w http.ResponseWriter, status int, message string) {
-	respondJSON(w, status, map[string]string{"error": message})
-}
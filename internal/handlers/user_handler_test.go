@@ -5,8 +5,10 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"cloudflaredb/internal/models"
@@ -31,7 +33,8 @@ func setupTestDB(t *testing.T) *sql.DB {
 		email TEXT NOT NULL UNIQUE,
 		name TEXT NOT NULL,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		version INTEGER NOT NULL DEFAULT 1
 	);
 	CREATE INDEX idx_users_email ON users(email);
 	`
@@ -47,8 +50,9 @@ func TestUserHandler_CreateUser(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
 
-	repo := repository.NewUserRepository(db)
+	repo := repository.NewUserRepository(db, repository.NewRoomRepository(db))
 	handler := NewUserHandler(repo)
+	router := NewRouter((&API{Users: handler}).Routes()...)
 
 	tests := []struct {
 		name           string
@@ -106,7 +110,7 @@ func TestUserHandler_CreateUser(t *testing.T) {
 			req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewReader(body))
 			w := httptest.NewRecorder()
 
-			handler.CreateUser(w, req)
+			router.ServeHTTP(w, req)
 
 			if w.Code != tt.expectedStatus {
 				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
@@ -123,8 +127,9 @@ func TestUserHandler_GetUser(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
 
-	repo := repository.NewUserRepository(db)
+	repo := repository.NewUserRepository(db, repository.NewRoomRepository(db))
 	handler := NewUserHandler(repo)
+	router := NewRouter((&API{Users: handler}).Routes()...)
 
 	// Create a test user
 	ctx := context.Background()
@@ -163,7 +168,7 @@ func TestUserHandler_GetUser(t *testing.T) {
 			req := httptest.NewRequest(http.MethodGet, "/users/"+tt.userID, nil)
 			w := httptest.NewRecorder()
 
-			handler.GetUser(w, req)
+			router.ServeHTTP(w, req)
 
 			if w.Code != tt.expectedStatus {
 				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
@@ -186,8 +191,9 @@ func TestUserHandler_ListUsers(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
 
-	repo := repository.NewUserRepository(db)
+	repo := repository.NewUserRepository(db, repository.NewRoomRepository(db))
 	handler := NewUserHandler(repo)
+	router := NewRouter((&API{Users: handler}).Routes()...)
 
 	// Create test users
 	ctx := context.Background()
@@ -232,7 +238,7 @@ func TestUserHandler_ListUsers(t *testing.T) {
 			req := httptest.NewRequest(http.MethodGet, "/users"+tt.queryParams, nil)
 			w := httptest.NewRecorder()
 
-			handler.ListUsers(w, req)
+			router.ServeHTTP(w, req)
 
 			if w.Code != tt.expectedStatus {
 				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
@@ -251,12 +257,168 @@ func TestUserHandler_ListUsers(t *testing.T) {
 	}
 }
 
+func TestUserHandler_ListUsersFilters(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := repository.NewUserRepository(db, repository.NewRoomRepository(db))
+	handler := NewUserHandler(repo)
+	router := NewRouter((&API{Users: handler}).Routes()...)
+
+	ctx := context.Background()
+	for _, u := range []models.CreateUserRequest{
+		{Email: "ada@example.com", Name: "Ada Lovelace"},
+		{Email: "alan@example.com", Name: "Alan Turing"},
+		{Email: "grace@example.com", Name: "Grace Hopper"},
+	} {
+		if _, err := repo.Create(ctx, &u); err != nil {
+			t.Fatalf("Failed to create test user: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name           string
+		queryParams    string
+		expectedStatus int
+		expectedNames  []string
+	}{
+		{
+			name:           "name contains sorted by name",
+			queryParams:    "?name_contains=a&sort=name",
+			expectedStatus: http.StatusOK,
+			expectedNames:  []string{"Ada Lovelace", "Alan Turing", "Grace Hopper"},
+		},
+		{
+			name:           "exact email",
+			queryParams:    "?email=alan@example.com",
+			expectedStatus: http.StatusOK,
+			expectedNames:  []string{"Alan Turing"},
+		},
+		{
+			name:           "descending sort",
+			queryParams:    "?sort=-email",
+			expectedStatus: http.StatusOK,
+			expectedNames:  []string{"Grace Hopper", "Alan Turing", "Ada Lovelace"},
+		},
+		{
+			name:           "unknown sort column",
+			queryParams:    "?sort=password",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid date",
+			queryParams:    "?created_after=yesterday",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "sort with cursor",
+			queryParams:    "?after=&sort=name",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/users"+tt.queryParams, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var users []*models.User
+			if err := json.Unmarshal(w.Body.Bytes(), &users); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			var names []string
+			for _, u := range users {
+				names = append(names, u.Name)
+			}
+			if fmt.Sprint(names) != fmt.Sprint(tt.expectedNames) {
+				t.Errorf("Expected %v, got %v", tt.expectedNames, names)
+			}
+		})
+	}
+}
+
+func TestUserHandler_ListUsersWithTotal(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := repository.NewUserRepository(db, repository.NewRoomRepository(db))
+	handler := NewUserHandler(repo)
+	router := NewRouter((&API{Users: handler}).Routes()...)
+
+	ctx := context.Background()
+	for i := 1; i <= 5; i++ {
+		if _, err := repo.Create(ctx, &models.CreateUserRequest{Email: fmt.Sprintf("user%d@example.com", i), Name: "User"}); err != nil {
+			t.Fatalf("Failed to create test user: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/users?include_total=true&limit=2&offset=2&sort=email", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var page struct {
+		Items  []*models.User `json:"items"`
+		Total  int            `json:"total"`
+		Limit  int            `json:"limit"`
+		Offset int            `json:"offset"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(page.Items) != 2 || page.Total != 5 || page.Limit != 2 || page.Offset != 2 {
+		t.Errorf("Unexpected page: %d items, total %d, limit %d, offset %d", len(page.Items), page.Total, page.Limit, page.Offset)
+	}
+
+	link := w.Header().Get("Link")
+	for _, want := range []string{
+		`</users?include_total=true&limit=2&offset=0&sort=email>; rel="first"`,
+		`</users?include_total=true&limit=2&offset=0&sort=email>; rel="prev"`,
+		`</users?include_total=true&limit=2&offset=4&sort=email>; rel="next"`,
+		`</users?include_total=true&limit=2&offset=4&sort=email>; rel="last"`,
+	} {
+		if !strings.Contains(link, want) {
+			t.Errorf("Expected Link header to contain %s, got %s", want, link)
+		}
+	}
+
+	// The last page has no next link
+	req = httptest.NewRequest(http.MethodGet, "/users?include_total=true&limit=2&offset=4", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if link := w.Header().Get("Link"); strings.Contains(link, `rel="next"`) {
+		t.Errorf("Expected no next link on the last page, got %s", link)
+	}
+
+	// Invalid include_total values are rejected
+	req = httptest.NewRequest(http.MethodGet, "/users?include_total=maybe", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
 func TestUserHandler_UpdateUser(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
 
-	repo := repository.NewUserRepository(db)
+	repo := repository.NewUserRepository(db, repository.NewRoomRepository(db))
 	handler := NewUserHandler(repo)
+	router := NewRouter((&API{Users: handler}).Routes()...)
 
 	// Create a test user
 	ctx := context.Background()
@@ -278,7 +440,8 @@ func TestUserHandler_UpdateUser(t *testing.T) {
 			name:   "successful update",
 			userID: "1",
 			requestBody: models.UpdateUserRequest{
-				Name: "Updated Name",
+				Email: "updated@example.com",
+				Name:  "Updated Name",
 			},
 			expectedStatus: http.StatusOK,
 		},
@@ -286,7 +449,8 @@ func TestUserHandler_UpdateUser(t *testing.T) {
 			name:   "non-existent user",
 			userID: "9999",
 			requestBody: models.UpdateUserRequest{
-				Name: "Should Fail",
+				Email: "missing@example.com",
+				Name:  "Should Fail",
 			},
 			expectedStatus: http.StatusNotFound,
 		},
@@ -315,7 +479,7 @@ func TestUserHandler_UpdateUser(t *testing.T) {
 			req := httptest.NewRequest(http.MethodPut, "/users/"+tt.userID, bytes.NewReader(body))
 			w := httptest.NewRecorder()
 
-			handler.UpdateUser(w, req)
+			router.ServeHTTP(w, req)
 
 			if w.Code != tt.expectedStatus {
 				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
@@ -333,12 +497,53 @@ func TestUserHandler_UpdateUser(t *testing.T) {
 	}
 }
 
-func TestUserHandler_DeleteUser(t *testing.T) {
+func TestUserHandler_PatchUser(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
 
-	repo := repository.NewUserRepository(db)
+	repo := repository.NewUserRepository(db, repository.NewRoomRepository(db))
+	handler := NewUserHandler(repo)
+	router := NewRouter((&API{Users: handler}).Routes()...)
+
+	user, err := repo.Create(context.Background(), &models.CreateUserRequest{Email: "jane@example.com", Name: "Jane"})
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/users/%d", user.ID), strings.NewReader(`{"name": "Jane Doe"}`))
+	req.Header.Set("Content-Type", mergePatchContentType)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var patched models.User
+	if err := json.NewDecoder(w.Body).Decode(&patched); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if patched.Name != "Jane Doe" || patched.Email != "jane@example.com" {
+		t.Errorf("Expected only the name to change, got %s <%s>", patched.Name, patched.Email)
+	}
+
+	req = httptest.NewRequest(http.MethodPatch, "/users/9999", strings.NewReader(`{"name": "Nobody"}`))
+	w = httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestUserHandler_DeleteUser(t *testing.T) {
+	db := setupTestDBForRooms(t)
+	defer db.Close()
+
+	repo := repository.NewUserRepository(db, repository.NewRoomRepository(db))
 	handler := NewUserHandler(repo)
+	router := NewRouter((&API{Users: handler}).Routes()...)
 
 	// Create a test user
 	ctx := context.Background()
@@ -377,7 +582,7 @@ func TestUserHandler_DeleteUser(t *testing.T) {
 			req := httptest.NewRequest(http.MethodDelete, "/users/"+tt.userID, nil)
 			w := httptest.NewRecorder()
 
-			handler.DeleteUser(w, req)
+			router.ServeHTTP(w, req)
 
 			if w.Code != tt.expectedStatus {
 				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
@@ -391,3 +596,228 @@ func TestUserHandler_DeleteUser(t *testing.T) {
 		t.Error("Expected user to be deleted")
 	}
 }
+
+func TestUserHandler_UpdateUserDuplicateEmail(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := repository.NewUserRepository(db, repository.NewRoomRepository(db))
+	handler := NewUserHandler(repo)
+	router := NewRouter((&API{Users: handler}).Routes()...)
+
+	ctx := context.Background()
+	repo.Create(ctx, &models.CreateUserRequest{Email: "taken@example.com", Name: "First"})
+	second, _ := repo.Create(ctx, &models.CreateUserRequest{Email: "second@example.com", Name: "Second"})
+
+	body, _ := json.Marshal(models.UpdateUserRequest{Email: "taken@example.com", Name: "Second"})
+	req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/users/%d", second.ID), bytes.NewReader(body))
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+}
+
+func TestUserHandler_ConditionalRequests(t *testing.T) {
+	db := setupTestDBForRooms(t)
+	defer db.Close()
+
+	repo := repository.NewUserRepository(db, repository.NewRoomRepository(db))
+	handler := NewUserHandler(repo)
+	router := NewRouter((&API{Users: handler}).Routes()...)
+
+	user, err := repo.Create(context.Background(), &models.CreateUserRequest{Email: "jane@example.com", Name: "Jane"})
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+	path := fmt.Sprintf("/users/%d", user.ID)
+
+	send := func(method, body string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		for name, value := range header {
+			req.Header.Set(name, value)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send(http.MethodGet, "", nil)
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag != `"1"` {
+		t.Fatalf("Expected status %d with ETag \"1\", got %d with %q", http.StatusOK, w.Code, etag)
+	}
+
+	if w := send(http.MethodGet, "", map[string]string{"If-None-Match": etag}); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("Expected status %d without a body, got %d: %s", http.StatusNotModified, w.Code, w.Body.String())
+	}
+
+	w = send(http.MethodPut, `{"email": "jane@example.com", "name": "Jane Doe"}`, map[string]string{"If-Match": etag})
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"2"` {
+		t.Fatalf("Expected status %d with ETag \"2\", got %d with %q: %s", http.StatusOK, w.Code, w.Header().Get("ETag"), w.Body.String())
+	}
+
+	// The first ETag is now stale
+	if w := send(http.MethodGet, "", map[string]string{"If-None-Match": etag}); w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		body    string
+		ifMatch string
+	}{
+		{name: "stale PUT", method: http.MethodPut, body: `{"email": "jane@example.com", "name": "Stale"}`, ifMatch: etag},
+		{name: "stale PATCH", method: http.MethodPatch, body: `{"name": "Stale"}`, ifMatch: etag},
+		{name: "stale DELETE", method: http.MethodDelete, ifMatch: etag},
+		{name: "weak ETag", method: http.MethodDelete, ifMatch: `W/"2"`},
+		{name: "malformed ETag", method: http.MethodPatch, body: `{"name": "Stale"}`, ifMatch: "2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := send(tt.method, tt.body, map[string]string{"If-Match": tt.ifMatch})
+			if w.Code != http.StatusPreconditionFailed {
+				t.Fatalf("Expected status %d, got %d: %s", http.StatusPreconditionFailed, w.Code, w.Body.String())
+			}
+			if p := decodeProblem(t, w); p.Code != CodePreconditionFailed {
+				t.Errorf("Expected code %s, got %s", CodePreconditionFailed, p.Code)
+			}
+		})
+	}
+
+	if w := send(http.MethodPatch, `{"name": "Jane"}`, map[string]string{"If-Match": "*"}); w.Code != http.StatusOK || w.Header().Get("ETag") != `"3"` {
+		t.Errorf("Expected If-Match * to match any version, got %d with %q", w.Code, w.Header().Get("ETag"))
+	}
+	if w := send(http.MethodDelete, "", map[string]string{"If-Match": `"3"`}); w.Code != http.StatusNoContent {
+		t.Errorf("Expected status %d, got %d: %s", http.StatusNoContent, w.Code, w.Body.String())
+	}
+}
+
+func TestUserHandler_RequestValidation(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := repository.NewUserRepository(db, repository.NewRoomRepository(db))
+	handler := NewUserHandler(repo)
+	router := NewRouter((&API{Users: handler}).Routes()...)
+
+	user, _ := repo.Create(context.Background(), &models.CreateUserRequest{Email: "jane@example.com", Name: "Jane"})
+	userPath := fmt.Sprintf("/users/%d", user.ID)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantCode   string
+		wantErrors []FieldError
+	}{
+		{
+			name:       "every failing field",
+			method:     http.MethodPost,
+			path:       "/users",
+			body:       `{"email": "not-an-email", "name": "` + strings.Repeat("a", 10000) + `"}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeValidationFailed,
+			wantErrors: []FieldError{
+				{Field: "email", Message: "must be a valid email address"},
+				{Field: "name", Message: "must be at most 100 characters"},
+			},
+		},
+		{
+			name:       "unknown field",
+			method:     http.MethodPost,
+			path:       "/users",
+			body:       `{"email": "john@example.com", "name": "John", "nickname": "Johnny"}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeValidationFailed,
+			wantErrors: []FieldError{{Field: "nickname", Message: "is not a known field"}},
+		},
+		{
+			name:       "wrong type",
+			method:     http.MethodPost,
+			path:       "/users",
+			body:       `{"email": "john@example.com", "name": 42}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeValidationFailed,
+			wantErrors: []FieldError{{Field: "name", Message: "must be a string"}},
+		},
+		{
+			name:       "replacement without every field",
+			method:     http.MethodPut,
+			path:       userPath,
+			body:       `{"email": "jane@"}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeValidationFailed,
+			wantErrors: []FieldError{
+				{Field: "email", Message: "must be a valid email address"},
+				{Field: "name", Message: "is required"},
+			},
+		},
+		{
+			name:       "malformed email in patch",
+			method:     http.MethodPatch,
+			path:       userPath,
+			body:       `{"email": "jane@"}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeValidationFailed,
+			wantErrors: []FieldError{{Field: "email", Message: "must be a valid email address"}},
+		},
+		{
+			name:       "null in patch",
+			method:     http.MethodPatch,
+			path:       userPath,
+			body:       `{"name": null}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeValidationFailed,
+			wantErrors: []FieldError{{Field: "name", Message: "must not be null"}},
+		},
+		{
+			name:       "wrong type in patch",
+			method:     http.MethodPatch,
+			path:       userPath,
+			body:       `{"name": ["Jane"]}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeValidationFailed,
+			wantErrors: []FieldError{{Field: "name", Message: "must be a string"}},
+		},
+		{
+			name:       "oversized body",
+			method:     http.MethodPut,
+			path:       userPath,
+			body:       `{"name": "` + strings.Repeat("a", maxBodyBytes) + `"}`,
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantCode:   CodeBodyTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			p := decodeProblem(t, w)
+			if p.Code != tt.wantCode {
+				t.Errorf("Expected code %s, got %s", tt.wantCode, p.Code)
+			}
+			if fmt.Sprint(p.Errors) != fmt.Sprint(tt.wantErrors) {
+				t.Errorf("Expected errors %v, got %v", tt.wantErrors, p.Errors)
+			}
+		})
+	}
+
+	// Rejected updates leave the user unchanged
+	if got, _ := repo.GetByID(context.Background(), user.ID); got.Email != "jane@example.com" || got.Name != "Jane" {
+		t.Errorf("Expected the user to be unchanged, got %+v", got)
+	}
+}
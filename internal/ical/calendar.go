@@ -0,0 +1,205 @@
+// Package ical renders RFC 5545 iCalendar feeds.
+package ical
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ProdID identifies the application that produced a calendar
+const ProdID = "-//cloudflaredb//Rooms API//EN"
+
+// ContentType is the media type of an iCalendar feed
+const ContentType = "text/calendar; charset=utf-8"
+
+const (
+	utcLayout   = "20060102T150405Z"
+	localLayout = "20060102T150405"
+
+	// maxLineOctets is the longest content line RFC 5545 allows before folding, excluding the CRLF
+	maxLineOctets = 75
+)
+
+// Calendar is a VCALENDAR with its events
+type Calendar struct {
+	// Name is shown by calendar clients as the feed title (X-WR-CALNAME)
+	Name string
+	// Location is the time zone event times are written in; nil or UTC writes UTC times
+	Location *time.Location
+	Events   []Event
+}
+
+// Event is a VEVENT
+type Event struct {
+	// UID must stay the same across renderings so clients update events instead of duplicating them
+	UID string
+	// Stamp is the DTSTAMP; it should only change when the event does, so feeds render identically
+	Stamp time.Time
+	Start time.Time
+	// End is optional; without it the event is an instant
+	End         time.Time
+	Summary     string
+	Description string
+	// Transparent marks events that do not block time in free/busy lookups
+	Transparent bool
+}
+
+// Encode renders the calendar. Rendering is deterministic, so equal calendars encode to equal bytes.
+func (c *Calendar) Encode() []byte {
+	w := &writer{}
+	w.line("BEGIN", "VCALENDAR")
+	w.line("VERSION", "2.0")
+	w.line("PRODID", ProdID)
+	w.line("CALSCALE", "GREGORIAN")
+	w.line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		w.line("X-WR-CALNAME", escapeText(c.Name))
+	}
+
+	loc := c.Location
+	if loc == time.UTC {
+		loc = nil
+	}
+	if loc != nil {
+		w.line("X-WR-TIMEZONE", loc.String())
+		if first, last, ok := c.span(); ok {
+			writeTimezone(w, loc, first, last)
+		}
+	}
+
+	for _, e := range c.Events {
+		w.line("BEGIN", "VEVENT")
+		w.line("UID", e.UID)
+		w.line("DTSTAMP", e.Stamp.UTC().Format(utcLayout))
+		w.dateTime("DTSTART", e.Start, loc)
+		if !e.End.IsZero() {
+			w.dateTime("DTEND", e.End, loc)
+		}
+		w.line("SUMMARY", escapeText(e.Summary))
+		if e.Description != "" {
+			w.line("DESCRIPTION", escapeText(e.Description))
+		}
+		if e.Transparent {
+			w.line("TRANSP", "TRANSPARENT")
+		}
+		w.line("END", "VEVENT")
+	}
+
+	w.line("END", "VCALENDAR")
+	return w.buf.Bytes()
+}
+
+// span returns the earliest and latest event times
+func (c *Calendar) span() (first, last time.Time, ok bool) {
+	for _, e := range c.Events {
+		for _, t := range []time.Time{e.Start, e.End} {
+			if t.IsZero() {
+				continue
+			}
+			if !ok || t.Before(first) {
+				first = t
+			}
+			if !ok || t.After(last) {
+				last = t
+			}
+			ok = true
+		}
+	}
+	return first, last, ok
+}
+
+// writeTimezone writes a VTIMEZONE with one observance per offset period between first and last,
+// so clients without the IANA database still resolve every event time
+func writeTimezone(w *writer, loc *time.Location, first, last time.Time) {
+	w.line("BEGIN", "VTIMEZONE")
+	w.line("TZID", loc.String())
+
+	t := first.In(loc)
+	for {
+		start, end := t.ZoneBounds()
+		name, offset := t.Zone()
+
+		from := offset
+		onset := "19700101T000000"
+		if !start.IsZero() {
+			_, from = start.Add(-time.Second).Zone()
+			onset = start.In(time.FixedZone("", from)).Format(localLayout)
+		}
+
+		kind := "STANDARD"
+		if t.IsDST() {
+			kind = "DAYLIGHT"
+		}
+		w.line("BEGIN", kind)
+		w.line("DTSTART", onset)
+		w.line("TZOFFSETFROM", formatOffset(from))
+		w.line("TZOFFSETTO", formatOffset(offset))
+		w.line("TZNAME", escapeText(name))
+		w.line("END", kind)
+
+		if end.IsZero() || end.After(last) {
+			break
+		}
+		t = end.In(loc)
+	}
+
+	w.line("END", "VTIMEZONE")
+}
+
+// formatOffset formats a UTC offset in seconds as ±hhmm, or ±hhmmss when it has seconds
+func formatOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign = "-"
+		seconds = -seconds
+	}
+	s := fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds%3600/60)
+	if seconds%60 != 0 {
+		s += fmt.Sprintf("%02d", seconds%60)
+	}
+	return s
+}
+
+// escapeText escapes a TEXT value
+func escapeText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`).Replace(s)
+}
+
+// writer accumulates content lines
+type writer struct {
+	buf bytes.Buffer
+}
+
+// dateTime writes a DATE-TIME property in UTC, or as local time with a TZID parameter when loc is set
+func (w *writer) dateTime(name string, t time.Time, loc *time.Location) {
+	if loc == nil {
+		w.line(name, t.UTC().Format(utcLayout))
+		return
+	}
+	w.line(name+";TZID="+loc.String(), t.In(loc).Format(localLayout))
+}
+
+// line writes "name:value" folded to 75 octets per line, never splitting a UTF-8 sequence
+func (w *writer) line(name, value string) {
+	s := name + ":" + value
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(s[cut]) {
+			cut--
+		}
+		w.buf.WriteString(s[:cut])
+		w.buf.WriteString("\r\n ")
+		s = s[cut:]
+		// Continuation lines start with a space, which counts towards their length
+		limit = maxLineOctets - 1
+	}
+	w.buf.WriteString(s)
+	w.buf.WriteString("\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
@@ -0,0 +1,114 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCalendar_EncodeUTC(t *testing.T) {
+	at := time.Date(2026, 1, 5, 9, 30, 0, 0, time.FixedZone("CET", 3600))
+	cal := &Calendar{
+		Name: "Room A, 2nd floor",
+		Events: []Event{{
+			UID:         "user-room-1@example.com",
+			Stamp:       at,
+			Start:       at,
+			Summary:     "Ada joined Room A; welcome",
+			Description: "line one\nline two",
+			Transparent: true,
+		}},
+	}
+
+	got := string(cal.Encode())
+	want := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:" + ProdID,
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		`X-WR-CALNAME:Room A\, 2nd floor`,
+		"BEGIN:VEVENT",
+		"UID:user-room-1@example.com",
+		"DTSTAMP:20260105T083000Z",
+		"DTSTART:20260105T083000Z",
+		`SUMMARY:Ada joined Room A\; welcome`,
+		`DESCRIPTION:line one\nline two`,
+		"TRANSP:TRANSPARENT",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+
+	if got != want {
+		t.Errorf("Encode() mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+	if !bytes.Equal(cal.Encode(), cal.Encode()) {
+		t.Errorf("Encode() is not deterministic")
+	}
+}
+
+func TestCalendar_EncodeTimeZone(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	winter := time.Date(2026, 3, 2, 9, 0, 0, 0, loc)
+	summer := time.Date(2026, 4, 6, 9, 0, 0, 0, loc)
+	cal := &Calendar{
+		Location: loc,
+		Events: []Event{
+			{UID: "a", Stamp: winter, Start: winter, Summary: "Winter"},
+			{UID: "b", Stamp: summer, Start: summer, End: summer.Add(time.Hour), Summary: "Summer"},
+		},
+	}
+
+	got := string(cal.Encode())
+	for _, want := range []string{
+		"X-WR-TIMEZONE:Europe/Berlin\r\n",
+		"BEGIN:VTIMEZONE\r\nTZID:Europe/Berlin\r\n",
+		// The winter period began when summer time ended in 2025
+		"BEGIN:STANDARD\r\nDTSTART:20251026T030000\r\nTZOFFSETFROM:+0200\r\nTZOFFSETTO:+0100\r\nTZNAME:CET\r\nEND:STANDARD\r\n",
+		"BEGIN:DAYLIGHT\r\nDTSTART:20260329T020000\r\nTZOFFSETFROM:+0100\r\nTZOFFSETTO:+0200\r\nTZNAME:CEST\r\nEND:DAYLIGHT\r\n",
+		"DTSTART;TZID=Europe/Berlin:20260302T090000\r\n",
+		"DTSTART;TZID=Europe/Berlin:20260406T090000\r\nDTEND;TZID=Europe/Berlin:20260406T100000\r\n",
+		"DTSTAMP:20260406T070000Z\r\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, got)
+		}
+	}
+	if strings.Count(got, "BEGIN:STANDARD") != 1 || strings.Count(got, "BEGIN:DAYLIGHT") != 1 {
+		t.Errorf("Expected exactly the two periods spanned by the events, got:\n%s", got)
+	}
+}
+
+func TestWriter_FoldsLongLines(t *testing.T) {
+	w := &writer{}
+	w.line("SUMMARY", strings.Repeat("ü", 60))
+
+	lines := strings.Split(strings.TrimSuffix(w.buf.String(), "\r\n"), "\r\n")
+	if len(lines) < 2 {
+		t.Fatalf("Expected the line to be folded, got %q", w.buf.String())
+	}
+
+	var unfolded strings.Builder
+	for i, line := range lines {
+		if len(line) > maxLineOctets {
+			t.Errorf("Line %d has %d octets", i, len(line))
+		}
+		if i > 0 {
+			if !strings.HasPrefix(line, " ") {
+				t.Errorf("Continuation line %d does not start with a space: %q", i, line)
+			}
+			line = line[1:]
+		}
+		unfolded.WriteString(line)
+	}
+
+	if want := "SUMMARY:" + strings.Repeat("ü", 60); unfolded.String() != want {
+		t.Errorf("Unfolded line = %q, want %q", unfolded.String(), want)
+	}
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"cloudflaredb/internal/validation"
+)
+
+// MaxBookingDuration is the longest time range a booking or an occurrence of a recurring booking may
+// cover. It bounds the occurrences expanded to check a booking against the room's capacity.
+const MaxBookingDuration = 30 * 24 * time.Hour
+
+// Booking represents a reservation of a room by a user for a time range
+type Booking struct {
+	ID       int64     `json:"id"`
+	RoomID   int64     `json:"room_id"`
+	UserID   int64     `json:"user_id"`
+	Title    string    `json:"title"`
+	StartsAt time.Time `json:"starts_at"`
+	// EndsAt is exclusive: a booking ending at 10:00 does not overlap one starting at 10:00
+	EndsAt    time.Time `json:"ends_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateBookingRequest represents the payload for booking a room
+type CreateBookingRequest struct {
+	UserID   int64     `json:"user_id" validate:"required"`
+	Title    string    `json:"title" validate:"max=200"`
+	StartsAt time.Time `json:"starts_at" validate:"required"`
+	EndsAt   time.Time `json:"ends_at" validate:"required"`
+}
+
+// Validate checks that the booking ends after it starts, within MaxBookingDuration
+func (r CreateBookingRequest) Validate() []validation.FieldError {
+	return validateTimeRange(r.StartsAt, r.EndsAt)
+}
+
+// validateTimeRange checks that ends_at is after starts_at and at most MaxBookingDuration later;
+// missing times are left to the required rule
+func validateTimeRange(startsAt, endsAt time.Time) []validation.FieldError {
+	switch {
+	case startsAt.IsZero() || endsAt.IsZero():
+		return nil
+	case !endsAt.After(startsAt):
+		return []validation.FieldError{{Field: "ends_at", Message: "must be after starts_at"}}
+	case endsAt.Sub(startsAt) > MaxBookingDuration:
+		return []validation.FieldError{{Field: "ends_at", Message: "must be at most 30 days after starts_at"}}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+)
+
+// Group is a set of users, such as a team, that can be assigned to rooms as a unit
+type Group struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// GroupMember is the membership of a user in a group
+type GroupMember struct {
+	GroupID   int64     `json:"group_id"`
+	UserID    int64     `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RoomGroup is the assignment of a group to a room; every member of the group reaches the room
+type RoomGroup struct {
+	RoomID    int64     `json:"room_id"`
+	GroupID   int64     `json:"group_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateGroupRequest represents the payload for creating a group
+type CreateGroupRequest struct {
+	Name        string `json:"name" validate:"required,max=100"`
+	Description string `json:"description" validate:"max=1000"`
+}
+
+// UpdateGroupRequest represents the payload for updating a group; a nil Description keeps the
+// current one and an empty one clears it
+type UpdateGroupRequest struct {
+	Name        string  `json:"name,omitempty" validate:"max=100"`
+	Description *string `json:"description,omitempty" validate:"omitempty,max=1000"`
+}
+
+// AddGroupMemberRequest represents the payload for adding a user to a group
+type AddGroupMemberRequest struct {
+	UserID int64 `json:"user_id" validate:"required"`
+}
+
+// AssignGroupToRoomRequest represents the payload for assigning a group to a room
+type AssignGroupToRoomRequest struct {
+	GroupID int64 `json:"group_id" validate:"required"`
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"time"
+
+	"cloudflaredb/internal/validation"
+)
+
+// Invitation statuses
+const (
+	InvitationStatusPending  = "pending"
+	InvitationStatusAccepted = "accepted"
+	InvitationStatusDeclined = "declined"
+	// InvitationStatusExpired is reported for pending invitations whose expires_at has passed
+	InvitationStatusExpired = "expired"
+)
+
+// Invitation is an owner's invitation for a user, addressed by user ID or email, to join a room
+type Invitation struct {
+	ID        int64  `json:"id"`
+	RoomID    int64  `json:"room_id"`
+	InvitedBy int64  `json:"invited_by"`
+	UserID    *int64 `json:"user_id,omitempty"`
+	Email     string `json:"email,omitempty"`
+	Role      string `json:"role"`
+	Status    string `json:"status"`
+	// Token is only returned when the invitation is created; the database stores its hash
+	Token       string     `json:"token,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	RespondedAt *time.Time `json:"responded_at,omitempty"`
+}
+
+// CreateInvitationRequest represents the payload for inviting a user to a room.
+// Exactly one of UserID and Email must be set.
+type CreateInvitationRequest struct {
+	InvitedBy int64  `json:"invited_by" validate:"required"`
+	UserID    int64  `json:"user_id,omitempty" validate:"min=0"`
+	Email     string `json:"email,omitempty" validate:"omitempty,email,max=254"`
+	// Role defaults to member
+	Role string `json:"role,omitempty" validate:"omitempty,oneof=owner moderator member"`
+	// ExpiresAt is optional; without it the invitation does not expire
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// Validate checks that exactly one of user_id and email is set and that expires_at is in the future
+func (r CreateInvitationRequest) Validate() []validation.FieldError {
+	var errs []validation.FieldError
+	switch {
+	case r.UserID == 0 && r.Email == "":
+		errs = append(errs, validation.FieldError{Field: "user_id", Message: "either user_id or email is required"})
+	case r.UserID != 0 && r.Email != "":
+		errs = append(errs, validation.FieldError{Field: "email", Message: "must not be set together with user_id"})
+	}
+	if r.ExpiresAt != nil && !r.ExpiresAt.After(time.Now()) {
+		errs = append(errs, validation.FieldError{Field: "expires_at", Message: "must be in the future"})
+	}
+	return errs
+}
+
+// RespondInvitationRequest represents the payload for accepting or declining an invitation by its ID
+type RespondInvitationRequest struct {
+	UserID int64 `json:"user_id" validate:"required"`
+}
+
+// AcceptInvitationByTokenRequest represents the payload for accepting an invitation by its token
+type AcceptInvitationByTokenRequest struct {
+	UserID int64  `json:"user_id" validate:"required"`
+	Token  string `json:"token" validate:"required"`
+}
+
+// Join request statuses
+const (
+	JoinRequestStatusPending  = "pending"
+	JoinRequestStatusApproved = "approved"
+	JoinRequestStatusRejected = "rejected"
+)
+
+// JoinRequest is a user's request to join a room, reviewed by one of its owners or moderators
+type JoinRequest struct {
+	ID         int64      `json:"id"`
+	RoomID     int64      `json:"room_id"`
+	UserID     int64      `json:"user_id"`
+	Message    string     `json:"message,omitempty"`
+	Status     string     `json:"status"`
+	ReviewedBy *int64     `json:"reviewed_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+}
+
+// CreateJoinRequestRequest represents the payload for requesting to join a room
+type CreateJoinRequestRequest struct {
+	UserID  int64  `json:"user_id" validate:"required"`
+	Message string `json:"message,omitempty" validate:"max=500"`
+}
+
+// ReviewJoinRequestRequest represents the payload for approving or rejecting a join request
+type ReviewJoinRequestRequest struct {
+	ReviewedBy int64 `json:"reviewed_by" validate:"required"`
+}
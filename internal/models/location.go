@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+)
+
+// Site is an office location; it contains buildings
+type Site struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Address   string    `json:"address"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Building belongs to a site and contains floors
+type Building struct {
+	ID        int64     `json:"id"`
+	SiteID    int64     `json:"site_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Floor belongs to a building and contains rooms
+type Floor struct {
+	ID         int64  `json:"id"`
+	BuildingID int64  `json:"building_id"`
+	Name       string `json:"name"`
+	// Level orders the floors of a building; 0 is the ground floor and basements are negative
+	Level     int       `json:"level"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CreateSiteRequest represents the payload for creating a site
+type CreateSiteRequest struct {
+	Name    string `json:"name" validate:"required,max=100"`
+	Address string `json:"address" validate:"max=500"`
+}
+
+// UpdateSiteRequest represents the payload for updating a site
+type UpdateSiteRequest struct {
+	Name    string `json:"name,omitempty" validate:"max=100"`
+	Address string `json:"address,omitempty" validate:"max=500"`
+}
+
+// CreateBuildingRequest represents the payload for creating a building in a site
+type CreateBuildingRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+// UpdateBuildingRequest represents the payload for updating a building
+type UpdateBuildingRequest struct {
+	Name string `json:"name,omitempty" validate:"max=100"`
+}
+
+// CreateFloorRequest represents the payload for creating a floor in a building
+type CreateFloorRequest struct {
+	Name  string `json:"name" validate:"required,max=100"`
+	Level int    `json:"level"`
+}
+
+// UpdateFloorRequest represents the payload for updating a floor; a nil Level keeps the current one
+type UpdateFloorRequest struct {
+	Name  string `json:"name,omitempty" validate:"max=100"`
+	Level *int   `json:"level,omitempty"`
+}
@@ -0,0 +1,18 @@
+package models
+
+// CursorPage is the response envelope for cursor-paginated lists
+type CursorPage struct {
+	Items      interface{} `json:"items"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	HasMore    bool        `json:"has_more"`
+	// Total is set when the client asked for include_total
+	Total *int `json:"total,omitempty"`
+}
+
+// OffsetPage is the response envelope for offset-paginated lists requested with include_total
+type OffsetPage struct {
+	Items  interface{} `json:"items"`
+	Total  int         `json:"total"`
+	Limit  int         `json:"limit"`
+	Offset int         `json:"offset"`
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"cloudflaredb/internal/validation"
+)
+
+// Optional is a member of a JSON merge patch (RFC 7396). A member left out of the patch keeps
+// its current value, null removes it and any other value replaces it.
+type Optional[T any] struct {
+	Value T
+	// Set reports whether the member was present in the patch
+	Set bool
+	// Null reports whether the member was null; Value is then the zero value
+	Null bool
+}
+
+// Set returns an Optional replacing the current value with v
+func Set[T any](v T) Optional[T] {
+	return Optional[T]{Value: v, Set: true}
+}
+
+// Null returns an Optional removing the current value
+func Null[T any]() Optional[T] {
+	return Optional[T]{Set: true, Null: true}
+}
+
+// UnmarshalJSON records that the member was present and decodes its value
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	*o = Optional[T]{Set: true}
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		o.Null = true
+		return nil
+	}
+	return json.Unmarshal(data, &o.Value)
+}
+
+// Present returns the value, nil when the member was null, and whether the member was present.
+// It lets the validation package apply rules to the value only when one was sent.
+func (o Optional[T]) Present() (any, bool) {
+	if !o.Set || o.Null {
+		return nil, o.Set
+	}
+	return o.Value, true
+}
+
+// PatchUserRequest is a JSON merge patch of a user; email and name cannot be removed
+type PatchUserRequest struct {
+	Email Optional[string] `json:"email" validate:"required,email,max=254"`
+	Name  Optional[string] `json:"name" validate:"required,max=100"`
+}
+
+// PatchRoomRequest is a JSON merge patch of a room. Null clears the description, floor, tags,
+// amenities or attributes; attributes are merged key by key, with null removing a key.
+type PatchRoomRequest struct {
+	Name        Optional[string]          `json:"name" validate:"required,max=100"`
+	Description Optional[string]          `json:"description" validate:"max=1000"`
+	Capacity    Optional[int]             `json:"capacity" validate:"required,min=1,max=10000"`
+	FloorID     Optional[int64]           `json:"floor_id" validate:"min=0"`
+	Tags        Optional[[]string]        `json:"tags" validate:"max=20"`
+	Amenities   Optional[[]string]        `json:"amenities"`
+	Attributes  Optional[json.RawMessage] `json:"attributes"`
+}
+
+// Validate checks the tags, amenities and attributes of the patch
+func (r PatchRoomRequest) Validate() []validation.FieldError {
+	return validateRoomMetadata(r.Tags.Value, r.Amenities.Value, r.Attributes.Value)
+}
@@ -0,0 +1,134 @@
+package models
+
+import (
+	"cmp"
+	"strings"
+	"time"
+
+	"cloudflaredb/internal/recurrence"
+	"cloudflaredb/internal/validation"
+)
+
+const (
+	// SeriesCheckHorizon bounds, in years from the first occurrence, the occurrences of a series that
+	// are checked for capacity or may be excluded, and the expansion of rules without COUNT or UNTIL
+	SeriesCheckHorizon = 1
+
+	// MaxSeriesSpan bounds, in years from the first occurrence, the expansion of rules with COUNT or UNTIL
+	MaxSeriesSpan = 100
+)
+
+// RecurringBooking is a series of bookings of a room following an RFC 5545 recurrence rule.
+// StartsAt and EndsAt describe the first occurrence; every occurrence has the same duration.
+type RecurringBooking struct {
+	ID       int64     `json:"id"`
+	RoomID   int64     `json:"room_id"`
+	UserID   int64     `json:"user_id"`
+	Title    string    `json:"title"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+	// Timezone is the IANA zone whose wall-clock time occurrences keep, e.g. "Europe/Berlin"
+	Timezone string `json:"timezone"`
+	// RRule is the recurrence rule without the "RRULE:" prefix, e.g. "FREQ=WEEKLY;BYDAY=MO;COUNT=10"
+	RRule     string    `json:"rrule"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateRecurringBookingRequest represents the payload for creating a recurring booking
+type CreateRecurringBookingRequest struct {
+	UserID   int64     `json:"user_id" validate:"required"`
+	Title    string    `json:"title" validate:"max=200"`
+	StartsAt time.Time `json:"starts_at" validate:"required"`
+	EndsAt   time.Time `json:"ends_at" validate:"required"`
+	Timezone string    `json:"timezone"`
+	RRule    string    `json:"rrule" validate:"required"`
+	// ExDates lists the start times of occurrences to leave out (RFC 5545 EXDATE)
+	ExDates []time.Time `json:"exdates,omitempty" validate:"max=100"`
+}
+
+// Validate checks the time range, the time zone and the rule, and that starts_at and every
+// excluded date are occurrences of the rule. Excluded dates must fall within the span the rule is
+// expanded for: SeriesCheckHorizon for rules without COUNT or UNTIL, MaxSeriesSpan for others.
+func (r CreateRecurringBookingRequest) Validate() []validation.FieldError {
+	errs := validateTimeRange(r.StartsAt, r.EndsAt)
+
+	loc, err := time.LoadLocation(cmp.Or(r.Timezone, "UTC"))
+	if err != nil {
+		errs = append(errs, validation.FieldError{Field: "timezone", Message: "must be an IANA time zone name"})
+	}
+
+	var rule *recurrence.Rule
+	if r.RRule != "" {
+		if rule, err = recurrence.Parse(r.RRule); err != nil {
+			errs = append(errs, validation.FieldError{Field: "rrule", Message: strings.TrimPrefix(err.Error(), recurrence.ErrInvalidRule.Error()+": ")})
+		}
+	}
+
+	if rule == nil || loc == nil || r.StartsAt.IsZero() {
+		return errs
+	}
+
+	dtstart := r.StartsAt.In(loc).Truncate(time.Second)
+	if starts := rule.Occurrences(dtstart, dtstart.Add(time.Second)); len(starts) == 0 {
+		errs = append(errs, validation.FieldError{Field: "starts_at", Message: "must be an occurrence of rrule"})
+		return errs
+	}
+	if len(r.ExDates) == 0 {
+		return errs
+	}
+
+	// Excluded dates are checked against one expansion of the rule, which is only as long as the
+	// series is expanded anywhere else, so a far-off date cannot make it arbitrarily long
+	span, horizonMessage := SeriesCheckHorizon, "must be less than a year after starts_at"
+	if rule.Bounded() {
+		span, horizonMessage = MaxSeriesSpan, "must be less than 100 years after starts_at"
+	}
+	horizon := dtstart.AddDate(span, 0, 0)
+	last := dtstart
+	for _, t := range r.ExDates {
+		if !t.Before(horizon) {
+			errs = append(errs, validation.FieldError{Field: "exdates", Message: horizonMessage})
+			return errs
+		}
+		if t.After(last) {
+			last = t
+		}
+	}
+
+	occurrences := map[int64]bool{}
+	for _, start := range rule.Occurrences(dtstart, last.Add(time.Second)) {
+		occurrences[start.Unix()] = true
+	}
+	for _, t := range r.ExDates {
+		if !occurrences[t.Truncate(time.Second).Unix()] {
+			errs = append(errs, validation.FieldError{Field: "exdates", Message: "must only contain occurrences of rrule"})
+			break
+		}
+	}
+	return errs
+}
+
+// Occurrence is a single instance of a recurring booking
+type Occurrence struct {
+	SeriesID int64  `json:"series_id"`
+	RoomID   int64  `json:"room_id"`
+	UserID   int64  `json:"user_id"`
+	Title    string `json:"title"`
+	// RecurrenceID is the start time the rule assigns to the occurrence; it identifies the
+	// occurrence even after it has been moved (RFC 5545 RECURRENCE-ID)
+	RecurrenceID time.Time `json:"recurrence_id"`
+	StartsAt     time.Time `json:"starts_at"`
+	EndsAt       time.Time `json:"ends_at"`
+	Moved        bool      `json:"moved"`
+}
+
+// MoveOccurrenceRequest represents the payload for rescheduling a single occurrence
+type MoveOccurrenceRequest struct {
+	StartsAt time.Time `json:"starts_at" validate:"required"`
+	EndsAt   time.Time `json:"ends_at" validate:"required"`
+}
+
+// Validate checks that the occurrence ends after it starts, within MaxBookingDuration
+func (r MoveOccurrenceRequest) Validate() []validation.FieldError {
+	return validateTimeRange(r.StartsAt, r.EndsAt)
+}
@@ -1,31 +1,120 @@
 package models
 
 import (
+	"encoding/json"
+	"slices"
+	"strings"
 	"time"
+
+	"cloudflaredb/internal/validation"
 )
 
 // Room represents a room in the system
 type Room struct {
-	ID          int64     `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Capacity    int       `json:"capacity"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Capacity    int    `json:"capacity"`
+	// FloorID places the room in the site, building and floor hierarchy; nil when unplaced
+	FloorID   *int64   `json:"floor_id,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	Amenities []string `json:"amenities,omitempty"`
+	// Attributes is a free-form JSON object
+	Attributes json.RawMessage `json:"attributes,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+	// Version counts the changes to the room and backs its ETag
+	Version int64 `json:"version"`
 }
 
 // CreateRoomRequest represents the payload for creating a room
 type CreateRoomRequest struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Capacity    int    `json:"capacity"`
+	Name        string          `json:"name" validate:"required,max=100"`
+	Description string          `json:"description" validate:"max=1000"`
+	Capacity    int             `json:"capacity" validate:"min=1,max=10000"`
+	FloorID     int64           `json:"floor_id,omitempty" validate:"min=0"`
+	Tags        []string        `json:"tags,omitempty" validate:"max=20"`
+	Amenities   []string        `json:"amenities,omitempty"`
+	Attributes  json.RawMessage `json:"attributes,omitempty"`
 }
 
-// UpdateRoomRequest represents the payload for updating a room
+// Validate checks the tags, amenities and attributes of the room
+func (r CreateRoomRequest) Validate() []validation.FieldError {
+	return validateRoomMetadata(r.Tags, r.Amenities, r.Attributes)
+}
+
+// UpdateRoomRequest represents the payload for replacing a room; use PatchRoomRequest to change some fields.
+// Fields left out are reset: the description is emptied and the floor, tags, amenities and attributes are cleared.
 type UpdateRoomRequest struct {
-	Name        string `json:"name,omitempty"`
-	Description string `json:"description,omitempty"`
-	Capacity    int    `json:"capacity,omitempty"`
+	Name        string          `json:"name" validate:"required,max=100"`
+	Description string          `json:"description" validate:"max=1000"`
+	Capacity    int             `json:"capacity" validate:"min=1,max=10000"`
+	FloorID     int64           `json:"floor_id,omitempty" validate:"min=0"`
+	Tags        []string        `json:"tags,omitempty" validate:"max=20"`
+	Amenities   []string        `json:"amenities,omitempty"`
+	Attributes  json.RawMessage `json:"attributes,omitempty"`
+}
+
+// Validate checks the tags, amenities and attributes of the room
+func (r UpdateRoomRequest) Validate() []validation.FieldError {
+	return validateRoomMetadata(r.Tags, r.Amenities, r.Attributes)
+}
+
+// maxTagLength bounds the length of a room tag
+const maxTagLength = 50
+
+// validateRoomMetadata checks the elements of the tags and amenities and that attributes is a JSON object
+func validateRoomMetadata(tags, amenities []string, attributes json.RawMessage) []validation.FieldError {
+	var errs []validation.FieldError
+	for _, tag := range tags {
+		if tag = strings.TrimSpace(tag); tag == "" || len(tag) > maxTagLength {
+			errs = append(errs, validation.FieldError{Field: "tags", Message: "must be non-empty and at most 50 characters each"})
+			break
+		}
+	}
+	for _, amenity := range amenities {
+		if !ValidAmenity(amenity) {
+			errs = append(errs, validation.FieldError{Field: "amenities", Message: "must be one of " + strings.Join(Amenities, ", ")})
+			break
+		}
+	}
+	if len(attributes) > 0 {
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(attributes, &object); err != nil || object == nil {
+			errs = append(errs, validation.FieldError{Field: "attributes", Message: "must be a JSON object"})
+		}
+	}
+	return errs
+}
+
+// Amenities a room can offer
+const (
+	AmenityProjector  = "projector"
+	AmenityWhiteboard = "whiteboard"
+	AmenityVideo      = "video"
+)
+
+// Amenities is the fixed amenity vocabulary
+var Amenities = []string{AmenityProjector, AmenityWhiteboard, AmenityVideo}
+
+// ValidAmenity reports whether amenity is part of the amenity vocabulary
+func ValidAmenity(amenity string) bool {
+	return slices.Contains(Amenities, amenity)
+}
+
+// Membership roles of a user in a room
+const (
+	// RoleOwner manages the room; every room with members keeps at least one owner
+	RoleOwner = "owner"
+	// RoleModerator helps the owners manage the room
+	RoleModerator = "moderator"
+	// RoleMember is the default role
+	RoleMember = "member"
+)
+
+// ValidRole reports whether role is one of the membership roles
+func ValidRole(role string) bool {
+	return role == RoleOwner || role == RoleModerator || role == RoleMember
 }
 
 // UserRoom represents the many-to-many relationship between users and rooms
@@ -33,13 +122,41 @@ type UserRoom struct {
 	ID        int64     `json:"id"`
 	UserID    int64     `json:"user_id"`
 	RoomID    int64     `json:"room_id"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RoomMember is a user in a room, with how they got in: Direct users are assigned to the room and
+// have a Role in it, GroupIDs lists the assigned groups they reach it through
+type RoomMember struct {
+	User
+	Role     string  `json:"role,omitempty"`
+	Direct   bool    `json:"direct"`
+	GroupIDs []int64 `json:"group_ids,omitempty"`
+}
+
+// Assignment is a user-room assignment with the names of both sides
+type Assignment struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	UserName  string    `json:"user_name"`
+	UserEmail string    `json:"user_email"`
+	RoomID    int64     `json:"room_id"`
+	RoomName  string    `json:"room_name"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// RoomAvailability is a room with its current occupancy
+type RoomAvailability struct {
+	Room
+	Occupancy int `json:"occupancy"`
+	FreeSeats int `json:"free_seats"`
+}
+
 // RoomWithUsers represents a room with its associated users
 type RoomWithUsers struct {
 	Room
-	Users []*User `json:"users"`
+	Users []*RoomMember `json:"users"`
 }
 
 // UserWithRooms represents a user with their associated rooms
@@ -50,6 +167,13 @@ type UserWithRooms struct {
 
 // AssignUserToRoomRequest represents the payload for assigning a user to a room
 type AssignUserToRoomRequest struct {
-	UserID int64 `json:"user_id"`
+	UserID int64 `json:"user_id" validate:"required"`
 	RoomID int64 `json:"room_id"`
+	// Role is optional; it defaults to owner for a room without one and to member otherwise
+	Role string `json:"role,omitempty" validate:"omitempty,oneof=owner moderator member"`
+}
+
+// UpdateMembershipRequest represents the payload for changing a user's role in a room
+type UpdateMembershipRequest struct {
+	Role string `json:"role" validate:"required,oneof=owner moderator member"`
 }
@@ -0,0 +1,35 @@
+package models
+
+// Search modes report how results were produced
+const (
+	// SearchModeFullText means results come from the FTS5 index and are ranked and highlighted
+	SearchModeFullText = "fts"
+	// SearchModeBasic means the FTS5 index is unavailable and results come from substring matching
+	SearchModeBasic = "basic"
+)
+
+// RoomSearchHit is a room matched by a search query
+type RoomSearchHit struct {
+	Room
+	// Rank orders hits by relevance; lower is better
+	Rank float64 `json:"rank"`
+	// Highlights maps field names to their text with matches wrapped in <mark> tags
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
+
+// UserSearchHit is a user matched by a search query
+type UserSearchHit struct {
+	User
+	// Rank orders hits by relevance; lower is better
+	Rank float64 `json:"rank"`
+	// Highlights maps field names to their text with matches wrapped in <mark> tags
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
+
+// SearchResponse is the response body of GET /search
+type SearchResponse struct {
+	Query string           `json:"query"`
+	Mode  string           `json:"mode"`
+	Rooms []*RoomSearchHit `json:"rooms"`
+	Users []*UserSearchHit `json:"users"`
+}
@@ -11,16 +11,18 @@ type User struct {
 	Name      string    `json:"name"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	// Version counts the changes to the user and backs its ETag
+	Version int64 `json:"version"`
 }
 
 // CreateUserRequest represents the payload for creating a user
 type CreateUserRequest struct {
-	Email string `json:"email"`
-	Name  string `json:"name"`
+	Email string `json:"email" validate:"required,email,max=254"`
+	Name  string `json:"name" validate:"required,max=100"`
 }
 
-// UpdateUserRequest represents the payload for updating a user
+// UpdateUserRequest represents the payload for replacing a user; use PatchUserRequest to change some fields
 type UpdateUserRequest struct {
-	Email string `json:"email,omitempty"`
-	Name  string `json:"name,omitempty"`
+	Email string `json:"email" validate:"required,email,max=254"`
+	Name  string `json:"name" validate:"required,max=100"`
 }
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+// Waitlist entry statuses
+const (
+	// WaitlistStatusWaiting means the user is queued for a seat
+	WaitlistStatusWaiting = "waiting"
+	// WaitlistStatusAssigned means a seat was free and the user was assigned right away
+	WaitlistStatusAssigned = "assigned"
+)
+
+// WaitlistEntry is a user's place in the queue for a full room
+type WaitlistEntry struct {
+	RoomID int64  `json:"room_id"`
+	UserID int64  `json:"user_id"`
+	Status string `json:"status"`
+	// Position is the 1-based place in the queue; it is omitted once the user is assigned
+	Position  int       `json:"position,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WaitlistPromotion is the data of the event published when a waitlisted user gets a seat
+type WaitlistPromotion struct {
+	RoomID     int64     `json:"room_id"`
+	UserID     int64     `json:"user_id"`
+	PromotedAt time.Time `json:"promoted_at"`
+}
@@ -0,0 +1,326 @@
+// Package recurrence parses and expands the subset of RFC 5545 recurrence rules used for
+// recurring bookings: FREQ=DAILY|WEEKLY|MONTHLY with INTERVAL, BYDAY, COUNT and UNTIL.
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidRule is returned when a recurrence rule cannot be parsed or is not supported
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+// Frequency is the FREQ part of a rule
+type Frequency string
+
+// Supported frequencies
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+)
+
+// untilLayout and untilDateLayout are the UTC date-time and date forms of UNTIL
+const (
+	untilLayout     = "20060102T150405Z"
+	untilDateLayout = "20060102"
+)
+
+// weekdayCodes maps the two-letter BYDAY codes to weekdays
+var weekdayCodes = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// WeekdayNum is one BYDAY entry. N is 0 for every such weekday of the period, or the
+// 1-based position of the weekday within the month (negative counts from the end).
+type WeekdayNum struct {
+	N   int
+	Day time.Weekday
+}
+
+// String returns the BYDAY form of the entry, e.g. "MO" or "-1FR"
+func (w WeekdayNum) String() string {
+	code := strings.ToUpper(w.Day.String()[:2])
+	if w.N == 0 {
+		return code
+	}
+	return strconv.Itoa(w.N) + code
+}
+
+// Rule is a parsed recurrence rule
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	ByDay    []WeekdayNum
+	// Count limits the number of occurrences; 0 means no limit
+	Count int
+	// Until is the last instant an occurrence may start at; the zero time means no limit
+	Until time.Time
+	// UntilDate reports whether UNTIL was a date, which includes the whole day in the series time zone
+	UntilDate bool
+}
+
+// Parse parses a rule such as "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10". An "RRULE:" prefix is accepted.
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return nil, fmt.Errorf("%w: empty rule", ErrInvalidRule)
+	}
+
+	r := &Rule{Interval: 1}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(strings.TrimSpace(name))
+		value = strings.ToUpper(strings.TrimSpace(value))
+		if !ok || value == "" {
+			return nil, fmt.Errorf("%w: malformed part %q", ErrInvalidRule, part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%w: duplicate %s", ErrInvalidRule, name)
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			r.Freq = Frequency(value)
+			if r.Freq != Daily && r.Freq != Weekly && r.Freq != Monthly {
+				err = fmt.Errorf("unsupported FREQ %s", value)
+			}
+		case "INTERVAL":
+			r.Interval, err = parsePositive(value)
+		case "COUNT":
+			r.Count, err = parsePositive(value)
+		case "UNTIL":
+			err = r.parseUntil(value)
+		case "BYDAY":
+			r.ByDay, err = parseByDay(value)
+		case "WKST":
+			if value != "MO" {
+				err = fmt.Errorf("only WKST=MO is supported")
+			}
+		default:
+			err = fmt.Errorf("unsupported part %s", name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+		}
+	}
+
+	if r.Freq == "" {
+		return nil, fmt.Errorf("%w: FREQ is required", ErrInvalidRule)
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return nil, fmt.Errorf("%w: COUNT and UNTIL are mutually exclusive", ErrInvalidRule)
+	}
+	if r.Freq != Monthly {
+		for _, d := range r.ByDay {
+			if d.N != 0 {
+				return nil, fmt.Errorf("%w: numbered BYDAY requires FREQ=MONTHLY", ErrInvalidRule)
+			}
+		}
+	}
+
+	return r, nil
+}
+
+func parsePositive(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%q is not a positive integer", s)
+	}
+	return n, nil
+}
+
+func (r *Rule) parseUntil(s string) error {
+	if t, err := time.Parse(untilLayout, s); err == nil {
+		r.Until = t
+		return nil
+	}
+	if t, err := time.Parse(untilDateLayout, s); err == nil {
+		r.Until = t
+		r.UntilDate = true
+		return nil
+	}
+	return fmt.Errorf("UNTIL must be YYYYMMDD or YYYYMMDDTHHMMSSZ")
+}
+
+func parseByDay(s string) ([]WeekdayNum, error) {
+	var days []WeekdayNum
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) < 2 {
+			return nil, fmt.Errorf("invalid BYDAY %q", item)
+		}
+
+		day, ok := weekdayCodes[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("invalid BYDAY %q", item)
+		}
+
+		n := 0
+		if prefix := item[:len(item)-2]; prefix != "" {
+			var err error
+			n, err = strconv.Atoi(prefix)
+			if err != nil || n == 0 || n < -5 || n > 5 {
+				return nil, fmt.Errorf("invalid BYDAY %q", item)
+			}
+		}
+
+		wd := WeekdayNum{N: n, Day: day}
+		if !slices.Contains(days, wd) {
+			days = append(days, wd)
+		}
+	}
+	return days, nil
+}
+
+// String returns the canonical form of the rule
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, d := range r.ByDay {
+			days[i] = d.String()
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		if r.UntilDate {
+			parts = append(parts, "UNTIL="+r.Until.Format(untilDateLayout))
+		} else {
+			parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilLayout))
+		}
+	}
+	return strings.Join(parts, ";")
+}
+
+// Bounded reports whether the rule has a finite number of occurrences
+func (r *Rule) Bounded() bool {
+	return r.Count > 0 || !r.Until.IsZero()
+}
+
+// Occurrences returns the start times of the occurrences of a series starting at dtstart that begin
+// before end, in order. Occurrences keep dtstart's wall-clock time in dtstart's location, so a daily
+// 09:00 series stays at 09:00 across daylight saving changes.
+func (r *Rule) Occurrences(dtstart, end time.Time) []time.Time {
+	loc := dtstart.Location()
+	until := r.Until
+	if r.UntilDate {
+		y, m, d := r.Until.Date()
+		until = time.Date(y, m, d+1, 0, 0, 0, 0, loc).Add(-time.Nanosecond)
+	}
+
+	var out []time.Time
+	emitted := 0
+	for period := 0; ; period++ {
+		candidates, periodStart := r.candidates(dtstart, period)
+		if !periodStart.Before(end) || (!until.IsZero() && periodStart.After(until)) {
+			return out
+		}
+
+		for _, c := range candidates {
+			if c.Before(dtstart) {
+				continue
+			}
+			if !c.Before(end) || (!until.IsZero() && c.After(until)) {
+				return out
+			}
+			out = append(out, c)
+			emitted++
+			if r.Count > 0 && emitted == r.Count {
+				return out
+			}
+		}
+	}
+}
+
+// candidates returns the sorted occurrence candidates of the n-th period after dtstart's period,
+// along with the start of that period
+func (r *Rule) candidates(dtstart time.Time, n int) ([]time.Time, time.Time) {
+	loc := dtstart.Location()
+	y, m, d := dtstart.Date()
+	hh, mm, ss := dtstart.Clock()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, hh, mm, ss, 0, loc)
+	}
+	step := n * r.Interval
+
+	switch r.Freq {
+	case Daily:
+		day := at(y, m, d+step)
+		start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+		if len(r.ByDay) > 0 && !r.hasWeekday(day.Weekday()) {
+			return nil, start
+		}
+		return []time.Time{day}, start
+
+	case Weekly:
+		// Weeks start on Monday (WKST=MO)
+		monday := d - (int(dtstart.Weekday())+6)%7 + 7*step
+		start := time.Date(y, m, monday, 0, 0, 0, 0, loc)
+		days := []time.Weekday{dtstart.Weekday()}
+		if len(r.ByDay) > 0 {
+			days = days[:0]
+			for _, wd := range r.ByDay {
+				days = append(days, wd.Day)
+			}
+		}
+		var out []time.Time
+		for _, wd := range days {
+			out = append(out, at(y, m, monday+(int(wd)+6)%7))
+		}
+		slices.SortFunc(out, time.Time.Compare)
+		return out, start
+
+	default: // Monthly
+		first := time.Date(y, m+time.Month(step), 1, 0, 0, 0, 0, loc)
+		fy, fm, _ := first.Date()
+		daysInMonth := time.Date(fy, fm+1, 0, 0, 0, 0, 0, loc).Day()
+
+		if len(r.ByDay) == 0 {
+			// Months without dtstart's day of month are skipped
+			if d > daysInMonth {
+				return nil, first
+			}
+			return []time.Time{at(fy, fm, d)}, first
+		}
+
+		var out []time.Time
+		for _, wd := range r.ByDay {
+			offset := (int(wd.Day) - int(first.Weekday()) + 7) % 7
+			var days []int
+			for day := 1 + offset; day <= daysInMonth; day += 7 {
+				days = append(days, day)
+			}
+			switch {
+			case wd.N == 0:
+				for _, day := range days {
+					out = append(out, at(fy, fm, day))
+				}
+			case wd.N > 0 && wd.N <= len(days):
+				out = append(out, at(fy, fm, days[wd.N-1]))
+			case wd.N < 0 && -wd.N <= len(days):
+				out = append(out, at(fy, fm, days[len(days)+wd.N]))
+			}
+		}
+		slices.SortFunc(out, time.Time.Compare)
+		return slices.CompactFunc(out, time.Time.Equal), first
+	}
+}
+
+func (r *Rule) hasWeekday(day time.Weekday) bool {
+	return slices.ContainsFunc(r.ByDay, func(w WeekdayNum) bool { return w.Day == day })
+}
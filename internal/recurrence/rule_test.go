@@ -0,0 +1,161 @@
+package recurrence
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    string
+		want    string
+		wantErr bool
+	}{
+		{name: "weekly", rule: "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10", want: "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10"},
+		{name: "prefix and case", rule: "RRULE:freq=daily;interval=2", want: "FREQ=DAILY;INTERVAL=2"},
+		{name: "monthly ordinal", rule: "FREQ=MONTHLY;BYDAY=-1FR;UNTIL=20261231T000000Z", want: "FREQ=MONTHLY;BYDAY=-1FR;UNTIL=20261231T000000Z"},
+		{name: "until date", rule: "FREQ=DAILY;UNTIL=20260131", want: "FREQ=DAILY;UNTIL=20260131"},
+		{name: "default interval dropped", rule: "FREQ=WEEKLY;INTERVAL=1;WKST=MO", want: "FREQ=WEEKLY"},
+		{name: "missing freq", rule: "COUNT=3", wantErr: true},
+		{name: "unsupported freq", rule: "FREQ=YEARLY", wantErr: true},
+		{name: "unsupported part", rule: "FREQ=MONTHLY;BYMONTHDAY=1", wantErr: true},
+		{name: "count and until", rule: "FREQ=DAILY;COUNT=2;UNTIL=20260101", wantErr: true},
+		{name: "ordinal outside monthly", rule: "FREQ=WEEKLY;BYDAY=1MO", wantErr: true},
+		{name: "bad weekday", rule: "FREQ=WEEKLY;BYDAY=XX", wantErr: true},
+		{name: "zero interval", rule: "FREQ=DAILY;INTERVAL=0", wantErr: true},
+		{name: "duplicate part", rule: "FREQ=DAILY;FREQ=WEEKLY", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Parse(tt.rule)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidRule) {
+					t.Errorf("Expected ErrInvalidRule, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := r.String(); got != tt.want {
+				t.Errorf("String() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRule_Occurrences(t *testing.T) {
+	utc := func(s string) time.Time {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			panic(err)
+		}
+		return t
+	}
+
+	tests := []struct {
+		name    string
+		rule    string
+		dtstart time.Time
+		end     time.Time
+		want    []string
+	}{
+		{
+			name:    "daily count",
+			rule:    "FREQ=DAILY;COUNT=3",
+			dtstart: utc("2026-01-05T09:00:00Z"),
+			end:     utc("2027-01-01T00:00:00Z"),
+			want:    []string{"2026-01-05T09:00:00Z", "2026-01-06T09:00:00Z", "2026-01-07T09:00:00Z"},
+		},
+		{
+			name:    "daily on weekdays",
+			rule:    "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR",
+			dtstart: utc("2026-01-09T09:00:00Z"),
+			end:     utc("2026-01-14T00:00:00Z"),
+			want:    []string{"2026-01-09T09:00:00Z", "2026-01-12T09:00:00Z", "2026-01-13T09:00:00Z"},
+		},
+		{
+			name:    "weekly by day",
+			rule:    "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=4",
+			dtstart: utc("2026-01-07T10:00:00Z"),
+			end:     utc("2027-01-01T00:00:00Z"),
+			want:    []string{"2026-01-07T10:00:00Z", "2026-01-12T10:00:00Z", "2026-01-14T10:00:00Z", "2026-01-19T10:00:00Z"},
+		},
+		{
+			name:    "biweekly until",
+			rule:    "FREQ=WEEKLY;INTERVAL=2;UNTIL=20260202",
+			dtstart: utc("2026-01-05T10:00:00Z"),
+			end:     utc("2027-01-01T00:00:00Z"),
+			want:    []string{"2026-01-05T10:00:00Z", "2026-01-19T10:00:00Z", "2026-02-02T10:00:00Z"},
+		},
+		{
+			name:    "monthly skips short months",
+			rule:    "FREQ=MONTHLY;COUNT=3",
+			dtstart: utc("2026-01-31T09:00:00Z"),
+			end:     utc("2027-01-01T00:00:00Z"),
+			want:    []string{"2026-01-31T09:00:00Z", "2026-03-31T09:00:00Z", "2026-05-31T09:00:00Z"},
+		},
+		{
+			name:    "monthly last friday",
+			rule:    "FREQ=MONTHLY;BYDAY=-1FR;COUNT=2",
+			dtstart: utc("2026-01-30T16:00:00Z"),
+			end:     utc("2027-01-01T00:00:00Z"),
+			want:    []string{"2026-01-30T16:00:00Z", "2026-02-27T16:00:00Z"},
+		},
+		{
+			name:    "unbounded stops at end",
+			rule:    "FREQ=WEEKLY",
+			dtstart: utc("2026-01-05T10:00:00Z"),
+			end:     utc("2026-01-19T10:00:00Z"),
+			want:    []string{"2026-01-05T10:00:00Z", "2026-01-12T10:00:00Z"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			got := r.Occurrences(tt.dtstart, tt.end)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %d occurrences, got %v", len(tt.want), got)
+			}
+			for i, occ := range got {
+				if !occ.Equal(utc(tt.want[i])) {
+					t.Errorf("Occurrence %d = %s, want %s", i, occ.Format(time.RFC3339), tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRule_OccurrencesKeepWallClockAcrossDST(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	r, err := Parse("FREQ=WEEKLY;COUNT=2")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	// Daylight saving time starts on 2026-03-29 in Berlin
+	got := r.Occurrences(time.Date(2026, 3, 23, 9, 0, 0, 0, loc), time.Date(2027, 1, 1, 0, 0, 0, 0, loc))
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 occurrences, got %v", got)
+	}
+	for _, occ := range got {
+		if occ.Hour() != 9 {
+			t.Errorf("Expected 09:00 local time, got %s", occ)
+		}
+	}
+	if offset := got[1].Sub(got[0]); offset != 7*24*time.Hour-time.Hour {
+		t.Errorf("Expected a 167h gap across the DST change, got %s", offset)
+	}
+}
@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"cloudflaredb/internal/models"
+)
+
+// bookingTimeLayout is the storage format of booking times. Times are stored as UTC text in a
+// fixed-width format so they compare correctly as strings on both SQLite and D1.
+const bookingTimeLayout = "2006-01-02T15:04:05Z"
+
+// bookingPeakSQL computes the largest number of seats of a room taken at the same moment within
+// [start, end) by its bookings and by the occurrences of recurring bookings, which are expanded in
+// Go and passed as a single JSON array of [start, end] pairs. The statement has a fixed size however
+// many occurrences there are, so it stays within the compound SELECT limit of SQLite and the bound
+// parameter limit of D1. Occupancy only increases at a start, so it is enough to check the window
+// start and every start inside the window.
+// Arguments: room id, end, start, the occurrences, then start, start, end.
+const bookingPeakSQL = `(
+		WITH taken (starts_at, ends_at) AS (
+			SELECT starts_at, ends_at FROM bookings WHERE room_id = ? AND starts_at < ? AND ends_at > ?
+			UNION ALL
+			SELECT json_extract(value, '$[0]'), json_extract(value, '$[1]') FROM json_each(?)
+		)
+		SELECT COALESCE(MAX((
+			SELECT COUNT(*) FROM taken s
+			WHERE s.starts_at <= p.t AND s.ends_at > p.t
+		)), 0)
+		FROM (
+			SELECT ? AS t
+			UNION
+			SELECT starts_at FROM taken WHERE starts_at > ? AND starts_at < ?
+		) AS p
+	)`
+
+// formatBookingTime converts t to the storage format of booking times
+func formatBookingTime(t time.Time) string {
+	return t.UTC().Format(bookingTimeLayout)
+}
+
+// BookingRepository handles database operations for room bookings
+type BookingRepository struct {
+	db *sql.DB
+}
+
+// NewBookingRepository creates a new booking repository
+func NewBookingRepository(db *sql.DB) *BookingRepository {
+	return &BookingRepository{db: db}
+}
+
+// Create books a room for a user. Up to rooms.capacity bookings and occurrences of recurring
+// bookings may overlap at any moment. The occurrences are expanded in Go and passed to the single
+// statement that checks the capacity and inserts the booking, so concurrent bookings cannot
+// overbook the room. Recurring bookings are checked by separate statements, since D1 has no
+// interactive transactions, so a booking racing the creation or move of an occurrence in the
+// same slot may still overbook it.
+func (r *BookingRepository) Create(ctx context.Context, roomID int64, req *models.CreateBookingRequest) (*models.Booking, error) {
+	start := formatBookingTime(req.StartsAt)
+	end := formatBookingTime(req.EndsAt)
+
+	occurrences, err := roomOccurrences(ctx, r.db, roomID, req.StartsAt, req.EndsAt)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO bookings (room_id, user_id, title, starts_at, ends_at, created_at)
+		SELECT rooms.id, ?, ?, ?, ?, ?
+		FROM rooms
+		WHERE rooms.id = ?
+		  AND EXISTS (SELECT 1 FROM users WHERE users.id = ?)
+		  AND ` + bookingPeakSQL + ` < rooms.capacity
+	`
+
+	taken := make([][2]string, len(occurrences))
+	for i, o := range occurrences {
+		taken[i] = [2]string{formatBookingTime(o.StartsAt), formatBookingTime(o.EndsAt)}
+	}
+	takenJSON, err := json.Marshal(taken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode occurrences: %w", err)
+	}
+
+	result, err := r.db.ExecContext(ctx, query,
+		req.UserID, req.Title, start, end, time.Now(), roomID, req.UserID,
+		roomID, end, start, string(takenJSON), start, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create booking: %w", classifyError(err))
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		// Nothing was inserted: find out why
+		if err := checkExists(ctx, r.db, "rooms", roomID, ErrRoomNotFound); err != nil {
+			return nil, err
+		}
+		if err := checkExists(ctx, r.db, "users", req.UserID, ErrUserNotFound); err != nil {
+			return nil, err
+		}
+		return nil, ErrFullyBooked
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	return r.GetByID(ctx, id)
+}
+
+// GetByID retrieves a booking by ID
+func (r *BookingRepository) GetByID(ctx context.Context, id int64) (*models.Booking, error) {
+	query := `
+		SELECT *
+		FROM bookings
+		WHERE id = ?
+	`
+
+	bookings, err := r.queryBookings(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bookings) == 0 {
+		return nil, ErrBookingNotFound
+	}
+
+	return bookings[0], nil
+}
+
+// ListByRoom retrieves the bookings of a room that overlap [from, to), ordered by start time.
+// A zero from or to leaves that side of the window open.
+func (r *BookingRepository) ListByRoom(ctx context.Context, roomID int64, from, to time.Time) ([]*models.Booking, error) {
+	if err := checkExists(ctx, r.db, "rooms", roomID, ErrRoomNotFound); err != nil {
+		return nil, err
+	}
+
+	c := &conditions{}
+	c.add("room_id = ?", roomID)
+	if !to.IsZero() {
+		c.add("starts_at < ?", formatBookingTime(to))
+	}
+	if !from.IsZero() {
+		c.add("ends_at > ?", formatBookingTime(from))
+	}
+
+	query := `
+		SELECT *
+		FROM bookings
+		` + c.where() + `
+		ORDER BY starts_at, id
+	`
+
+	return r.queryBookings(ctx, query, c.args...)
+}
+
+// Delete cancels a booking
+func (r *BookingRepository) Delete(ctx context.Context, id int64) error {
+	query := `DELETE FROM bookings WHERE id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete booking: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrBookingNotFound
+	}
+
+	return nil
+}
+
+// queryBookings runs a query returning full booking rows and scans them
+func (r *BookingRepository) queryBookings(ctx context.Context, query string, args ...interface{}) ([]*models.Booking, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query bookings: %w", err)
+	}
+	defer rows.Close()
+
+	var bookings []*models.Booking
+	for rows.Next() {
+		v, err := scanValues(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan booking: %w", err)
+		}
+
+		bookings = append(bookings, &models.Booking{
+			ID:        toInt64(v["id"]),
+			RoomID:    toInt64(v["room_id"]),
+			UserID:    toInt64(v["user_id"]),
+			Title:     toString(v["title"]),
+			StartsAt:  parseTimeValue(v["starts_at"]),
+			EndsAt:    parseTimeValue(v["ends_at"]),
+			CreatedAt: parseTimeValue(v["created_at"]),
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return bookings, nil
+}
@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"cloudflaredb/internal/models"
+)
+
+// seedBookingData creates two users and a room with the given capacity
+func seedBookingData(t *testing.T, repo *BookingRepository, capacity int) {
+	t.Helper()
+
+	if _, err := repo.db.Exec(`INSERT INTO users (email, name) VALUES ('a@example.com', 'A'), ('b@example.com', 'B')`); err != nil {
+		t.Fatalf("Failed to seed users: %v", err)
+	}
+	if _, err := repo.db.Exec(`INSERT INTO rooms (name, capacity) VALUES ('Room', ?)`, capacity); err != nil {
+		t.Fatalf("Failed to seed room: %v", err)
+	}
+}
+
+// hourSlot returns a booking request for user covering [start, end) hours of 2026-01-05 UTC
+func hourSlot(userID int64, start, end int) *models.CreateBookingRequest {
+	day := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	return &models.CreateBookingRequest{
+		UserID:   userID,
+		StartsAt: day.Add(time.Duration(start) * time.Hour),
+		EndsAt:   day.Add(time.Duration(end) * time.Hour),
+	}
+}
+
+func TestBookingRepository_Create(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	repo := NewBookingRepository(db)
+	ctx := context.Background()
+	seedBookingData(t, repo, 1)
+
+	req := hourSlot(1, 9, 10)
+	req.Title = "Standup"
+	req.StartsAt = req.StartsAt.In(time.FixedZone("CET", 3600))
+
+	booking, err := repo.Create(ctx, 1, req)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if booking.ID == 0 || booking.RoomID != 1 || booking.UserID != 1 || booking.Title != "Standup" {
+		t.Errorf("Unexpected booking: %+v", booking)
+	}
+	if !booking.StartsAt.Equal(req.StartsAt) || !booking.EndsAt.Equal(req.EndsAt) {
+		t.Errorf("Expected %v-%v, got %v-%v", req.StartsAt, req.EndsAt, booking.StartsAt, booking.EndsAt)
+	}
+
+	tests := []struct {
+		name    string
+		roomID  int64
+		req     *models.CreateBookingRequest
+		wantErr error
+	}{
+		{name: "overlapping booking", roomID: 1, req: hourSlot(2, 9, 11), wantErr: ErrFullyBooked},
+		{name: "adjacent booking", roomID: 1, req: hourSlot(2, 10, 11)},
+		{name: "missing room", roomID: 99, req: hourSlot(1, 12, 13), wantErr: ErrRoomNotFound},
+		{name: "missing user", roomID: 1, req: hourSlot(99, 12, 13), wantErr: ErrUserNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := repo.Create(ctx, tt.roomID, tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Create() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBookingRepository_CreateRespectsCapacity(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	repo := NewBookingRepository(db)
+	ctx := context.Background()
+	seedBookingData(t, repo, 2)
+
+	// 9-11 and 11-13 never overlap each other, so 10-12 only ever sees one concurrent booking
+	for _, req := range []*models.CreateBookingRequest{hourSlot(1, 9, 11), hourSlot(1, 11, 13), hourSlot(2, 10, 12)} {
+		if _, err := repo.Create(ctx, 1, req); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	// 8-12 would be the third concurrent booking from 10:00 on
+	if _, err := repo.Create(ctx, 1, hourSlot(2, 8, 12)); !errors.Is(err, ErrFullyBooked) {
+		t.Errorf("Expected ErrFullyBooked, got %v", err)
+	}
+
+	if _, err := repo.Create(ctx, 1, hourSlot(2, 12, 14)); err != nil {
+		t.Errorf("Expected free seat after 12:00, got %v", err)
+	}
+}
+
+func TestBookingRepository_CreateOverLongSeries(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	repo := NewBookingRepository(db)
+	ctx := context.Background()
+	seedBookingData(t, repo, 2)
+
+	// The series takes one of the two seats from 9 to 10 every day, with no end
+	series := hourSlot(2, 9, 10)
+	if _, err := NewRecurringBookingRepository(db).Create(ctx, 1, &models.CreateRecurringBookingRequest{
+		UserID: 2, StartsAt: series.StartsAt, EndsAt: series.EndsAt, RRule: "FREQ=DAILY",
+	}); err != nil {
+		t.Fatalf("Failed to create recurring booking: %v", err)
+	}
+
+	// Two years overlap more than 700 occurrences, all checked by one fixed-size statement
+	long := hourSlot(1, 0, 1)
+	long.EndsAt = long.StartsAt.AddDate(2, 0, 0)
+	if _, err := repo.Create(ctx, 1, long); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	if _, err := repo.Create(ctx, 1, hourSlot(1, 9, 10)); !errors.Is(err, ErrFullyBooked) {
+		t.Errorf("Expected ErrFullyBooked next to the series and the long booking, got %v", err)
+	}
+	if _, err := repo.Create(ctx, 1, long); !errors.Is(err, ErrFullyBooked) {
+		t.Errorf("Expected ErrFullyBooked for a second long booking, got %v", err)
+	}
+}
+
+func TestBookingRepository_CreateConcurrentWithSeries(t *testing.T) {
+	// A file database lets concurrent connections share the same data
+	dsn := filepath.Join(t.TempDir(), "bookings.db") + "?_busy_timeout=5000"
+	db := openTestDBWithRooms(t, dsn)
+	defer db.Close()
+
+	repo := NewBookingRepository(db)
+	ctx := context.Background()
+	seedBookingData(t, repo, 2)
+
+	// The series takes one of the two seats from 9 to 10 every day
+	series := hourSlot(2, 9, 10)
+	if _, err := NewRecurringBookingRepository(db).Create(ctx, 1, &models.CreateRecurringBookingRequest{
+		UserID: 2, StartsAt: series.StartsAt, EndsAt: series.EndsAt, RRule: "FREQ=DAILY;COUNT=5",
+	}); err != nil {
+		t.Fatalf("Failed to create recurring booking: %v", err)
+	}
+
+	const attempts = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, attempts)
+	for range attempts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.Create(ctx, 1, hourSlot(1, 9, 10))
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, ErrFullyBooked):
+			t.Errorf("Unexpected error: %v", err)
+		}
+	}
+
+	if succeeded != 1 {
+		t.Errorf("Expected 1 booking next to the series, got %d", succeeded)
+	}
+}
+
+func TestBookingRepository_ListByRoom(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	repo := NewBookingRepository(db)
+	ctx := context.Background()
+	seedBookingData(t, repo, 5)
+
+	for _, req := range []*models.CreateBookingRequest{hourSlot(1, 14, 15), hourSlot(1, 9, 10), hourSlot(2, 11, 12)} {
+		if _, err := repo.Create(ctx, 1, req); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	all, err := repo.ListByRoom(ctx, 1, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("ListByRoom() error = %v", err)
+	}
+	if len(all) != 3 || all[0].StartsAt.Hour() != 9 || all[2].StartsAt.Hour() != 14 {
+		t.Errorf("Expected 3 bookings ordered by start, got %+v", all)
+	}
+
+	window := hourSlot(0, 10, 12)
+	inWindow, err := repo.ListByRoom(ctx, 1, window.StartsAt, window.EndsAt)
+	if err != nil {
+		t.Fatalf("ListByRoom() error = %v", err)
+	}
+	if len(inWindow) != 1 || inWindow[0].StartsAt.Hour() != 11 {
+		t.Errorf("Expected only the 11:00 booking, got %+v", inWindow)
+	}
+
+	if _, err := repo.ListByRoom(ctx, 99, time.Time{}, time.Time{}); !errors.Is(err, ErrRoomNotFound) {
+		t.Errorf("Expected ErrRoomNotFound, got %v", err)
+	}
+}
+
+func TestBookingRepository_Delete(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	repo := NewBookingRepository(db)
+	ctx := context.Background()
+	seedBookingData(t, repo, 1)
+
+	booking, err := repo.Create(ctx, 1, hourSlot(1, 9, 10))
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	if err := repo.Delete(ctx, booking.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := repo.GetByID(ctx, booking.ID); !errors.Is(err, ErrBookingNotFound) {
+		t.Errorf("Expected ErrBookingNotFound after delete, got %v", err)
+	}
+	if err := repo.Delete(ctx, booking.ID); !errors.Is(err, ErrBookingNotFound) {
+		t.Errorf("Expected ErrBookingNotFound on second delete, got %v", err)
+	}
+
+	// The freed slot can be booked again
+	if _, err := repo.Create(ctx, 1, hourSlot(2, 9, 10)); err != nil {
+		t.Errorf("Expected slot to be free after delete, got %v", err)
+	}
+}
@@ -0,0 +1,250 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+var (
+	// ErrNotFound is matched by every error reporting a missing row
+	ErrNotFound = errors.New("not found")
+
+	// ErrConflict is matched by every error reporting a clash with existing data
+	ErrConflict = errors.New("conflict")
+
+	// ErrForbidden is matched by every error reporting that the acting user lacks the required role
+	ErrForbidden = errors.New("forbidden")
+)
+
+var (
+	// ErrUserNotFound is returned when a user does not exist
+	ErrUserNotFound = newKindError("user not found", ErrNotFound)
+
+	// ErrRoomNotFound is returned when a room does not exist
+	ErrRoomNotFound = newKindError("room not found", ErrNotFound)
+
+	// ErrNotAssigned is returned when removing a user from a room they are not assigned to
+	ErrNotAssigned = newKindError("user not assigned to this room", ErrNotFound)
+
+	// ErrAlreadyAssigned is returned when assigning a user to a room they are already in
+	ErrAlreadyAssigned = newKindError("user already assigned to this room", ErrConflict)
+
+	// ErrRoomFull is returned when assigning a user to a room that has reached its capacity
+	ErrRoomFull = newKindError("room is full", ErrConflict)
+
+	// ErrLastOwner is returned when removing or demoting the last owner of a room that has other members
+	ErrLastOwner = newKindError("room must keep at least one owner", ErrConflict)
+
+	// ErrNotRoomOwner is returned when a user who does not own a room tries to manage its members
+	ErrNotRoomOwner = newKindError("only owners of the room can do this", ErrForbidden)
+
+	// ErrNotRoomModerator is returned when a user who neither owns nor moderates a room tries to review its join requests
+	ErrNotRoomModerator = newKindError("only owners and moderators of the room can do this", ErrForbidden)
+
+	// ErrInvitationNotFound is returned when an invitation does not exist or the token matches none
+	ErrInvitationNotFound = newKindError("invitation not found", ErrNotFound)
+
+	// ErrInvitationClosed is returned when responding to an invitation that was already accepted or declined
+	ErrInvitationClosed = newKindError("invitation was already answered", ErrConflict)
+
+	// ErrInvitationExpired is returned when responding to an invitation after its expiry
+	ErrInvitationExpired = newKindError("invitation has expired", ErrConflict)
+
+	// ErrNotInvitee is returned when a user responds to an invitation addressed to someone else
+	ErrNotInvitee = newKindError("invitation is addressed to another user", ErrForbidden)
+
+	// ErrJoinRequestNotFound is returned when a join request does not exist
+	ErrJoinRequestNotFound = newKindError("join request not found", ErrNotFound)
+
+	// ErrJoinRequestClosed is returned when reviewing a join request that was already approved or rejected
+	ErrJoinRequestClosed = newKindError("join request was already reviewed", ErrConflict)
+
+	// ErrJoinRequestPending is returned when a user asks to join a room they already have a pending request for
+	ErrJoinRequestPending = newKindError("user already has a pending request to join this room", ErrConflict)
+
+	// ErrAlreadyWaitlisted is returned when a user joins the waitlist of a room they are already queued for
+	ErrAlreadyWaitlisted = newKindError("user already on the waitlist of this room", ErrConflict)
+
+	// ErrNotWaitlisted is returned when removing a user from a waitlist they are not on
+	ErrNotWaitlisted = newKindError("user not on the waitlist of this room", ErrNotFound)
+
+	// ErrCapacityBelowOccupancy is returned when an update would lower a room's capacity below its current number of users
+	ErrCapacityBelowOccupancy = newKindError("capacity is below the current number of assigned users", ErrConflict)
+
+	// ErrVersionMismatch is returned when a user or room was changed since the version an update or delete expected
+	ErrVersionMismatch = newKindError("row was modified since the expected version", ErrConflict)
+
+	// ErrBookingNotFound is returned when a booking does not exist
+	ErrBookingNotFound = newKindError("booking not found", ErrNotFound)
+
+	// ErrFullyBooked is returned when a booking would exceed the room's capacity at some point of its time range
+	ErrFullyBooked = newKindError("room is fully booked for the requested time", ErrConflict)
+
+	// ErrRecurringBookingNotFound is returned when a recurring booking does not exist
+	ErrRecurringBookingNotFound = newKindError("recurring booking not found", ErrNotFound)
+
+	// ErrOccurrenceNotFound is returned when a recurring booking has no (uncancelled) occurrence at the given time
+	ErrOccurrenceNotFound = newKindError("occurrence not found", ErrNotFound)
+
+	// ErrSiteNotFound is returned when a site does not exist
+	ErrSiteNotFound = newKindError("site not found", ErrNotFound)
+
+	// ErrBuildingNotFound is returned when a building does not exist
+	ErrBuildingNotFound = newKindError("building not found", ErrNotFound)
+
+	// ErrFloorNotFound is returned when a floor does not exist
+	ErrFloorNotFound = newKindError("floor not found", ErrNotFound)
+
+	// ErrGroupNotFound is returned when a group does not exist
+	ErrGroupNotFound = newKindError("group not found", ErrNotFound)
+
+	// ErrAlreadyGroupMember is returned when adding a user to a group they are already in
+	ErrAlreadyGroupMember = newKindError("user already in this group", ErrConflict)
+
+	// ErrNotGroupMember is returned when removing a user from a group they are not in
+	ErrNotGroupMember = newKindError("user not in this group", ErrNotFound)
+
+	// ErrGroupAlreadyAssigned is returned when assigning a group to a room it is already assigned to
+	ErrGroupAlreadyAssigned = newKindError("group already assigned to this room", ErrConflict)
+
+	// ErrGroupNotAssigned is returned when removing a group from a room it is not assigned to
+	ErrGroupNotAssigned = newKindError("group not assigned to this room", ErrNotFound)
+)
+
+// kindError is a sentinel error that also matches a broader category (ErrNotFound, ErrConflict) with errors.Is
+type kindError struct {
+	msg  string
+	kind error
+}
+
+func newKindError(msg string, kind error) error {
+	return &kindError{msg: msg, kind: kind}
+}
+
+// Error implements the error interface
+func (e *kindError) Error() string {
+	return e.msg
+}
+
+// Is reports whether target is the category of the error
+func (e *kindError) Is(target error) bool {
+	return target == e.kind
+}
+
+// ConstraintKind identifies the type of constraint that was violated
+type ConstraintKind string
+
+const (
+	// ConstraintUnique is a UNIQUE or PRIMARY KEY violation
+	ConstraintUnique ConstraintKind = "unique"
+	// ConstraintForeignKey is a FOREIGN KEY violation
+	ConstraintForeignKey ConstraintKind = "foreign_key"
+	// ConstraintNotNull is a NOT NULL violation
+	ConstraintNotNull ConstraintKind = "not_null"
+	// ConstraintCheck is a CHECK violation
+	ConstraintCheck ConstraintKind = "check"
+)
+
+// ConstraintError wraps a driver error caused by a constraint violation.
+// It works for both mattn/go-sqlite3 (typed errors) and cfd1 (D1 error messages).
+type ConstraintError struct {
+	Kind ConstraintKind
+	// Columns lists the offending columns as reported by SQLite, e.g. "users.email"
+	Columns []string
+	Err     error
+}
+
+// Error implements the error interface
+func (e *ConstraintError) Error() string {
+	return string(e.Kind) + " constraint violation: " + e.Err.Error()
+}
+
+// Unwrap returns the underlying driver error
+func (e *ConstraintError) Unwrap() error {
+	return e.Err
+}
+
+// Is makes unique violations match ErrConflict
+func (e *ConstraintError) Is(target error) bool {
+	return target == ErrConflict && e.Kind == ConstraintUnique
+}
+
+// HasColumn reports whether the violation involves the given column (e.g. "email" or "users.email")
+func (e *ConstraintError) HasColumn(column string) bool {
+	for _, c := range e.Columns {
+		if c == column || strings.HasSuffix(c, "."+column) {
+			return true
+		}
+	}
+	return false
+}
+
+// constraintMessages maps the SQLite error message prefixes to their constraint kinds.
+// D1 forwards these messages verbatim, so they are used when no typed driver error is available.
+var constraintMessages = []struct {
+	prefix string
+	kind   ConstraintKind
+}{
+	{"UNIQUE constraint failed", ConstraintUnique},
+	{"PRIMARY KEY constraint failed", ConstraintUnique},
+	{"FOREIGN KEY constraint failed", ConstraintForeignKey},
+	{"NOT NULL constraint failed", ConstraintNotNull},
+	{"CHECK constraint failed", ConstraintCheck},
+}
+
+// classifyError converts driver constraint violations into *ConstraintError and returns other errors unchanged
+func classifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var ce *ConstraintError
+	if errors.As(err, &ce) {
+		return err
+	}
+
+	msg := err.Error()
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code == sqlite3.ErrConstraint {
+		switch sqliteErr.ExtendedCode {
+		case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+			return &ConstraintError{Kind: ConstraintUnique, Columns: constraintColumns(msg), Err: err}
+		case sqlite3.ErrConstraintForeignKey:
+			return &ConstraintError{Kind: ConstraintForeignKey, Err: err}
+		case sqlite3.ErrConstraintNotNull:
+			return &ConstraintError{Kind: ConstraintNotNull, Columns: constraintColumns(msg), Err: err}
+		case sqlite3.ErrConstraintCheck:
+			return &ConstraintError{Kind: ConstraintCheck, Err: err}
+		}
+	}
+
+	for _, cm := range constraintMessages {
+		if strings.Contains(msg, cm.prefix) {
+			return &ConstraintError{Kind: cm.kind, Columns: constraintColumns(msg), Err: err}
+		}
+	}
+
+	return err
+}
+
+// constraintColumns extracts the column list from messages like "UNIQUE constraint failed: users.email"
+func constraintColumns(msg string) []string {
+	_, rest, ok := strings.Cut(msg, "constraint failed: ")
+	if !ok {
+		return nil
+	}
+
+	// D1 appends its own suffix (e.g. ": SQLITE_CONSTRAINT")
+	rest, _, _ = strings.Cut(rest, ":")
+
+	var columns []string
+	for _, c := range strings.Split(rest, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			columns = append(columns, c)
+		}
+	}
+	return columns
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"cloudflaredb/internal/models"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestSentinelErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		target error
+		want   bool
+	}{
+		{name: "user not found is not found", err: ErrUserNotFound, target: ErrNotFound, want: true},
+		{name: "room not found is not found", err: ErrRoomNotFound, target: ErrNotFound, want: true},
+		{name: "not assigned is not found", err: ErrNotAssigned, target: ErrNotFound, want: true},
+		{name: "already assigned is conflict", err: ErrAlreadyAssigned, target: ErrConflict, want: true},
+		{name: "room full is conflict", err: ErrRoomFull, target: ErrConflict, want: true},
+		{name: "capacity below occupancy is conflict", err: ErrCapacityBelowOccupancy, target: ErrConflict, want: true},
+		{name: "wrapped room not found", err: fmt.Errorf("lookup: %w", ErrRoomNotFound), target: ErrNotFound, want: true},
+		{name: "room full is not not found", err: ErrRoomFull, target: ErrNotFound, want: false},
+		{name: "room not found is not user not found", err: ErrRoomNotFound, target: ErrUserNotFound, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errors.Is(tt.err, tt.target); got != tt.want {
+				t.Errorf("errors.Is(%v, %v) = %v, want %v", tt.err, tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifyError_Messages(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantKind   ConstraintKind
+		wantColumn string
+		conflict   bool
+	}{
+		{
+			name:       "d1 unique violation",
+			err:        errors.New("D1_ERROR: UNIQUE constraint failed: users.email: SQLITE_CONSTRAINT"),
+			wantKind:   ConstraintUnique,
+			wantColumn: "email",
+			conflict:   true,
+		},
+		{
+			name:     "d1 foreign key violation",
+			err:      errors.New("D1_ERROR: FOREIGN KEY constraint failed: SQLITE_CONSTRAINT"),
+			wantKind: ConstraintForeignKey,
+		},
+		{
+			name:       "d1 not null violation",
+			err:        errors.New("NOT NULL constraint failed: rooms.name"),
+			wantKind:   ConstraintNotNull,
+			wantColumn: "rooms.name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := classifyError(tt.err)
+
+			var ce *ConstraintError
+			if !errors.As(err, &ce) {
+				t.Fatalf("Expected *ConstraintError, got %T", err)
+			}
+
+			if ce.Kind != tt.wantKind {
+				t.Errorf("Expected kind %s, got %s", tt.wantKind, ce.Kind)
+			}
+
+			if tt.wantColumn != "" && !ce.HasColumn(tt.wantColumn) {
+				t.Errorf("Expected column %s in %v", tt.wantColumn, ce.Columns)
+			}
+
+			if errors.Is(err, ErrConflict) != tt.conflict {
+				t.Errorf("Expected errors.Is(ErrConflict) = %v", tt.conflict)
+			}
+
+			if !errors.Is(err, tt.err) {
+				t.Error("Expected the driver error to remain in the chain")
+			}
+		})
+	}
+
+	// Unrelated errors pass through untouched
+	plain := errors.New("connection refused")
+	if got := classifyError(plain); got != plain {
+		t.Errorf("Expected unrelated error to be returned unchanged, got %v", got)
+	}
+}
+
+func TestClassifyError_SQLiteDriver(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	repo := NewUserRepository(db, NewRoomRepository(db))
+
+	if _, err := repo.Create(ctx, &models.CreateUserRequest{Email: "dup@example.com", Name: "First"}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	_, err := repo.Create(ctx, &models.CreateUserRequest{Email: "dup@example.com", Name: "Second"})
+
+	var ce *ConstraintError
+	if !errors.As(err, &ce) {
+		t.Fatalf("Expected *ConstraintError, got %v", err)
+	}
+
+	if ce.Kind != ConstraintUnique || !ce.HasColumn("email") {
+		t.Errorf("Expected unique violation on email, got %s on %v", ce.Kind, ce.Columns)
+	}
+
+	if !errors.Is(err, ErrConflict) {
+		t.Error("Expected duplicate email to match ErrConflict")
+	}
+
+	// Foreign keys are enforced when enabled (always on in D1)
+	if _, err := db.ExecContext(ctx, "PRAGMA foreign_keys = ON"); err != nil {
+		t.Fatalf("Failed to enable foreign keys: %v", err)
+	}
+
+	_, err = db.ExecContext(ctx, "INSERT INTO user_rooms (user_id, room_id) VALUES (9999, 9999)")
+	if err := classifyError(err); !errors.As(err, &ce) || ce.Kind != ConstraintForeignKey {
+		t.Errorf("Expected foreign key violation, got %v", err)
+	}
+}
+
+func TestRepository_NotFoundErrors(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	userRepo := NewUserRepository(db, NewRoomRepository(db))
+	roomRepo := NewRoomRepository(db)
+
+	if _, err := userRepo.GetByID(ctx, 9999); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound, got %v", err)
+	}
+
+	if err := userRepo.Delete(ctx, 9999, 0); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	if _, err := roomRepo.GetRoomWithUsers(ctx, 9999); !errors.Is(err, ErrRoomNotFound) {
+		t.Errorf("Expected ErrRoomNotFound, got %v", err)
+	}
+
+	if err := roomRepo.RemoveUserFromRoom(ctx, 1, 1); !errors.Is(err, ErrNotAssigned) {
+		t.Errorf("Expected ErrNotAssigned, got %v", err)
+	}
+}
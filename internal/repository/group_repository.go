@@ -0,0 +1,375 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloudflaredb/internal/models"
+)
+
+// GroupRepository handles database operations for groups, their members and the rooms they are
+// assigned to. Every member of a group assigned to a room takes a seat in it, so adding members
+// and assigning groups check room capacities like direct assignments do. Freed seats go to the
+// rooms' waitlists through RoomRepository.
+type GroupRepository struct {
+	db    *sql.DB
+	rooms *RoomRepository
+}
+
+// NewGroupRepository creates a new group repository
+func NewGroupRepository(db *sql.DB, rooms *RoomRepository) *GroupRepository {
+	return &GroupRepository{db: db, rooms: rooms}
+}
+
+// Create inserts a new group
+func (r *GroupRepository) Create(ctx context.Context, req *models.CreateGroupRequest) (*models.Group, error) {
+	query := `
+		INSERT INTO groups (name, description, created_at, updated_at)
+		VALUES (?, ?, ?, ?)
+	`
+
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx, query, req.Name, req.Description, now, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create group: %w", classifyError(err))
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	return r.GetByID(ctx, id)
+}
+
+// GetByID retrieves a group by ID
+func (r *GroupRepository) GetByID(ctx context.Context, id int64) (*models.Group, error) {
+	groups, err := r.queryGroups(ctx, `WHERE g.id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(groups) == 0 {
+		return nil, ErrGroupNotFound
+	}
+	return groups[0], nil
+}
+
+// List retrieves all groups ordered by name
+func (r *GroupRepository) List(ctx context.Context) ([]*models.Group, error) {
+	return r.queryGroups(ctx, ``)
+}
+
+// Update updates a group's information; an empty name or a nil description is left unchanged
+func (r *GroupRepository) Update(ctx context.Context, id int64, req *models.UpdateGroupRequest) (*models.Group, error) {
+	query := `
+		UPDATE groups
+		SET name = COALESCE(NULLIF(?, ''), name),
+		    description = COALESCE(?, description),
+		    updated_at = ?
+		WHERE id = ?
+	`
+
+	var description interface{}
+	if req.Description != nil {
+		description = *req.Description
+	}
+
+	result, err := r.db.ExecContext(ctx, query, req.Name, description, time.Now(), id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update group: %w", classifyError(err))
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return nil, ErrGroupNotFound
+	}
+
+	return r.GetByID(ctx, id)
+}
+
+// Delete deletes a group, removing it from its rooms and giving the freed seats to their waitlists.
+// The room assignments and memberships are deleted before the group, so a failed deletion can be retried.
+func (r *GroupRepository) Delete(ctx context.Context, id int64) error {
+	roomIDs, err := r.groupRoomIDs(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM room_groups WHERE group_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to remove group from rooms: %w", err)
+	}
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM group_members WHERE group_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to remove group members: %w", err)
+	}
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM groups WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete group: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrGroupNotFound
+	}
+
+	return r.promoteWaitlisted(ctx, roomIDs)
+}
+
+// AddMember adds a user to a group. The user takes a seat in every room the group is assigned to,
+// so when one of them is full, or has a waitlist the user would jump, ErrRoomFull is returned.
+// The capacity checks, the check that the user exists and the insert run as a single statement.
+func (r *GroupRepository) AddMember(ctx context.Context, groupID, userID int64) (*models.GroupMember, error) {
+	if err := checkExists(ctx, r.db, "groups", groupID, ErrGroupNotFound); err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO group_members (group_id, user_id, created_at)
+		SELECT groups.id, ?, ?
+		FROM groups
+		WHERE groups.id = ?
+		  AND EXISTS (SELECT 1 FROM users WHERE id = ?)
+		  AND NOT EXISTS (
+		      SELECT 1
+		      FROM room_groups
+		      INNER JOIN rooms ON rooms.id = room_groups.room_id
+		      WHERE room_groups.group_id = groups.id
+		        AND ? NOT IN (` + roomUsersSQL + `)
+		        AND (` + roomOccupancySQL + ` >= rooms.capacity OR ` + roomWaitlistedSQL + `))
+	`
+
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx, query, userID, now, groupID, userID, userID)
+	if err != nil {
+		err = classifyError(err)
+		if errors.Is(err, ErrConflict) {
+			return nil, ErrAlreadyGroupMember
+		}
+		return nil, fmt.Errorf("failed to add group member: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		if err := checkExists(ctx, r.db, "users", userID, ErrUserNotFound); err != nil {
+			return nil, err
+		}
+		return nil, ErrRoomFull
+	}
+
+	return &models.GroupMember{GroupID: groupID, UserID: userID, CreatedAt: now}, nil
+}
+
+// RemoveMember removes a user from a group and gives the seats they leave to the waitlists of the group's rooms
+func (r *GroupRepository) RemoveMember(ctx context.Context, groupID, userID int64) error {
+	roomIDs, err := r.groupRoomIDs(ctx, groupID)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM group_members WHERE group_id = ? AND user_id = ?`, groupID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to remove group member: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotGroupMember
+	}
+
+	return r.promoteWaitlisted(ctx, roomIDs)
+}
+
+// Members retrieves the users of a group ordered by name
+func (r *GroupRepository) Members(ctx context.Context, groupID int64) ([]*models.User, error) {
+	if err := checkExists(ctx, r.db, "groups", groupID, ErrGroupNotFound); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT u.id, u.email, u.name, u.created_at, u.updated_at, u.version
+		FROM users u
+		INNER JOIN group_members gm ON gm.user_id = u.id
+		WHERE gm.group_id = ?
+		ORDER BY u.name, u.id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group members: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*models.User
+	for rows.Next() {
+		user := &models.User{}
+		if err := scanUser(rows, &user.ID, &user.Email, &user.Name, &user.CreatedAt, &user.UpdatedAt, &user.Version); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return users, nil
+}
+
+// AssignToRoom assigns a group to a room, giving a seat to each of its members not in the room yet.
+// Like AssignUserToRoom, the capacity check and the insert run as a single statement, and a room
+// with a waitlist only accepts a group that brings no new users.
+func (r *GroupRepository) AssignToRoom(ctx context.Context, roomID, groupID int64) (*models.RoomGroup, error) {
+	if err := checkExists(ctx, r.db, "rooms", roomID, ErrRoomNotFound); err != nil {
+		return nil, err
+	}
+	if err := checkExists(ctx, r.db, "groups", groupID, ErrGroupNotFound); err != nil {
+		return nil, err
+	}
+
+	// occupancyWithGroup counts the users of the room once the group is assigned
+	occupancyWithGroup := `(SELECT COUNT(*) FROM (` + roomUsersSQL + `
+		UNION
+		SELECT user_id FROM group_members WHERE group_id = ?))`
+
+	query := `
+		INSERT INTO room_groups (room_id, group_id, created_at)
+		SELECT rooms.id, ?, ?
+		FROM rooms
+		WHERE rooms.id = ?
+		  AND (` + occupancyWithGroup + ` = ` + roomOccupancySQL + `
+		       OR (` + occupancyWithGroup + ` <= rooms.capacity AND NOT ` + roomWaitlistedSQL + `))
+	`
+
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx, query, groupID, now, roomID, groupID, groupID)
+	if err != nil {
+		err = classifyError(err)
+		if errors.Is(err, ErrConflict) {
+			return nil, ErrGroupAlreadyAssigned
+		}
+		return nil, fmt.Errorf("failed to assign group to room: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return nil, ErrRoomFull
+	}
+
+	return &models.RoomGroup{RoomID: roomID, GroupID: groupID, CreatedAt: now}, nil
+}
+
+// RemoveFromRoom removes a group from a room and gives the freed seats to the room's waitlist.
+// Members who are also assigned directly or through another group keep their seat.
+func (r *GroupRepository) RemoveFromRoom(ctx context.Context, roomID, groupID int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM room_groups WHERE room_id = ? AND group_id = ?`, roomID, groupID)
+	if err != nil {
+		return fmt.Errorf("failed to remove group from room: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrGroupNotAssigned
+	}
+
+	return r.promoteWaitlisted(ctx, []int64{roomID})
+}
+
+// RoomGroups retrieves the groups assigned to a room ordered by name
+func (r *GroupRepository) RoomGroups(ctx context.Context, roomID int64) ([]*models.Group, error) {
+	if err := checkExists(ctx, r.db, "rooms", roomID, ErrRoomNotFound); err != nil {
+		return nil, err
+	}
+	return r.queryGroups(ctx, `INNER JOIN room_groups rg ON rg.group_id = g.id WHERE rg.room_id = ?`, roomID)
+}
+
+// groupRoomIDs returns the IDs of the rooms a group is assigned to
+func (r *GroupRepository) groupRoomIDs(ctx context.Context, groupID int64) ([]int64, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT room_id FROM room_groups WHERE group_id = ? ORDER BY room_id`, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query group rooms: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan room id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return ids, nil
+}
+
+// promoteWaitlisted fills the free seats of rooms from their waitlists
+func (r *GroupRepository) promoteWaitlisted(ctx context.Context, roomIDs []int64) error {
+	for _, roomID := range roomIDs {
+		if _, err := r.rooms.promoteWaitlisted(ctx, roomID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// queryGroups runs a SELECT over groups, aliased g, with the given joins and WHERE clause
+func (r *GroupRepository) queryGroups(ctx context.Context, where string, args ...interface{}) ([]*models.Group, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT g.* FROM groups g `+where+` ORDER BY g.name, g.id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query groups: %w", err)
+	}
+	defer rows.Close()
+
+	var groups []*models.Group
+	for rows.Next() {
+		v, err := scanValues(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan group: %w", err)
+		}
+
+		groups = append(groups, &models.Group{
+			ID:          toInt64(v["id"]),
+			Name:        toString(v["name"]),
+			Description: toString(v["description"]),
+			CreatedAt:   parseTimeValue(v["created_at"]),
+			UpdatedAt:   parseTimeValue(v["updated_at"]),
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return groups, nil
+}
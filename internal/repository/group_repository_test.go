@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"cloudflaredb/internal/models"
+)
+
+func TestGroupRepository_RoomAssignment(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	rooms := NewRoomRepository(db)
+	userRepo := NewUserRepository(db, rooms)
+	repo := NewGroupRepository(db, rooms)
+	ctx := context.Background()
+
+	var userIDs []int64
+	for i := 0; i < 5; i++ {
+		user, err := userRepo.Create(ctx, &models.CreateUserRequest{
+			Email: fmt.Sprintf("member%d@example.com", i),
+			Name:  fmt.Sprintf("Member %d", i),
+		})
+		if err != nil {
+			t.Fatalf("Failed to create test user: %v", err)
+		}
+		userIDs = append(userIDs, user.ID)
+	}
+
+	team, err := repo.Create(ctx, &models.CreateGroupRequest{Name: "Platform"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := repo.Create(ctx, &models.CreateGroupRequest{Name: "Platform"}); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict for a duplicate name, got %v", err)
+	}
+
+	for _, id := range userIDs[:3] {
+		if _, err := repo.AddMember(ctx, team.ID, id); err != nil {
+			t.Fatalf("AddMember() error = %v", err)
+		}
+	}
+	if _, err := repo.AddMember(ctx, team.ID, userIDs[0]); !errors.Is(err, ErrAlreadyGroupMember) {
+		t.Errorf("Expected ErrAlreadyGroupMember, got %v", err)
+	}
+	if _, err := repo.AddMember(ctx, team.ID, 9999); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound, got %v", err)
+	}
+
+	room, err := rooms.Create(ctx, &models.CreateRoomRequest{Name: "Project Room", Capacity: 3})
+	if err != nil {
+		t.Fatalf("Failed to create test room: %v", err)
+	}
+	small, err := rooms.Create(ctx, &models.CreateRoomRequest{Name: "Phone Booth", Capacity: 2})
+	if err != nil {
+		t.Fatalf("Failed to create test room: %v", err)
+	}
+
+	// A user assigned directly and through the group takes a single seat
+	if _, err := rooms.AssignUserToRoom(ctx, userIDs[0], room.ID, ""); err != nil {
+		t.Fatalf("AssignUserToRoom() error = %v", err)
+	}
+	if _, err := repo.AssignToRoom(ctx, small.ID, team.ID); !errors.Is(err, ErrRoomFull) {
+		t.Errorf("Expected ErrRoomFull for a group larger than the room, got %v", err)
+	}
+	if _, err := repo.AssignToRoom(ctx, room.ID, team.ID); err != nil {
+		t.Fatalf("AssignToRoom() error = %v", err)
+	}
+	if _, err := repo.AssignToRoom(ctx, room.ID, team.ID); !errors.Is(err, ErrGroupAlreadyAssigned) {
+		t.Errorf("Expected ErrGroupAlreadyAssigned, got %v", err)
+	}
+
+	available, err := rooms.Available(ctx, 1, 10, RoomFilter{})
+	if err != nil {
+		t.Fatalf("Available() error = %v", err)
+	}
+	if len(available) != 1 || available[0].ID != small.ID {
+		t.Errorf("Expected only the empty room to have seats, got %+v", available)
+	}
+
+	// The room is full: neither new group members nor direct assignments fit
+	if _, err := repo.AddMember(ctx, team.ID, userIDs[3]); !errors.Is(err, ErrRoomFull) {
+		t.Errorf("Expected ErrRoomFull when adding a member, got %v", err)
+	}
+	if _, err := rooms.AssignUserToRoom(ctx, userIDs[3], room.ID, ""); !errors.Is(err, ErrRoomFull) {
+		t.Errorf("Expected ErrRoomFull, got %v", err)
+	}
+
+	// Group members reaching the room can still get a role in it
+	if _, err := rooms.AssignUserToRoom(ctx, userIDs[1], room.ID, models.RoleModerator); err != nil {
+		t.Errorf("Expected a group member to be assigned directly, got %v", err)
+	}
+
+	userRooms, err := rooms.GetUserRooms(ctx, userIDs[2])
+	if err != nil || len(userRooms) != 1 || userRooms[0].ID != room.ID {
+		t.Errorf("Expected the room reached through the group, got %+v (%v)", userRooms, err)
+	}
+
+	// The room lists every user once, with how they got in
+	withGroup, err := rooms.GetRoomWithUsers(ctx, room.ID)
+	if err != nil {
+		t.Fatalf("GetRoomWithUsers() error = %v", err)
+	}
+	want := []models.RoomMember{
+		{Role: models.RoleOwner, Direct: true, GroupIDs: []int64{team.ID}},
+		{Role: models.RoleModerator, Direct: true, GroupIDs: []int64{team.ID}},
+		{GroupIDs: []int64{team.ID}},
+	}
+	if len(withGroup.Users) != len(want) {
+		t.Fatalf("Expected %d users, got %+v", len(want), withGroup.Users)
+	}
+	for i, u := range withGroup.Users {
+		if u.ID != userIDs[i] || u.Role != want[i].Role || u.Direct != want[i].Direct || !slices.Equal(u.GroupIDs, want[i].GroupIDs) {
+			t.Errorf("Expected user %d with %+v, got %+v", userIDs[i], want[i], u)
+		}
+	}
+
+	// A freed seat goes to the waitlist
+	if _, err := rooms.JoinWaitlist(ctx, room.ID, userIDs[4]); err != nil {
+		t.Fatalf("JoinWaitlist() error = %v", err)
+	}
+	if err := repo.RemoveMember(ctx, team.ID, userIDs[2]); err != nil {
+		t.Fatalf("RemoveMember() error = %v", err)
+	}
+	if _, err := rooms.GetMembership(ctx, room.ID, userIDs[4]); err != nil {
+		t.Errorf("Expected the waitlisted user to be promoted, got %v", err)
+	}
+	if err := repo.RemoveMember(ctx, team.ID, userIDs[2]); !errors.Is(err, ErrNotGroupMember) {
+		t.Errorf("Expected ErrNotGroupMember, got %v", err)
+	}
+
+	members, err := repo.Members(ctx, team.ID)
+	if err != nil || len(members) != 2 {
+		t.Errorf("Expected 2 members, got %+v (%v)", members, err)
+	}
+
+	// Deleting the group keeps direct assignments only
+	if err := repo.Delete(ctx, team.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	var ids []int64
+	withUsers, err := rooms.GetRoomWithUsers(ctx, room.ID)
+	if err != nil {
+		t.Fatalf("GetRoomWithUsers() error = %v", err)
+	}
+	for _, u := range withUsers.Users {
+		ids = append(ids, u.ID)
+	}
+	slices.Sort(ids)
+	if want := []int64{userIDs[0], userIDs[1], userIDs[4]}; !slices.Equal(ids, want) {
+		t.Errorf("Expected users %v, got %v", want, ids)
+	}
+	if _, err := repo.GetByID(ctx, team.ID); !errors.Is(err, ErrGroupNotFound) {
+		t.Errorf("Expected ErrGroupNotFound, got %v", err)
+	}
+}
+
+func TestGroupRepository_Update(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	repo := NewGroupRepository(db, NewRoomRepository(db))
+	ctx := context.Background()
+
+	group, err := repo.Create(ctx, &models.CreateGroupRequest{Name: "Platform", Description: "Infrastructure team"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// A missing description is kept
+	updated, err := repo.Update(ctx, group.ID, &models.UpdateGroupRequest{Name: "Core"})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if updated.Name != "Core" || updated.Description != "Infrastructure team" {
+		t.Errorf("Expected the description to be kept, got %+v", updated)
+	}
+
+	// An empty description clears it
+	empty := ""
+	updated, err = repo.Update(ctx, group.ID, &models.UpdateGroupRequest{Description: &empty})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if updated.Name != "Core" || updated.Description != "" {
+		t.Errorf("Expected the description to be cleared, got %+v", updated)
+	}
+
+	if _, err := repo.Update(ctx, 9999, &models.UpdateGroupRequest{Name: "Ghost"}); !errors.Is(err, ErrGroupNotFound) {
+		t.Errorf("Expected ErrGroupNotFound, got %v", err)
+	}
+}
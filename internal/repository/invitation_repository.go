@@ -0,0 +1,347 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"cloudflaredb/internal/models"
+)
+
+// invitationColumns are the columns read for an invitation; the token hash is never returned
+const invitationColumns = `id, room_id, invited_by, user_id, email, role, status, expires_at, created_at, responded_at`
+
+// InvitationRepository handles database operations for room invitations.
+// Accepted invitations become memberships through RoomRepository, so they respect capacity,
+// waitlists and roles like any other assignment.
+type InvitationRepository struct {
+	db    *sql.DB
+	rooms *RoomRepository
+}
+
+// NewInvitationRepository creates a new invitation repository
+func NewInvitationRepository(db *sql.DB, rooms *RoomRepository) *InvitationRepository {
+	return &InvitationRepository{db: db, rooms: rooms}
+}
+
+// Create stores an invitation from one of the room's owners. The returned invitation carries the
+// plain token, which is not stored and cannot be retrieved again.
+func (r *InvitationRepository) Create(ctx context.Context, roomID int64, req *models.CreateInvitationRequest) (*models.Invitation, error) {
+	if err := checkExists(ctx, r.db, "rooms", roomID, ErrRoomNotFound); err != nil {
+		return nil, err
+	}
+	if err := requireRole(ctx, r.rooms, roomID, req.InvitedBy, ErrNotRoomOwner, models.RoleOwner); err != nil {
+		return nil, err
+	}
+
+	var userID interface{}
+	var email interface{}
+	if req.UserID != 0 {
+		if err := checkExists(ctx, r.db, "users", req.UserID, ErrUserNotFound); err != nil {
+			return nil, err
+		}
+		if _, err := r.rooms.GetMembership(ctx, roomID, req.UserID); err == nil {
+			return nil, ErrAlreadyAssigned
+		} else if !errors.Is(err, ErrNotAssigned) {
+			return nil, err
+		}
+		userID = req.UserID
+	} else {
+		email = normalizeEmail(req.Email)
+	}
+
+	var expiresAt interface{}
+	if req.ExpiresAt != nil {
+		expiresAt = formatBookingTime(*req.ExpiresAt)
+	}
+
+	token, err := newInvitationToken()
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO room_invitations (room_id, invited_by, user_id, email, role, token_hash, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, roomID, req.InvitedBy, userID, email,
+		cmp.Or(req.Role, models.RoleMember), hashInvitationToken(token), expiresAt, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create invitation: %w", classifyError(err))
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	inv, err := r.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	inv.Token = token
+	return inv, nil
+}
+
+// GetByID retrieves an invitation by ID
+func (r *InvitationRepository) GetByID(ctx context.Context, id int64) (*models.Invitation, error) {
+	invitations, err := r.queryInvitations(ctx, `id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(invitations) == 0 {
+		return nil, ErrInvitationNotFound
+	}
+	return invitations[0], nil
+}
+
+// ListByRoom retrieves the invitations of a room, oldest first
+func (r *InvitationRepository) ListByRoom(ctx context.Context, roomID int64) ([]*models.Invitation, error) {
+	if err := checkExists(ctx, r.db, "rooms", roomID, ErrRoomNotFound); err != nil {
+		return nil, err
+	}
+	return r.queryInvitations(ctx, `room_id = ?`, roomID)
+}
+
+// ListForUser retrieves the invitations addressed to a user, by user ID or by their email, oldest first
+func (r *InvitationRepository) ListForUser(ctx context.Context, userID int64) ([]*models.Invitation, error) {
+	if err := checkExists(ctx, r.db, "users", userID, ErrUserNotFound); err != nil {
+		return nil, err
+	}
+	return r.queryInvitations(ctx, `user_id = ? OR email = (SELECT LOWER(email) FROM users WHERE id = ?)`, userID, userID)
+}
+
+// Accept accepts an invitation on behalf of the user it is addressed to and assigns them to the
+// room with the invitation's role
+func (r *InvitationRepository) Accept(ctx context.Context, id, userID int64) (*models.UserRoom, error) {
+	inv, err := r.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.checkInvitee(ctx, inv, userID); err != nil {
+		return nil, err
+	}
+	return r.accept(ctx, inv, userID)
+}
+
+// AcceptByToken accepts the invitation a token was issued for. The token proves the invitation was
+// received, so any existing user holding it may accept.
+func (r *InvitationRepository) AcceptByToken(ctx context.Context, token string, userID int64) (*models.UserRoom, error) {
+	invitations, err := r.queryInvitations(ctx, `token_hash = ?`, hashInvitationToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if len(invitations) == 0 {
+		return nil, ErrInvitationNotFound
+	}
+	if err := checkExists(ctx, r.db, "users", userID, ErrUserNotFound); err != nil {
+		return nil, err
+	}
+	return r.accept(ctx, invitations[0], userID)
+}
+
+// Decline declines an invitation on behalf of the user it is addressed to
+func (r *InvitationRepository) Decline(ctx context.Context, id, userID int64) (*models.Invitation, error) {
+	inv, err := r.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.checkInvitee(ctx, inv, userID); err != nil {
+		return nil, err
+	}
+	if err := r.respond(ctx, inv.ID, models.InvitationStatusDeclined); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+// accept marks the invitation accepted and assigns the user. The two steps are separate statements,
+// since D1 has no interactive transactions; when the assignment fails, e.g. because the room is
+// full, the invitation is reopened so it can be accepted later.
+func (r *InvitationRepository) accept(ctx context.Context, inv *models.Invitation, userID int64) (*models.UserRoom, error) {
+	if err := r.respond(ctx, inv.ID, models.InvitationStatusAccepted); err != nil {
+		return nil, err
+	}
+
+	membership, err := r.rooms.AssignUserToRoom(ctx, userID, inv.RoomID, inv.Role)
+	if err != nil {
+		reopenQuery := `UPDATE room_invitations SET status = 'pending', responded_at = NULL WHERE id = ?`
+		if _, reopenErr := r.db.ExecContext(ctx, reopenQuery, inv.ID); reopenErr != nil {
+			return nil, errors.Join(err, fmt.Errorf("failed to reopen invitation: %w", reopenErr))
+		}
+		return nil, err
+	}
+
+	return membership, nil
+}
+
+// respond moves a pending, unexpired invitation to status in a single guarded statement,
+// so an invitation can only be answered once
+func (r *InvitationRepository) respond(ctx context.Context, id int64, status string) error {
+	query := `
+		UPDATE room_invitations
+		SET status = ?, responded_at = ?
+		WHERE id = ? AND status = 'pending'
+		  AND (expires_at IS NULL OR expires_at > ?)
+	`
+
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx, query, status, now, id, formatBookingTime(now))
+	if err != nil {
+		return fmt.Errorf("failed to respond to invitation: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected > 0 {
+		return nil
+	}
+
+	// Nothing was updated: find out why
+	inv, err := r.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if inv.Status == models.InvitationStatusExpired {
+		return ErrInvitationExpired
+	}
+	return ErrInvitationClosed
+}
+
+// checkInvitee returns ErrNotInvitee unless the invitation is addressed to the user's ID or email
+func (r *InvitationRepository) checkInvitee(ctx context.Context, inv *models.Invitation, userID int64) error {
+	if inv.UserID != nil {
+		if *inv.UserID != userID {
+			return ErrNotInvitee
+		}
+		return nil
+	}
+
+	var email string
+	err := r.db.QueryRowContext(ctx, `SELECT email FROM users WHERE id = ?`, userID).Scan(&email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user email: %w", err)
+	}
+
+	if normalizeEmail(email) != inv.Email {
+		return ErrNotInvitee
+	}
+	return nil
+}
+
+// queryInvitations runs a SELECT over room_invitations with the given WHERE condition
+func (r *InvitationRepository) queryInvitations(ctx context.Context, condition string, args ...interface{}) ([]*models.Invitation, error) {
+	query := `SELECT ` + invitationColumns + ` FROM room_invitations WHERE ` + condition + ` ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query invitations: %w", err)
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	var invitations []*models.Invitation
+	for rows.Next() {
+		v, err := scanValues(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan invitation: %w", err)
+		}
+
+		inv := &models.Invitation{
+			ID:          toInt64(v["id"]),
+			RoomID:      toInt64(v["room_id"]),
+			InvitedBy:   toInt64(v["invited_by"]),
+			UserID:      optionalInt64(v["user_id"]),
+			Email:       toString(v["email"]),
+			Role:        toString(v["role"]),
+			Status:      toString(v["status"]),
+			ExpiresAt:   optionalTime(v["expires_at"]),
+			CreatedAt:   parseTimeValue(v["created_at"]),
+			RespondedAt: optionalTime(v["responded_at"]),
+		}
+		if inv.Status == models.InvitationStatusPending && inv.ExpiresAt != nil && !inv.ExpiresAt.After(now) {
+			inv.Status = models.InvitationStatusExpired
+		}
+		invitations = append(invitations, inv)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return invitations, nil
+}
+
+// requireRole returns denied unless the user is a member of the room with one of roles
+func requireRole(ctx context.Context, rooms *RoomRepository, roomID, userID int64, denied error, roles ...string) error {
+	membership, err := rooms.GetMembership(ctx, roomID, userID)
+	if errors.Is(err, ErrNotAssigned) {
+		return denied
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, role := range roles {
+		if membership.Role == role {
+			return nil
+		}
+	}
+	return denied
+}
+
+// newInvitationToken returns a random URL-safe token
+func newInvitationToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate invitation token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashInvitationToken returns the stored form of a token, so a database leak does not leak usable tokens
+func hashInvitationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeEmail returns the form emails are compared in
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// optionalInt64 converts a nullable integer column value
+func optionalInt64(val interface{}) *int64 {
+	if val == nil {
+		return nil
+	}
+	v := toInt64(val)
+	return &v
+}
+
+// optionalTime converts a nullable time column value
+func optionalTime(val interface{}) *time.Time {
+	if val == nil {
+		return nil
+	}
+	t := parseTimeValue(val)
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
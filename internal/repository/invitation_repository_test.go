@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"cloudflaredb/internal/models"
+)
+
+// seedMembershipData creates an owned room with the given capacity and returns the room, its owner and two outsiders
+func seedMembershipData(t *testing.T, rooms *RoomRepository, users *UserRepository, capacity int) (room *models.Room, owner, alice, bob *models.User) {
+	t.Helper()
+	ctx := context.Background()
+
+	var err error
+	if room, err = rooms.Create(ctx, &models.CreateRoomRequest{Name: "Project Room", Capacity: capacity}); err != nil {
+		t.Fatalf("Failed to create test room: %v", err)
+	}
+
+	created := make([]*models.User, 3)
+	for i, email := range []string{"owner@example.com", "Alice@Example.com", "bob@example.com"} {
+		if created[i], err = users.Create(ctx, &models.CreateUserRequest{Email: email, Name: email}); err != nil {
+			t.Fatalf("Failed to create test user: %v", err)
+		}
+	}
+
+	if _, err := rooms.AssignUserToRoom(ctx, created[0].ID, room.ID, ""); err != nil {
+		t.Fatalf("Failed to assign owner: %v", err)
+	}
+
+	return room, created[0], created[1], created[2]
+}
+
+func TestInvitationRepository_InviteByUserID(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	rooms := NewRoomRepository(db)
+	repo := NewInvitationRepository(db, rooms)
+	ctx := context.Background()
+	room, owner, alice, bob := seedMembershipData(t, rooms, NewUserRepository(db, rooms), 5)
+
+	inv, err := repo.Create(ctx, room.ID, &models.CreateInvitationRequest{
+		InvitedBy: owner.ID,
+		UserID:    alice.ID,
+		Role:      models.RoleModerator,
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if inv.Token == "" || inv.Status != models.InvitationStatusPending || inv.Role != models.RoleModerator {
+		t.Errorf("Unexpected invitation: %+v", inv)
+	}
+
+	// Only owners can invite
+	if _, err := repo.Create(ctx, room.ID, &models.CreateInvitationRequest{InvitedBy: bob.ID, UserID: alice.ID}); !errors.Is(err, ErrNotRoomOwner) {
+		t.Errorf("Expected ErrNotRoomOwner, got %v", err)
+	}
+	if _, err := repo.Create(ctx, room.ID, &models.CreateInvitationRequest{InvitedBy: owner.ID, UserID: owner.ID}); !errors.Is(err, ErrAlreadyAssigned) {
+		t.Errorf("Expected ErrAlreadyAssigned for a member, got %v", err)
+	}
+
+	// Only the invitee can respond
+	if _, err := repo.Accept(ctx, inv.ID, bob.ID); !errors.Is(err, ErrNotInvitee) {
+		t.Errorf("Expected ErrNotInvitee, got %v", err)
+	}
+
+	membership, err := repo.Accept(ctx, inv.ID, alice.ID)
+	if err != nil {
+		t.Fatalf("Accept() error = %v", err)
+	}
+	if membership.UserID != alice.ID || membership.Role != models.RoleModerator {
+		t.Errorf("Unexpected membership: %+v", membership)
+	}
+
+	if _, err := repo.Decline(ctx, inv.ID, alice.ID); !errors.Is(err, ErrInvitationClosed) {
+		t.Errorf("Expected ErrInvitationClosed, got %v", err)
+	}
+
+	got, err := repo.GetByID(ctx, inv.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if got.Status != models.InvitationStatusAccepted || got.RespondedAt == nil || got.Token != "" {
+		t.Errorf("Unexpected invitation after accepting: %+v", got)
+	}
+}
+
+func TestInvitationRepository_InviteByEmail(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	rooms := NewRoomRepository(db)
+	repo := NewInvitationRepository(db, rooms)
+	ctx := context.Background()
+	room, owner, alice, bob := seedMembershipData(t, rooms, NewUserRepository(db, rooms), 5)
+
+	inv, err := repo.Create(ctx, room.ID, &models.CreateInvitationRequest{InvitedBy: owner.ID, Email: " alice@example.COM"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if inv.Email != "alice@example.com" || inv.UserID != nil || inv.Role != models.RoleMember {
+		t.Errorf("Unexpected invitation: %+v", inv)
+	}
+
+	// Emails match case-insensitively
+	invitations, err := repo.ListForUser(ctx, alice.ID)
+	if err != nil {
+		t.Fatalf("ListForUser() error = %v", err)
+	}
+	if len(invitations) != 1 || invitations[0].ID != inv.ID {
+		t.Errorf("Expected the invitation to be listed for alice, got %+v", invitations)
+	}
+
+	if _, err := repo.Decline(ctx, inv.ID, bob.ID); !errors.Is(err, ErrNotInvitee) {
+		t.Errorf("Expected ErrNotInvitee, got %v", err)
+	}
+
+	declined, err := repo.Decline(ctx, inv.ID, alice.ID)
+	if err != nil {
+		t.Fatalf("Decline() error = %v", err)
+	}
+	if declined.Status != models.InvitationStatusDeclined {
+		t.Errorf("Expected a declined invitation, got %+v", declined)
+	}
+	if _, err := repo.Accept(ctx, inv.ID, alice.ID); !errors.Is(err, ErrInvitationClosed) {
+		t.Errorf("Expected ErrInvitationClosed, got %v", err)
+	}
+}
+
+func TestInvitationRepository_AcceptByToken(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	rooms := NewRoomRepository(db)
+	repo := NewInvitationRepository(db, rooms)
+	ctx := context.Background()
+	room, owner, alice, bob := seedMembershipData(t, rooms, NewUserRepository(db, rooms), 2)
+
+	expired := time.Now().Add(-time.Minute)
+	old, err := repo.Create(ctx, room.ID, &models.CreateInvitationRequest{InvitedBy: owner.ID, UserID: alice.ID, ExpiresAt: &expired})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if old.Status != models.InvitationStatusExpired {
+		t.Errorf("Expected an expired invitation, got %+v", old)
+	}
+	if _, err := repo.AcceptByToken(ctx, old.Token, alice.ID); !errors.Is(err, ErrInvitationExpired) {
+		t.Errorf("Expected ErrInvitationExpired, got %v", err)
+	}
+
+	later := time.Now().Add(time.Hour)
+	inv, err := repo.Create(ctx, room.ID, &models.CreateInvitationRequest{InvitedBy: owner.ID, Email: "someone@example.com", ExpiresAt: &later})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	if _, err := repo.AcceptByToken(ctx, "not-a-token", bob.ID); !errors.Is(err, ErrInvitationNotFound) {
+		t.Errorf("Expected ErrInvitationNotFound, got %v", err)
+	}
+
+	// Filling the room keeps the invitation open for a later attempt
+	if _, err := rooms.AssignUserToRoom(ctx, alice.ID, room.ID, ""); err != nil {
+		t.Fatalf("Failed to fill the room: %v", err)
+	}
+	if _, err := repo.AcceptByToken(ctx, inv.Token, bob.ID); !errors.Is(err, ErrRoomFull) {
+		t.Errorf("Expected ErrRoomFull, got %v", err)
+	}
+	if got, _ := repo.GetByID(ctx, inv.ID); got.Status != models.InvitationStatusPending {
+		t.Errorf("Expected the invitation to be reopened, got %+v", got)
+	}
+
+	if err := rooms.RemoveUserFromRoom(ctx, alice.ID, room.ID); err != nil {
+		t.Fatalf("Failed to free a seat: %v", err)
+	}
+	if _, err := repo.AcceptByToken(ctx, inv.Token, bob.ID); err != nil {
+		t.Errorf("AcceptByToken() error = %v", err)
+	}
+}
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloudflaredb/internal/models"
+)
+
+// JoinRequestRepository handles database operations for requests to join rooms.
+// Approved requests become memberships through RoomRepository.
+type JoinRequestRepository struct {
+	db    *sql.DB
+	rooms *RoomRepository
+}
+
+// NewJoinRequestRepository creates a new join request repository
+func NewJoinRequestRepository(db *sql.DB, rooms *RoomRepository) *JoinRequestRepository {
+	return &JoinRequestRepository{db: db, rooms: rooms}
+}
+
+// Create stores a user's request to join a room
+func (r *JoinRequestRepository) Create(ctx context.Context, roomID int64, req *models.CreateJoinRequestRequest) (*models.JoinRequest, error) {
+	if err := checkExists(ctx, r.db, "rooms", roomID, ErrRoomNotFound); err != nil {
+		return nil, err
+	}
+	if err := checkExists(ctx, r.db, "users", req.UserID, ErrUserNotFound); err != nil {
+		return nil, err
+	}
+	if _, err := r.rooms.GetMembership(ctx, roomID, req.UserID); err == nil {
+		return nil, ErrAlreadyAssigned
+	} else if !errors.Is(err, ErrNotAssigned) {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO room_join_requests (room_id, user_id, message, created_at)
+		VALUES (?, ?, ?, ?)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, roomID, req.UserID, req.Message, time.Now())
+	if err != nil {
+		err = classifyError(err)
+		if errors.Is(err, ErrConflict) {
+			return nil, ErrJoinRequestPending
+		}
+		return nil, fmt.Errorf("failed to create join request: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	return r.GetByID(ctx, id)
+}
+
+// GetByID retrieves a join request by ID
+func (r *JoinRequestRepository) GetByID(ctx context.Context, id int64) (*models.JoinRequest, error) {
+	requests, err := r.queryJoinRequests(ctx, `id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(requests) == 0 {
+		return nil, ErrJoinRequestNotFound
+	}
+	return requests[0], nil
+}
+
+// ListByRoom retrieves the join requests of a room, oldest first. An empty status returns all of them.
+func (r *JoinRequestRepository) ListByRoom(ctx context.Context, roomID int64, status string) ([]*models.JoinRequest, error) {
+	if err := checkExists(ctx, r.db, "rooms", roomID, ErrRoomNotFound); err != nil {
+		return nil, err
+	}
+	if status == "" {
+		return r.queryJoinRequests(ctx, `room_id = ?`, roomID)
+	}
+	return r.queryJoinRequests(ctx, `room_id = ? AND status = ?`, roomID, status)
+}
+
+// Approve approves a pending join request on behalf of an owner or moderator of the room and
+// assigns the user as a member. The review and the assignment are separate statements, since D1
+// has no interactive transactions; when the assignment fails, e.g. because the room is full,
+// the request is reopened.
+func (r *JoinRequestRepository) Approve(ctx context.Context, id, reviewerID int64) (*models.UserRoom, error) {
+	req, err := r.review(ctx, id, reviewerID, models.JoinRequestStatusApproved)
+	if err != nil {
+		return nil, err
+	}
+
+	membership, err := r.rooms.AssignUserToRoom(ctx, req.UserID, req.RoomID, models.RoleMember)
+	if err != nil {
+		reopenQuery := `UPDATE room_join_requests SET status = 'pending', reviewed_by = NULL, reviewed_at = NULL WHERE id = ?`
+		if _, reopenErr := r.db.ExecContext(ctx, reopenQuery, id); reopenErr != nil {
+			return nil, errors.Join(err, fmt.Errorf("failed to reopen join request: %w", reopenErr))
+		}
+		return nil, err
+	}
+
+	return membership, nil
+}
+
+// Reject rejects a pending join request on behalf of an owner or moderator of the room
+func (r *JoinRequestRepository) Reject(ctx context.Context, id, reviewerID int64) (*models.JoinRequest, error) {
+	if _, err := r.review(ctx, id, reviewerID, models.JoinRequestStatusRejected); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+// review checks the reviewer's role and moves a pending request to status in a single guarded
+// statement, so a request can only be reviewed once
+func (r *JoinRequestRepository) review(ctx context.Context, id, reviewerID int64, status string) (*models.JoinRequest, error) {
+	req, err := r.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireRole(ctx, r.rooms, req.RoomID, reviewerID, ErrNotRoomModerator, models.RoleOwner, models.RoleModerator); err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE room_join_requests
+		SET status = ?, reviewed_by = ?, reviewed_at = ?
+		WHERE id = ? AND status = 'pending'
+	`
+
+	result, err := r.db.ExecContext(ctx, query, status, reviewerID, time.Now(), id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to review join request: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return nil, ErrJoinRequestClosed
+	}
+
+	return req, nil
+}
+
+// queryJoinRequests runs a SELECT over room_join_requests with the given WHERE condition
+func (r *JoinRequestRepository) queryJoinRequests(ctx context.Context, condition string, args ...interface{}) ([]*models.JoinRequest, error) {
+	query := `
+		SELECT id, room_id, user_id, message, status, reviewed_by, created_at, reviewed_at
+		FROM room_join_requests
+		WHERE ` + condition + `
+		ORDER BY id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query join requests: %w", err)
+	}
+	defer rows.Close()
+
+	var requests []*models.JoinRequest
+	for rows.Next() {
+		v, err := scanValues(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan join request: %w", err)
+		}
+
+		requests = append(requests, &models.JoinRequest{
+			ID:         toInt64(v["id"]),
+			RoomID:     toInt64(v["room_id"]),
+			UserID:     toInt64(v["user_id"]),
+			Message:    toString(v["message"]),
+			Status:     toString(v["status"]),
+			ReviewedBy: optionalInt64(v["reviewed_by"]),
+			CreatedAt:  parseTimeValue(v["created_at"]),
+			ReviewedAt: optionalTime(v["reviewed_at"]),
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return requests, nil
+}
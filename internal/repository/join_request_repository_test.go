@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"cloudflaredb/internal/models"
+)
+
+func TestJoinRequestRepository_ApproveAndReject(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	rooms := NewRoomRepository(db)
+	repo := NewJoinRequestRepository(db, rooms)
+	ctx := context.Background()
+	room, owner, alice, bob := seedMembershipData(t, rooms, NewUserRepository(db, rooms), 5)
+
+	req, err := repo.Create(ctx, room.ID, &models.CreateJoinRequestRequest{UserID: alice.ID, Message: "Let me in"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if req.Status != models.JoinRequestStatusPending || req.Message != "Let me in" {
+		t.Errorf("Unexpected join request: %+v", req)
+	}
+
+	if _, err := repo.Create(ctx, room.ID, &models.CreateJoinRequestRequest{UserID: alice.ID}); !errors.Is(err, ErrJoinRequestPending) {
+		t.Errorf("Expected ErrJoinRequestPending, got %v", err)
+	}
+	if _, err := repo.Create(ctx, room.ID, &models.CreateJoinRequestRequest{UserID: owner.ID}); !errors.Is(err, ErrAlreadyAssigned) {
+		t.Errorf("Expected ErrAlreadyAssigned for a member, got %v", err)
+	}
+
+	// Outsiders cannot review
+	if _, err := repo.Approve(ctx, req.ID, bob.ID); !errors.Is(err, ErrNotRoomModerator) {
+		t.Errorf("Expected ErrNotRoomModerator, got %v", err)
+	}
+
+	membership, err := repo.Approve(ctx, req.ID, owner.ID)
+	if err != nil {
+		t.Fatalf("Approve() error = %v", err)
+	}
+	if membership.UserID != alice.ID || membership.Role != models.RoleMember {
+		t.Errorf("Unexpected membership: %+v", membership)
+	}
+	if _, err := repo.Reject(ctx, req.ID, owner.ID); !errors.Is(err, ErrJoinRequestClosed) {
+		t.Errorf("Expected ErrJoinRequestClosed, got %v", err)
+	}
+
+	// Moderators review too
+	if _, err := rooms.UpdateMembershipRole(ctx, room.ID, alice.ID, models.RoleModerator); err != nil {
+		t.Fatalf("Failed to promote alice: %v", err)
+	}
+	other, err := repo.Create(ctx, room.ID, &models.CreateJoinRequestRequest{UserID: bob.ID})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	rejected, err := repo.Reject(ctx, other.ID, alice.ID)
+	if err != nil {
+		t.Fatalf("Reject() error = %v", err)
+	}
+	if rejected.Status != models.JoinRequestStatusRejected || rejected.ReviewedBy == nil || *rejected.ReviewedBy != alice.ID {
+		t.Errorf("Unexpected rejected request: %+v", rejected)
+	}
+
+	// A rejected user may ask again
+	if _, err := repo.Create(ctx, room.ID, &models.CreateJoinRequestRequest{UserID: bob.ID}); err != nil {
+		t.Errorf("Expected a new request after rejection, got %v", err)
+	}
+
+	pending, err := repo.ListByRoom(ctx, room.ID, models.JoinRequestStatusPending)
+	if err != nil {
+		t.Fatalf("ListByRoom() error = %v", err)
+	}
+	if len(pending) != 1 || pending[0].UserID != bob.ID {
+		t.Errorf("Expected bob's new request to be pending, got %+v", pending)
+	}
+}
@@ -0,0 +1,376 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"cloudflaredb/internal/models"
+)
+
+// Statements detaching the rooms of a floor, building or site before it is deleted.
+// rooms.floor_id has no foreign key (see migration 011), so the repository clears it and bumps the
+// room versions.
+const (
+	unplaceFloorRoomsSQL    = `UPDATE rooms SET floor_id = NULL, version = version + 1 WHERE floor_id = ?`
+	unplaceBuildingRoomsSQL = `UPDATE rooms SET floor_id = NULL, version = version + 1 WHERE floor_id IN (SELECT id FROM floors WHERE building_id = ?)`
+	unplaceSiteRoomsSQL     = `UPDATE rooms SET floor_id = NULL, version = version + 1 WHERE floor_id IN (
+		SELECT f.id FROM floors f INNER JOIN buildings b ON b.id = f.building_id WHERE b.site_id = ?)`
+)
+
+// LocationRepository handles database operations for the site, building and floor hierarchy.
+// Deleting a location deletes everything below it; rooms are kept but lose their floor.
+// Deletions run child-first as separate statements, since D1 has no interactive transactions,
+// so a failed deletion leaves the location in place and can simply be retried.
+type LocationRepository struct {
+	db *sql.DB
+}
+
+// NewLocationRepository creates a new location repository
+func NewLocationRepository(db *sql.DB) *LocationRepository {
+	return &LocationRepository{db: db}
+}
+
+// CreateSite inserts a new site
+func (r *LocationRepository) CreateSite(ctx context.Context, req *models.CreateSiteRequest) (*models.Site, error) {
+	query := `
+		INSERT INTO sites (name, address, created_at, updated_at)
+		VALUES (?, ?, ?, ?)
+	`
+
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx, query, req.Name, req.Address, now, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create site: %w", classifyError(err))
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	return r.GetSite(ctx, id)
+}
+
+// GetSite retrieves a site by ID
+func (r *LocationRepository) GetSite(ctx context.Context, id int64) (*models.Site, error) {
+	sites, err := r.querySites(ctx, `WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(sites) == 0 {
+		return nil, ErrSiteNotFound
+	}
+	return sites[0], nil
+}
+
+// ListSites retrieves all sites ordered by name
+func (r *LocationRepository) ListSites(ctx context.Context) ([]*models.Site, error) {
+	return r.querySites(ctx, ``)
+}
+
+// UpdateSite updates a site's information; empty fields are left unchanged
+func (r *LocationRepository) UpdateSite(ctx context.Context, id int64, req *models.UpdateSiteRequest) (*models.Site, error) {
+	query := `
+		UPDATE sites
+		SET name = COALESCE(NULLIF(?, ''), name),
+		    address = COALESCE(NULLIF(?, ''), address),
+		    updated_at = ?
+		WHERE id = ?
+	`
+
+	if err := r.update(ctx, "site", ErrSiteNotFound, query, req.Name, req.Address, time.Now(), id); err != nil {
+		return nil, err
+	}
+	return r.GetSite(ctx, id)
+}
+
+// DeleteSite deletes a site with its buildings and floors
+func (r *LocationRepository) DeleteSite(ctx context.Context, id int64) error {
+	return r.delete(ctx, "site", ErrSiteNotFound, id,
+		unplaceSiteRoomsSQL,
+		`DELETE FROM floors WHERE building_id IN (SELECT id FROM buildings WHERE site_id = ?)`,
+		`DELETE FROM buildings WHERE site_id = ?`,
+		`DELETE FROM sites WHERE id = ?`,
+	)
+}
+
+// CreateBuilding inserts a new building in a site
+func (r *LocationRepository) CreateBuilding(ctx context.Context, siteID int64, req *models.CreateBuildingRequest) (*models.Building, error) {
+	if err := checkExists(ctx, r.db, "sites", siteID, ErrSiteNotFound); err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO buildings (site_id, name, created_at, updated_at)
+		VALUES (?, ?, ?, ?)
+	`
+
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx, query, siteID, req.Name, now, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create building: %w", classifyError(err))
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	return r.GetBuilding(ctx, id)
+}
+
+// GetBuilding retrieves a building by ID
+func (r *LocationRepository) GetBuilding(ctx context.Context, id int64) (*models.Building, error) {
+	buildings, err := r.queryBuildings(ctx, `WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(buildings) == 0 {
+		return nil, ErrBuildingNotFound
+	}
+	return buildings[0], nil
+}
+
+// ListBuildings retrieves the buildings of a site ordered by name
+func (r *LocationRepository) ListBuildings(ctx context.Context, siteID int64) ([]*models.Building, error) {
+	if err := checkExists(ctx, r.db, "sites", siteID, ErrSiteNotFound); err != nil {
+		return nil, err
+	}
+	return r.queryBuildings(ctx, `WHERE site_id = ?`, siteID)
+}
+
+// UpdateBuilding updates a building's information; empty fields are left unchanged
+func (r *LocationRepository) UpdateBuilding(ctx context.Context, id int64, req *models.UpdateBuildingRequest) (*models.Building, error) {
+	query := `
+		UPDATE buildings
+		SET name = COALESCE(NULLIF(?, ''), name),
+		    updated_at = ?
+		WHERE id = ?
+	`
+
+	if err := r.update(ctx, "building", ErrBuildingNotFound, query, req.Name, time.Now(), id); err != nil {
+		return nil, err
+	}
+	return r.GetBuilding(ctx, id)
+}
+
+// DeleteBuilding deletes a building with its floors
+func (r *LocationRepository) DeleteBuilding(ctx context.Context, id int64) error {
+	return r.delete(ctx, "building", ErrBuildingNotFound, id,
+		unplaceBuildingRoomsSQL,
+		`DELETE FROM floors WHERE building_id = ?`,
+		`DELETE FROM buildings WHERE id = ?`,
+	)
+}
+
+// CreateFloor inserts a new floor in a building
+func (r *LocationRepository) CreateFloor(ctx context.Context, buildingID int64, req *models.CreateFloorRequest) (*models.Floor, error) {
+	if err := checkExists(ctx, r.db, "buildings", buildingID, ErrBuildingNotFound); err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO floors (building_id, name, level, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx, query, buildingID, req.Name, req.Level, now, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create floor: %w", classifyError(err))
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	return r.GetFloor(ctx, id)
+}
+
+// GetFloor retrieves a floor by ID
+func (r *LocationRepository) GetFloor(ctx context.Context, id int64) (*models.Floor, error) {
+	floors, err := r.queryFloors(ctx, `WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(floors) == 0 {
+		return nil, ErrFloorNotFound
+	}
+	return floors[0], nil
+}
+
+// ListFloors retrieves the floors of a building from the lowest level up
+func (r *LocationRepository) ListFloors(ctx context.Context, buildingID int64) ([]*models.Floor, error) {
+	if err := checkExists(ctx, r.db, "buildings", buildingID, ErrBuildingNotFound); err != nil {
+		return nil, err
+	}
+	return r.queryFloors(ctx, `WHERE building_id = ?`, buildingID)
+}
+
+// UpdateFloor updates a floor's information; empty fields are left unchanged
+func (r *LocationRepository) UpdateFloor(ctx context.Context, id int64, req *models.UpdateFloorRequest) (*models.Floor, error) {
+	query := `
+		UPDATE floors
+		SET name = COALESCE(NULLIF(?, ''), name),
+		    level = COALESCE(?, level),
+		    updated_at = ?
+		WHERE id = ?
+	`
+
+	var level interface{}
+	if req.Level != nil {
+		level = *req.Level
+	}
+
+	if err := r.update(ctx, "floor", ErrFloorNotFound, query, req.Name, level, time.Now(), id); err != nil {
+		return nil, err
+	}
+	return r.GetFloor(ctx, id)
+}
+
+// DeleteFloor deletes a floor
+func (r *LocationRepository) DeleteFloor(ctx context.Context, id int64) error {
+	return r.delete(ctx, "floor", ErrFloorNotFound, id,
+		unplaceFloorRoomsSQL,
+		`DELETE FROM floors WHERE id = ?`,
+	)
+}
+
+// update runs an UPDATE of a single location and returns notFound when no row matched
+func (r *LocationRepository) update(ctx context.Context, kind string, notFound error, query string, args ...interface{}) error {
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update %s: %w", kind, classifyError(err))
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return notFound
+	}
+
+	return nil
+}
+
+// delete runs the statements deleting a location, each taking the location ID. The last one deletes
+// the location itself; when it matches no row, notFound is returned.
+func (r *LocationRepository) delete(ctx context.Context, kind string, notFound error, id int64, statements ...string) error {
+	var result sql.Result
+	for _, statement := range statements {
+		var err error
+		if result, err = r.db.ExecContext(ctx, statement, id); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", kind, err)
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return notFound
+	}
+
+	return nil
+}
+
+// querySites runs a SELECT over sites with the given WHERE clause
+func (r *LocationRepository) querySites(ctx context.Context, where string, args ...interface{}) ([]*models.Site, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT * FROM sites `+where+` ORDER BY name, id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sites: %w", err)
+	}
+	defer rows.Close()
+
+	var sites []*models.Site
+	for rows.Next() {
+		v, err := scanValues(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan site: %w", err)
+		}
+
+		sites = append(sites, &models.Site{
+			ID:        toInt64(v["id"]),
+			Name:      toString(v["name"]),
+			Address:   toString(v["address"]),
+			CreatedAt: parseTimeValue(v["created_at"]),
+			UpdatedAt: parseTimeValue(v["updated_at"]),
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return sites, nil
+}
+
+// queryBuildings runs a SELECT over buildings with the given WHERE clause
+func (r *LocationRepository) queryBuildings(ctx context.Context, where string, args ...interface{}) ([]*models.Building, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT * FROM buildings `+where+` ORDER BY name, id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query buildings: %w", err)
+	}
+	defer rows.Close()
+
+	var buildings []*models.Building
+	for rows.Next() {
+		v, err := scanValues(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan building: %w", err)
+		}
+
+		buildings = append(buildings, &models.Building{
+			ID:        toInt64(v["id"]),
+			SiteID:    toInt64(v["site_id"]),
+			Name:      toString(v["name"]),
+			CreatedAt: parseTimeValue(v["created_at"]),
+			UpdatedAt: parseTimeValue(v["updated_at"]),
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return buildings, nil
+}
+
+// queryFloors runs a SELECT over floors with the given WHERE clause
+func (r *LocationRepository) queryFloors(ctx context.Context, where string, args ...interface{}) ([]*models.Floor, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT * FROM floors `+where+` ORDER BY level, id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query floors: %w", err)
+	}
+	defer rows.Close()
+
+	var floors []*models.Floor
+	for rows.Next() {
+		v, err := scanValues(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan floor: %w", err)
+		}
+
+		floors = append(floors, &models.Floor{
+			ID:         toInt64(v["id"]),
+			BuildingID: toInt64(v["building_id"]),
+			Name:       toString(v["name"]),
+			Level:      int(toInt64(v["level"])),
+			CreatedAt:  parseTimeValue(v["created_at"]),
+			UpdatedAt:  parseTimeValue(v["updated_at"]),
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return floors, nil
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"cloudflaredb/internal/models"
+)
+
+func TestLocationRepository_Hierarchy(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	repo := NewLocationRepository(db)
+	rooms := NewRoomRepository(db)
+	ctx := context.Background()
+
+	site, err := repo.CreateSite(ctx, &models.CreateSiteRequest{Name: "Berlin", Address: "Main St 1"})
+	if err != nil {
+		t.Fatalf("CreateSite() error = %v", err)
+	}
+	if _, err := repo.CreateSite(ctx, &models.CreateSiteRequest{Name: "Berlin"}); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict for a duplicate site name, got %v", err)
+	}
+
+	if _, err := repo.CreateBuilding(ctx, 999, &models.CreateBuildingRequest{Name: "North"}); !errors.Is(err, ErrSiteNotFound) {
+		t.Errorf("Expected ErrSiteNotFound, got %v", err)
+	}
+	north, err := repo.CreateBuilding(ctx, site.ID, &models.CreateBuildingRequest{Name: "North"})
+	if err != nil {
+		t.Fatalf("CreateBuilding() error = %v", err)
+	}
+	south, err := repo.CreateBuilding(ctx, site.ID, &models.CreateBuildingRequest{Name: "South"})
+	if err != nil {
+		t.Fatalf("CreateBuilding() error = %v", err)
+	}
+
+	ground, err := repo.CreateFloor(ctx, north.ID, &models.CreateFloorRequest{Name: "Ground", Level: 0})
+	if err != nil {
+		t.Fatalf("CreateFloor() error = %v", err)
+	}
+	basement, err := repo.CreateFloor(ctx, north.ID, &models.CreateFloorRequest{Name: "Basement", Level: -1})
+	if err != nil {
+		t.Fatalf("CreateFloor() error = %v", err)
+	}
+	if _, err := repo.CreateFloor(ctx, north.ID, &models.CreateFloorRequest{Name: "Lobby", Level: 0}); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict for a duplicate level, got %v", err)
+	}
+	southFloor, err := repo.CreateFloor(ctx, south.ID, &models.CreateFloorRequest{Name: "Ground", Level: 0})
+	if err != nil {
+		t.Fatalf("CreateFloor() error = %v", err)
+	}
+
+	floors, err := repo.ListFloors(ctx, north.ID)
+	if err != nil || len(floors) != 2 || floors[0].ID != basement.ID {
+		t.Errorf("Expected floors ordered by level, got %v (%v)", floors, err)
+	}
+
+	level := 1
+	updated, err := repo.UpdateFloor(ctx, basement.ID, &models.UpdateFloorRequest{Name: "First", Level: &level})
+	if err != nil || updated.Level != 1 || updated.Name != "First" {
+		t.Errorf("Unexpected floor after update: %+v (%v)", updated, err)
+	}
+	if _, err := repo.UpdateFloor(ctx, 999, &models.UpdateFloorRequest{Name: "x"}); !errors.Is(err, ErrFloorNotFound) {
+		t.Errorf("Expected ErrFloorNotFound, got %v", err)
+	}
+
+	// Rooms are placed on floors and can be listed at every level of the hierarchy
+	if _, err := rooms.Create(ctx, &models.CreateRoomRequest{Name: "Nowhere", Capacity: 2, FloorID: 999}); !errors.Is(err, ErrFloorNotFound) {
+		t.Errorf("Expected ErrFloorNotFound for an unknown floor, got %v", err)
+	}
+	lab, err := rooms.Create(ctx, &models.CreateRoomRequest{Name: "Lab", Capacity: 4, FloorID: ground.ID})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if lab.FloorID == nil || *lab.FloorID != ground.ID {
+		t.Errorf("Expected room on floor %d, got %v", ground.ID, lab.FloorID)
+	}
+	if _, err := rooms.Create(ctx, &models.CreateRoomRequest{Name: "Studio", Capacity: 4, FloorID: southFloor.ID}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := rooms.Create(ctx, &models.CreateRoomRequest{Name: "Annex", Capacity: 4}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	names := func(filter RoomFilter) []string {
+		t.Helper()
+		list, err := rooms.List(ctx, filter, ListOptions{Sort: []SortField{{Column: "name"}}, Limit: 10})
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		var names []string
+		for _, room := range list {
+			names = append(names, room.Name)
+		}
+		return names
+	}
+
+	if got := names(RoomFilter{FloorID: ground.ID}); !slices.Equal(got, []string{"Lab"}) {
+		t.Errorf("Expected [Lab] on the floor, got %v", got)
+	}
+	if got := names(RoomFilter{BuildingID: north.ID}); !slices.Equal(got, []string{"Lab"}) {
+		t.Errorf("Expected [Lab] in the building, got %v", got)
+	}
+	if got := names(RoomFilter{SiteID: site.ID}); !slices.Equal(got, []string{"Lab", "Studio"}) {
+		t.Errorf("Expected [Lab Studio] on the site, got %v", got)
+	}
+
+	// Deleting a building deletes its floors and unplaces their rooms
+	if err := repo.DeleteBuilding(ctx, north.ID); err != nil {
+		t.Fatalf("DeleteBuilding() error = %v", err)
+	}
+	if _, err := repo.GetFloor(ctx, ground.ID); !errors.Is(err, ErrFloorNotFound) {
+		t.Errorf("Expected the floor to be deleted, got %v", err)
+	}
+	room, err := rooms.GetByID(ctx, lab.ID)
+	if err != nil || room.FloorID != nil {
+		t.Errorf("Expected the room to be kept without a floor, got %+v (%v)", room, err)
+	}
+	if err := repo.DeleteBuilding(ctx, north.ID); !errors.Is(err, ErrBuildingNotFound) {
+		t.Errorf("Expected ErrBuildingNotFound, got %v", err)
+	}
+
+	// Deleting a site deletes everything below it
+	if err := repo.DeleteSite(ctx, site.ID); err != nil {
+		t.Fatalf("DeleteSite() error = %v", err)
+	}
+	if _, err := repo.GetBuilding(ctx, south.ID); !errors.Is(err, ErrBuildingNotFound) {
+		t.Errorf("Expected the building to be deleted, got %v", err)
+	}
+	if got := names(RoomFilter{}); len(got) != 3 {
+		t.Errorf("Expected all rooms to be kept, got %v", got)
+	}
+}
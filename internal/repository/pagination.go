@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor marks the position after which the next page starts in keyset pagination.
+// Lists are ordered by (created_at, id) descending, so both values identify the position.
+type Cursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        int64     `json:"id"`
+}
+
+// Encode returns the cursor as an opaque, URL-safe token
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor parses a token produced by Cursor.Encode
+func DecodeCursor(token string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil || c.ID <= 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// keysetCondition restricts a list ordered by (created_at, id) descending to the rows after the cursor
+const keysetCondition = `(created_at < ? OR (created_at = ? AND id < ?))`
+
+// keysetArgs returns the query arguments for keysetCondition
+func (c *Cursor) keysetArgs() []interface{} {
+	return []interface{}{c.CreatedAt, c.CreatedAt, c.ID}
+}
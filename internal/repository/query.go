@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrInvalidSort is returned when a sort specification names a column that cannot be sorted on
+var ErrInvalidSort = errors.New("invalid sort")
+
+// ErrSortWithCursor is returned when cursor pagination is combined with a custom sort order
+var ErrSortWithCursor = errors.New("cursor pagination only supports the default sort order")
+
+// UserSortColumns lists the user fields accepted in a sort specification
+var UserSortColumns = []string{"id", "name", "email", "created_at", "updated_at"}
+
+// RoomSortColumns lists the room fields accepted in a sort specification
+var RoomSortColumns = []string{"id", "name", "capacity", "created_at", "updated_at"}
+
+// SortField orders a list by one column
+type SortField struct {
+	Column string
+	Desc   bool
+}
+
+// ListOptions controls the order and pagination of List queries
+type ListOptions struct {
+	// Sort defaults to created_at descending; id is always appended as a tie-breaker
+	Sort   []SortField
+	Limit  int
+	Offset int
+	// After switches to keyset pagination and requires the default sort order
+	After *Cursor
+}
+
+// UserFilter narrows the users returned by List; zero values are ignored
+type UserFilter struct {
+	// NameContains matches users whose name contains the text (case-insensitive for ASCII)
+	NameContains string
+	Email        string
+	// CreatedAfter and CreatedBefore are exclusive bounds on created_at
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+}
+
+// RoomFilter narrows the rooms returned by List; zero values are ignored
+type RoomFilter struct {
+	// NameContains matches rooms whose name contains the text (case-insensitive for ASCII)
+	NameContains string
+	// MinCapacity and MaxCapacity are inclusive bounds on capacity
+	MinCapacity int
+	MaxCapacity int
+	// CreatedAfter and CreatedBefore are exclusive bounds on created_at
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	// FloorID, BuildingID and SiteID match rooms placed on the floor or anywhere in the building or site
+	FloorID    int64
+	BuildingID int64
+	SiteID     int64
+	// Tags and Amenities match rooms having all of them
+	Tags      []string
+	Amenities []string
+	// Attributes match rooms whose top-level JSON attributes equal the values, e.g. {"floor": "3"}
+	Attributes map[string]string
+}
+
+// ParseSort parses a comma-separated sort specification such as "name,-created_at".
+// A leading "-" sorts descending; every column must be in allowed.
+func ParseSort(spec string, allowed []string) ([]SortField, error) {
+	var fields []SortField
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		field := SortField{Column: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+		if !slices.Contains(allowed, field.Column) {
+			return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidSort, field.Column)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// conditions accumulates the clauses and arguments of a WHERE clause
+type conditions struct {
+	clauses []string
+	args    []interface{}
+}
+
+// add appends a clause with its placeholder arguments
+func (c *conditions) add(clause string, args ...interface{}) {
+	c.clauses = append(c.clauses, clause)
+	c.args = append(c.args, args...)
+}
+
+// addContains appends a case-insensitive substring match on column
+func (c *conditions) addContains(column, text string) {
+	c.add(column+` LIKE ? ESCAPE '\'`, "%"+escapeLike(text)+"%")
+}
+
+// addCreatedRange appends the exclusive created_at bounds that are set
+func (c *conditions) addCreatedRange(after, before time.Time) {
+	if !after.IsZero() {
+		c.add("created_at > ?", after)
+	}
+	if !before.IsZero() {
+		c.add("created_at < ?", before)
+	}
+}
+
+// where returns the WHERE clause, or an empty string when there are no conditions
+func (c *conditions) where() string {
+	if len(c.clauses) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(c.clauses, " AND ")
+}
+
+// assignments accumulates the column assignments and arguments of an UPDATE statement, so updates
+// only touch the columns a request supplies
+type assignments struct {
+	clauses []string
+	args    []interface{}
+}
+
+// set assigns value to column
+func (a *assignments) set(column string, value interface{}) {
+	a.setExpr(column+" = ?", value)
+}
+
+// setExpr appends an assignment computed by SQL, such as one merging into the current value
+func (a *assignments) setExpr(clause string, args ...interface{}) {
+	a.clauses = append(a.clauses, clause)
+	a.args = append(a.args, args...)
+}
+
+// sql returns the comma-separated assignments for a SET clause
+func (a *assignments) sql() string {
+	return strings.Join(a.clauses, ", ")
+}
+
+// userConditions translates a user filter into WHERE conditions
+func userConditions(f UserFilter) *conditions {
+	c := &conditions{}
+	if f.NameContains != "" {
+		c.addContains("name", f.NameContains)
+	}
+	if f.Email != "" {
+		c.add("email = ?", f.Email)
+	}
+	c.addCreatedRange(f.CreatedAfter, f.CreatedBefore)
+	return c
+}
+
+// roomConditions translates a room filter into WHERE conditions
+func roomConditions(f RoomFilter) *conditions {
+	c := &conditions{}
+	if f.NameContains != "" {
+		c.addContains("name", f.NameContains)
+	}
+	if f.MinCapacity > 0 {
+		c.add("capacity >= ?", f.MinCapacity)
+	}
+	if f.MaxCapacity > 0 {
+		c.add("capacity <= ?", f.MaxCapacity)
+	}
+	c.addCreatedRange(f.CreatedAfter, f.CreatedBefore)
+	if f.FloorID > 0 {
+		c.add("floor_id = ?", f.FloorID)
+	}
+	if f.BuildingID > 0 {
+		c.add("floor_id IN (SELECT id FROM floors WHERE building_id = ?)", f.BuildingID)
+	}
+	if f.SiteID > 0 {
+		c.add(`floor_id IN (SELECT f.id FROM floors f INNER JOIN buildings b ON b.id = f.building_id WHERE b.site_id = ?)`, f.SiteID)
+	}
+	addRoomMetadataConditions(c, f)
+	return c
+}
+
+// listClauses adds the keyset condition to c and returns the ORDER BY and LIMIT/OFFSET clauses for opts
+func listClauses(c *conditions, opts ListOptions, allowed []string) (string, error) {
+	if opts.After != nil {
+		if len(opts.Sort) > 0 {
+			return "", ErrSortWithCursor
+		}
+		c.add(keysetCondition, opts.After.keysetArgs()...)
+	}
+
+	orderBy, err := orderByClause(opts.Sort, allowed)
+	if err != nil {
+		return "", err
+	}
+
+	c.args = append(c.args, opts.Limit, opts.Offset)
+	return orderBy + " LIMIT ? OFFSET ?", nil
+}
+
+// orderByClause builds an ORDER BY clause from whitelisted columns, with id as the final tie-breaker
+func orderByClause(sort []SortField, allowed []string) (string, error) {
+	if len(sort) == 0 {
+		return "ORDER BY created_at DESC, id DESC", nil
+	}
+
+	terms := make([]string, 0, len(sort)+1)
+	hasID := false
+	for _, s := range sort {
+		// Columns are interpolated into SQL, so they must come from the whitelist
+		if !slices.Contains(allowed, s.Column) {
+			return "", fmt.Errorf("%w: unknown column %q", ErrInvalidSort, s.Column)
+		}
+		dir := "ASC"
+		if s.Desc {
+			dir = "DESC"
+		}
+		terms = append(terms, s.Column+" "+dir)
+		hasID = hasID || s.Column == "id"
+	}
+	if !hasID {
+		terms = append(terms, "id ASC")
+	}
+	return "ORDER BY " + strings.Join(terms, ", "), nil
+}
+
+// escapeLike escapes the LIKE wildcards in s so it is matched literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    []SortField
+		wantErr bool
+	}{
+		{name: "empty", spec: "", want: nil},
+		{name: "single ascending", spec: "name", want: []SortField{{Column: "name"}}},
+		{
+			name: "multiple with descending",
+			spec: "name, -created_at",
+			want: []SortField{{Column: "name"}, {Column: "created_at", Desc: true}},
+		},
+		{name: "unknown column", spec: "password", wantErr: true},
+		{name: "injection attempt", spec: "name;DROP TABLE users", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSort(tt.spec, UserSortColumns)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidSort) {
+					t.Errorf("ParseSort() error = %v, want ErrInvalidSort", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSort() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSort() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderByClause(t *testing.T) {
+	got, err := orderByClause([]SortField{{Column: "capacity", Desc: true}, {Column: "name"}}, RoomSortColumns)
+	if err != nil {
+		t.Fatalf("orderByClause() error = %v", err)
+	}
+	if want := "ORDER BY capacity DESC, name ASC, id ASC"; got != want {
+		t.Errorf("orderByClause() = %q, want %q", got, want)
+	}
+
+	if _, err := orderByClause([]SortField{{Column: "email"}}, RoomSortColumns); !errors.Is(err, ErrInvalidSort) {
+		t.Errorf("Expected ErrInvalidSort for a column outside the whitelist, got %v", err)
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	if got, want := escapeLike(`100%_a\b`), `100\%\_a\\b`; got != want {
+		t.Errorf("escapeLike() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,507 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"cloudflaredb/internal/models"
+	"cloudflaredb/internal/recurrence"
+)
+
+const (
+	// seriesCheckHorizon bounds the occurrences checked for capacity when a series is created or a
+	// one-off booking is placed; it also bounds the expansion of rules without COUNT or UNTIL
+	seriesCheckHorizon = models.SeriesCheckHorizon // years
+
+	// maxSeriesSpan bounds the expansion of rules with COUNT or UNTIL
+	maxSeriesSpan = models.MaxSeriesSpan // years
+)
+
+// OccurrenceConflictError is returned when an occurrence of a recurring booking would exceed the
+// room's capacity. It matches ErrFullyBooked and ErrConflict with errors.Is.
+type OccurrenceConflictError struct {
+	StartsAt time.Time
+}
+
+// Error implements the error interface
+func (e *OccurrenceConflictError) Error() string {
+	return "room is fully booked for the occurrence at " + e.StartsAt.UTC().Format(time.RFC3339)
+}
+
+// Unwrap returns ErrFullyBooked
+func (e *OccurrenceConflictError) Unwrap() error {
+	return ErrFullyBooked
+}
+
+// RecurringBookingRepository handles database operations for recurring bookings.
+// Series are stored as recurrence rules and expanded into occurrences on read; cancelled and
+// moved occurrences are stored as exceptions keyed by their recurrence ID.
+type RecurringBookingRepository struct {
+	db *sql.DB
+}
+
+// NewRecurringBookingRepository creates a new recurring booking repository
+func NewRecurringBookingRepository(db *sql.DB) *RecurringBookingRepository {
+	return &RecurringBookingRepository{db: db}
+}
+
+// Create stores a recurring booking after checking every occurrence within the next year against
+// the room's capacity. The check and the insert are separate statements, since D1 has no
+// interactive transactions, so concurrent requests for the same slots may both succeed.
+func (r *RecurringBookingRepository) Create(ctx context.Context, roomID int64, req *models.CreateRecurringBookingRequest) (*models.RecurringBooking, error) {
+	rule, err := recurrence.Parse(req.RRule)
+	if err != nil {
+		return nil, err
+	}
+	timezone := cmp.Or(req.Timezone, "UTC")
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load time zone %s: %w", timezone, err)
+	}
+
+	dtstart := req.StartsAt.In(loc).Truncate(time.Second)
+	duration := req.EndsAt.Truncate(time.Second).Sub(dtstart)
+	starts := expand(rule, dtstart)
+	if len(starts) == 0 {
+		return nil, fmt.Errorf("%w: no occurrences", recurrence.ErrInvalidRule)
+	}
+
+	capacity, err := roomCapacity(ctx, r.db, roomID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkExists(ctx, r.db, "users", req.UserID, ErrUserNotFound); err != nil {
+		return nil, err
+	}
+
+	excluded := make(map[string]bool, len(req.ExDates))
+	for _, t := range req.ExDates {
+		excluded[formatBookingTime(t)] = true
+	}
+
+	horizon := dtstart.AddDate(seriesCheckHorizon, 0, 0)
+	checkEnd := starts[len(starts)-1].Add(duration)
+	if checkEnd.After(horizon) {
+		checkEnd = horizon
+	}
+	slots, err := roomSlots(ctx, r.db, roomID, dtstart, checkEnd)
+	if err != nil {
+		return nil, err
+	}
+	for _, start := range starts {
+		if !start.Before(horizon) {
+			break
+		}
+		if excluded[formatBookingTime(start)] {
+			continue
+		}
+		if peakOccupancy(slots, start, start.Add(duration)) >= capacity {
+			return nil, &OccurrenceConflictError{StartsAt: start}
+		}
+	}
+
+	var lastEndsAt interface{}
+	if rule.Bounded() {
+		lastEndsAt = formatBookingTime(starts[len(starts)-1].Add(duration))
+	}
+
+	query := `
+		INSERT INTO recurring_bookings (room_id, user_id, title, starts_at, ends_at, timezone, rrule, last_ends_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		roomID, req.UserID, req.Title,
+		formatBookingTime(dtstart), formatBookingTime(dtstart.Add(duration)),
+		timezone, rule.String(), lastEndsAt, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recurring booking: %w", classifyError(err))
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	if len(excluded) > 0 {
+		recurrenceIDs := make([]string, 0, len(excluded))
+		for recurrenceID := range excluded {
+			recurrenceIDs = append(recurrenceIDs, recurrenceID)
+		}
+		recurrenceIDsJSON, err := json.Marshal(recurrenceIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode excluded dates: %w", err)
+		}
+
+		// The dates are passed as one JSON array, so the statement stays within D1's bound parameter limit
+		query := `
+			INSERT INTO recurring_booking_exceptions (series_id, recurrence_id, created_at)
+			SELECT ?, value, ? FROM json_each(?)
+		`
+		if _, err := r.db.ExecContext(ctx, query, id, time.Now(), string(recurrenceIDsJSON)); err != nil {
+			return nil, fmt.Errorf("failed to store excluded dates: %w", classifyError(err))
+		}
+	}
+
+	return r.GetByID(ctx, id)
+}
+
+// GetByID retrieves a recurring booking by ID
+func (r *RecurringBookingRepository) GetByID(ctx context.Context, id int64) (*models.RecurringBooking, error) {
+	series, err := querySeries(ctx, r.db, `SELECT * FROM recurring_bookings WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(series) == 0 {
+		return nil, ErrRecurringBookingNotFound
+	}
+
+	return series[0], nil
+}
+
+// ListOccurrences returns the occurrences of the room's recurring bookings that overlap [from, to),
+// ordered by start time
+func (r *RecurringBookingRepository) ListOccurrences(ctx context.Context, roomID int64, from, to time.Time) ([]*models.Occurrence, error) {
+	if err := checkExists(ctx, r.db, "rooms", roomID, ErrRoomNotFound); err != nil {
+		return nil, err
+	}
+
+	return roomOccurrences(ctx, r.db, roomID, from, to)
+}
+
+// Delete removes a recurring booking with all its occurrences
+func (r *RecurringBookingRepository) Delete(ctx context.Context, id int64) error {
+	query := `DELETE FROM recurring_bookings WHERE id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete recurring booking: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecurringBookingNotFound
+	}
+
+	return nil
+}
+
+// CancelOccurrence cancels the occurrence of a series identified by its recurrence ID
+func (r *RecurringBookingRepository) CancelOccurrence(ctx context.Context, seriesID int64, recurrenceID time.Time) error {
+	if _, err := r.occurrence(ctx, seriesID, recurrenceID); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO recurring_booking_exceptions (series_id, recurrence_id, starts_at, ends_at, created_at)
+		VALUES (?, ?, NULL, NULL, ?)
+		ON CONFLICT (series_id, recurrence_id) DO UPDATE SET starts_at = NULL, ends_at = NULL
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, seriesID, formatBookingTime(recurrenceID), time.Now()); err != nil {
+		return fmt.Errorf("failed to cancel occurrence: %w", err)
+	}
+
+	return nil
+}
+
+// MoveOccurrence reschedules a single occurrence of a series. The new time range is checked against
+// the room's capacity, ignoring the occurrence's current slot.
+func (r *RecurringBookingRepository) MoveOccurrence(ctx context.Context, seriesID int64, recurrenceID time.Time, req *models.MoveOccurrenceRequest) (*models.Occurrence, error) {
+	occ, err := r.occurrence(ctx, seriesID, recurrenceID)
+	if err != nil {
+		return nil, err
+	}
+
+	capacity, err := roomCapacity(ctx, r.db, occ.RoomID)
+	if err != nil {
+		return nil, err
+	}
+
+	slots, err := roomSlots(ctx, r.db, occ.RoomID, req.StartsAt, req.EndsAt)
+	if err != nil {
+		return nil, err
+	}
+	slots = slices.DeleteFunc(slots, func(s slot) bool {
+		return s.seriesID == seriesID && s.recurrenceID.Equal(occ.RecurrenceID)
+	})
+	if peakOccupancy(slots, req.StartsAt, req.EndsAt) >= capacity {
+		return nil, ErrFullyBooked
+	}
+
+	query := `
+		INSERT INTO recurring_booking_exceptions (series_id, recurrence_id, starts_at, ends_at, created_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (series_id, recurrence_id) DO UPDATE SET starts_at = excluded.starts_at, ends_at = excluded.ends_at
+	`
+
+	start, end := formatBookingTime(req.StartsAt), formatBookingTime(req.EndsAt)
+	if _, err := r.db.ExecContext(ctx, query, seriesID, formatBookingTime(occ.RecurrenceID), start, end, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to move occurrence: %w", classifyError(err))
+	}
+
+	occ.StartsAt = req.StartsAt.UTC().Truncate(time.Second)
+	occ.EndsAt = req.EndsAt.UTC().Truncate(time.Second)
+	occ.Moved = true
+	return occ, nil
+}
+
+// occurrence returns the current state of a series occurrence, or ErrOccurrenceNotFound when the
+// rule has no occurrence at recurrenceID or the occurrence is cancelled
+func (r *RecurringBookingRepository) occurrence(ctx context.Context, seriesID int64, recurrenceID time.Time) (*models.Occurrence, error) {
+	series, err := r.GetByID(ctx, seriesID)
+	if err != nil {
+		return nil, err
+	}
+
+	rule, loc, err := seriesRule(series)
+	if err != nil {
+		return nil, err
+	}
+
+	dtstart := series.StartsAt.In(loc)
+	starts := rule.Occurrences(dtstart, recurrenceID.Add(time.Second))
+	if len(starts) == 0 || !starts[len(starts)-1].Equal(recurrenceID) {
+		return nil, ErrOccurrenceNotFound
+	}
+
+	occ := &models.Occurrence{
+		SeriesID:     series.ID,
+		RoomID:       series.RoomID,
+		UserID:       series.UserID,
+		Title:        series.Title,
+		RecurrenceID: recurrenceID.UTC(),
+		StartsAt:     recurrenceID.UTC(),
+		EndsAt:       recurrenceID.Add(series.EndsAt.Sub(series.StartsAt)).UTC(),
+	}
+
+	exceptions, err := queryExceptions(ctx, r.db,
+		`SELECT * FROM recurring_booking_exceptions WHERE series_id = ? AND recurrence_id = ?`,
+		seriesID, formatBookingTime(recurrenceID))
+	if err != nil {
+		return nil, err
+	}
+	if len(exceptions) > 0 {
+		e := exceptions[0]
+		if e.cancelled() {
+			return nil, ErrOccurrenceNotFound
+		}
+		occ.StartsAt, occ.EndsAt, occ.Moved = e.startsAt, e.endsAt, true
+	}
+
+	return occ, nil
+}
+
+// roomOccurrences expands the recurring bookings of a room into the occurrences overlapping
+// [from, to), applying cancellations and moves, ordered by start time
+func roomOccurrences(ctx context.Context, db *sql.DB, roomID int64, from, to time.Time) ([]*models.Occurrence, error) {
+	series, err := querySeries(ctx, db, `
+		SELECT *
+		FROM recurring_bookings
+		WHERE room_id = ? AND starts_at < ? AND (last_ends_at IS NULL OR last_ends_at > ?)
+	`, roomID, formatBookingTime(to), formatBookingTime(from))
+	if err != nil {
+		return nil, err
+	}
+
+	// Exceptions replace the generated occurrences they are keyed by...
+	replaced := map[int64]map[string]bool{}
+	if len(series) > 0 {
+		placeholders := make([]string, len(series))
+		args := make([]interface{}, 0, len(series))
+		for i, s := range series {
+			placeholders[i] = "?"
+			args = append(args, s.ID)
+		}
+
+		exceptions, err := queryExceptions(ctx, db, `
+			SELECT *
+			FROM recurring_booking_exceptions
+			WHERE series_id IN (`+strings.Join(placeholders, ", ")+`) AND recurrence_id < ?
+		`, append(args, formatBookingTime(to))...)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range exceptions {
+			if replaced[e.seriesID] == nil {
+				replaced[e.seriesID] = map[string]bool{}
+			}
+			replaced[e.seriesID][formatBookingTime(e.recurrenceID)] = true
+		}
+	}
+
+	var occurrences []*models.Occurrence
+	for _, s := range series {
+		rule, loc, err := seriesRule(s)
+		if err != nil {
+			return nil, err
+		}
+
+		duration := s.EndsAt.Sub(s.StartsAt)
+		for _, start := range rule.Occurrences(s.StartsAt.In(loc), to) {
+			if !start.Add(duration).After(from) || replaced[s.ID][formatBookingTime(start)] {
+				continue
+			}
+			occurrences = append(occurrences, &models.Occurrence{
+				SeriesID:     s.ID,
+				RoomID:       s.RoomID,
+				UserID:       s.UserID,
+				Title:        s.Title,
+				RecurrenceID: start.UTC(),
+				StartsAt:     start.UTC(),
+				EndsAt:       start.Add(duration).UTC(),
+			})
+		}
+	}
+
+	// ...and moved occurrences show up wherever they were moved to
+	rows, err := db.QueryContext(ctx, `
+		SELECT e.recurrence_id, e.starts_at, e.ends_at, s.id, s.room_id, s.user_id, s.title
+		FROM recurring_booking_exceptions e
+		INNER JOIN recurring_bookings s ON s.id = e.series_id
+		WHERE s.room_id = ? AND e.starts_at < ? AND e.ends_at > ?
+	`, roomID, formatBookingTime(to), formatBookingTime(from))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query moved occurrences: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		v, err := scanValues(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan moved occurrence: %w", err)
+		}
+		occurrences = append(occurrences, &models.Occurrence{
+			SeriesID:     toInt64(v["id"]),
+			RoomID:       toInt64(v["room_id"]),
+			UserID:       toInt64(v["user_id"]),
+			Title:        toString(v["title"]),
+			RecurrenceID: parseTimeValue(v["recurrence_id"]),
+			StartsAt:     parseTimeValue(v["starts_at"]),
+			EndsAt:       parseTimeValue(v["ends_at"]),
+			Moved:        true,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	slices.SortFunc(occurrences, func(a, b *models.Occurrence) int {
+		if c := a.StartsAt.Compare(b.StartsAt); c != 0 {
+			return c
+		}
+		return int(a.SeriesID - b.SeriesID)
+	})
+
+	return occurrences, nil
+}
+
+// expand returns the occurrence starts of a rule beginning at dtstart, up to the series check
+// horizon for rules without COUNT or UNTIL
+func expand(rule *recurrence.Rule, dtstart time.Time) []time.Time {
+	span := seriesCheckHorizon
+	if rule.Bounded() {
+		span = maxSeriesSpan
+	}
+	return rule.Occurrences(dtstart, dtstart.AddDate(span, 0, 0))
+}
+
+// seriesRule parses the stored rule and time zone of a series
+func seriesRule(s *models.RecurringBooking) (*recurrence.Rule, *time.Location, error) {
+	rule, err := recurrence.Parse(s.RRule)
+	if err != nil {
+		return nil, nil, fmt.Errorf("recurring booking %d: %w", s.ID, err)
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return nil, nil, fmt.Errorf("recurring booking %d: failed to load time zone: %w", s.ID, err)
+	}
+	return rule, loc, nil
+}
+
+// querySeries runs a query returning full recurring booking rows and scans them
+func querySeries(ctx context.Context, db *sql.DB, query string, args ...interface{}) ([]*models.RecurringBooking, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recurring bookings: %w", err)
+	}
+	defer rows.Close()
+
+	var series []*models.RecurringBooking
+	for rows.Next() {
+		v, err := scanValues(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan recurring booking: %w", err)
+		}
+
+		series = append(series, &models.RecurringBooking{
+			ID:        toInt64(v["id"]),
+			RoomID:    toInt64(v["room_id"]),
+			UserID:    toInt64(v["user_id"]),
+			Title:     toString(v["title"]),
+			StartsAt:  parseTimeValue(v["starts_at"]),
+			EndsAt:    parseTimeValue(v["ends_at"]),
+			Timezone:  toString(v["timezone"]),
+			RRule:     toString(v["rrule"]),
+			CreatedAt: parseTimeValue(v["created_at"]),
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return series, nil
+}
+
+// exception is a cancelled or moved occurrence; startsAt and endsAt are zero when it is cancelled
+type exception struct {
+	seriesID         int64
+	recurrenceID     time.Time
+	startsAt, endsAt time.Time
+}
+
+func (e *exception) cancelled() bool {
+	return e.startsAt.IsZero()
+}
+
+// queryExceptions runs a query returning full exception rows and scans them
+func queryExceptions(ctx context.Context, db *sql.DB, query string, args ...interface{}) ([]*exception, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query occurrence exceptions: %w", err)
+	}
+	defer rows.Close()
+
+	var exceptions []*exception
+	for rows.Next() {
+		v, err := scanValues(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan occurrence exception: %w", err)
+		}
+
+		exceptions = append(exceptions, &exception{
+			seriesID:     toInt64(v["series_id"]),
+			recurrenceID: parseTimeValue(v["recurrence_id"]),
+			startsAt:     parseTimeValue(v["starts_at"]),
+			endsAt:       parseTimeValue(v["ends_at"]),
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return exceptions, nil
+}
@@ -0,0 +1,264 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"cloudflaredb/internal/models"
+)
+
+// monday is the first day of the test series, 2026-01-05 UTC
+var monday = time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+
+// weeklyStandup returns a request for a weekly 09:00-09:30 series on Mondays starting on monday
+func weeklyStandup(userID int64, rule string) *models.CreateRecurringBookingRequest {
+	return &models.CreateRecurringBookingRequest{
+		UserID:   userID,
+		Title:    "Standup",
+		StartsAt: monday.Add(9 * time.Hour),
+		EndsAt:   monday.Add(9*time.Hour + 30*time.Minute),
+		RRule:    rule,
+	}
+}
+
+func TestRecurringBookingRepository_CreateAndList(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	repo := NewRecurringBookingRepository(db)
+	ctx := context.Background()
+	seedBookingData(t, NewBookingRepository(db), 1)
+
+	req := weeklyStandup(1, "FREQ=WEEKLY;COUNT=4")
+	req.ExDates = []time.Time{monday.AddDate(0, 0, 7).Add(9 * time.Hour)}
+
+	series, err := repo.Create(ctx, 1, req)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if series.RRule != "FREQ=WEEKLY;COUNT=4" || series.Timezone != "UTC" {
+		t.Errorf("Unexpected series: %+v", series)
+	}
+
+	occurrences, err := repo.ListOccurrences(ctx, 1, monday, monday.AddDate(0, 1, 0))
+	if err != nil {
+		t.Fatalf("ListOccurrences() error = %v", err)
+	}
+
+	want := []int{5, 19, 26}
+	if len(occurrences) != len(want) {
+		t.Fatalf("Expected %d occurrences, got %d", len(want), len(occurrences))
+	}
+	for i, occ := range occurrences {
+		if occ.StartsAt.Day() != want[i] || occ.EndsAt.Sub(occ.StartsAt) != 30*time.Minute || occ.SeriesID != series.ID {
+			t.Errorf("Unexpected occurrence %d: %+v", i, occ)
+		}
+	}
+
+	// The window only returns overlapping occurrences
+	window, err := repo.ListOccurrences(ctx, 1, monday.AddDate(0, 0, 14).Add(9*time.Hour+15*time.Minute), monday.AddDate(0, 0, 15))
+	if err != nil {
+		t.Fatalf("ListOccurrences() error = %v", err)
+	}
+	if len(window) != 1 || window[0].StartsAt.Day() != 19 {
+		t.Errorf("Expected only the 2026-01-19 occurrence, got %+v", window)
+	}
+}
+
+func TestRecurringBookingRepository_CreateWithManyExDates(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	repo := NewRecurringBookingRepository(db)
+	ctx := context.Background()
+	seedBookingData(t, NewBookingRepository(db), 1)
+
+	// Every other day of a 100-day series is left out: more dates than one multi-row INSERT fits on D1
+	req := weeklyStandup(1, "FREQ=DAILY;COUNT=100")
+	for day := 1; day < 100; day += 2 {
+		req.ExDates = append(req.ExDates, req.StartsAt.AddDate(0, 0, day))
+	}
+
+	if _, err := repo.Create(ctx, 1, req); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	occurrences, err := repo.ListOccurrences(ctx, 1, monday, monday.AddDate(0, 0, 100))
+	if err != nil {
+		t.Fatalf("ListOccurrences() error = %v", err)
+	}
+	if len(occurrences) != 50 {
+		t.Fatalf("Expected 50 occurrences, got %d", len(occurrences))
+	}
+	for _, occ := range occurrences {
+		if day := int(occ.StartsAt.Sub(req.StartsAt).Hours()) / 24; day%2 != 0 {
+			t.Errorf("Expected excluded day %d to be left out", day)
+		}
+	}
+}
+
+func TestRecurringBookingRepository_CreateConflicts(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	repo := NewRecurringBookingRepository(db)
+	bookings := NewBookingRepository(db)
+	ctx := context.Background()
+	seedBookingData(t, bookings, 1)
+
+	// A one-off booking on the third Monday blocks the series
+	third := monday.AddDate(0, 0, 14)
+	if _, err := bookings.Create(ctx, 1, &models.CreateBookingRequest{
+		UserID:   2,
+		StartsAt: third.Add(9 * time.Hour),
+		EndsAt:   third.Add(10 * time.Hour),
+	}); err != nil {
+		t.Fatalf("Failed to create booking: %v", err)
+	}
+
+	_, err := repo.Create(ctx, 1, weeklyStandup(1, "FREQ=WEEKLY;COUNT=4"))
+	var conflict *OccurrenceConflictError
+	if !errors.As(err, &conflict) || !conflict.StartsAt.Equal(third.Add(9*time.Hour)) {
+		t.Fatalf("Expected a conflict on %s, got %v", third.Add(9*time.Hour), err)
+	}
+	if !errors.Is(err, ErrFullyBooked) || !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected the conflict to match ErrFullyBooked and ErrConflict")
+	}
+
+	// Excluding the blocked occurrence makes the series fit
+	req := weeklyStandup(1, "FREQ=WEEKLY;COUNT=4")
+	req.ExDates = []time.Time{third.Add(9 * time.Hour)}
+	if _, err := repo.Create(ctx, 1, req); err != nil {
+		t.Fatalf("Create() with exdate error = %v", err)
+	}
+
+	// Occurrences now block one-off bookings and other series
+	if _, err := bookings.Create(ctx, 1, &models.CreateBookingRequest{
+		UserID:   2,
+		StartsAt: monday.Add(9*time.Hour + 15*time.Minute),
+		EndsAt:   monday.Add(10 * time.Hour),
+	}); !errors.Is(err, ErrFullyBooked) {
+		t.Errorf("Expected the series to block a one-off booking, got %v", err)
+	}
+	if _, err := repo.Create(ctx, 1, weeklyStandup(2, "FREQ=WEEKLY")); !errors.Is(err, ErrFullyBooked) {
+		t.Errorf("Expected the series to block another series, got %v", err)
+	}
+
+	if _, err := repo.Create(ctx, 99, weeklyStandup(1, "FREQ=DAILY")); !errors.Is(err, ErrRoomNotFound) {
+		t.Errorf("Expected ErrRoomNotFound, got %v", err)
+	}
+}
+
+func TestRecurringBookingRepository_CancelAndMoveOccurrence(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	repo := NewRecurringBookingRepository(db)
+	ctx := context.Background()
+	seedBookingData(t, NewBookingRepository(db), 1)
+
+	series, err := repo.Create(ctx, 1, weeklyStandup(1, "FREQ=WEEKLY"))
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	first := monday.Add(9 * time.Hour)
+	second := first.AddDate(0, 0, 7)
+	third := first.AddDate(0, 0, 14)
+
+	if err := repo.CancelOccurrence(ctx, series.ID, first); err != nil {
+		t.Fatalf("CancelOccurrence() error = %v", err)
+	}
+	if err := repo.CancelOccurrence(ctx, series.ID, first); !errors.Is(err, ErrOccurrenceNotFound) {
+		t.Errorf("Expected ErrOccurrenceNotFound for a cancelled occurrence, got %v", err)
+	}
+	if err := repo.CancelOccurrence(ctx, series.ID, first.Add(time.Hour)); !errors.Is(err, ErrOccurrenceNotFound) {
+		t.Errorf("Expected ErrOccurrenceNotFound outside the rule, got %v", err)
+	}
+
+	// Moving onto another occurrence of the full room conflicts; moving to a free slot works
+	if _, err := repo.MoveOccurrence(ctx, series.ID, second, &models.MoveOccurrenceRequest{
+		StartsAt: third, EndsAt: third.Add(30 * time.Minute),
+	}); !errors.Is(err, ErrFullyBooked) {
+		t.Errorf("Expected ErrFullyBooked, got %v", err)
+	}
+
+	moved, err := repo.MoveOccurrence(ctx, series.ID, second, &models.MoveOccurrenceRequest{
+		StartsAt: second.Add(2 * time.Hour), EndsAt: second.Add(3 * time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("MoveOccurrence() error = %v", err)
+	}
+	if !moved.Moved || !moved.RecurrenceID.Equal(second) || !moved.StartsAt.Equal(second.Add(2*time.Hour)) {
+		t.Errorf("Unexpected moved occurrence: %+v", moved)
+	}
+
+	// Moving again within the old slot ignores the occurrence's own current slot
+	if _, err := repo.MoveOccurrence(ctx, series.ID, second, &models.MoveOccurrenceRequest{
+		StartsAt: second.Add(2*time.Hour + 30*time.Minute), EndsAt: second.Add(3 * time.Hour),
+	}); err != nil {
+		t.Fatalf("MoveOccurrence() again error = %v", err)
+	}
+
+	occurrences, err := repo.ListOccurrences(ctx, 1, monday, monday.AddDate(0, 0, 15))
+	if err != nil {
+		t.Fatalf("ListOccurrences() error = %v", err)
+	}
+	if len(occurrences) != 2 {
+		t.Fatalf("Expected the moved and the third occurrence, got %+v", occurrences)
+	}
+	if !occurrences[0].Moved || !occurrences[0].StartsAt.Equal(second.Add(2*time.Hour+30*time.Minute)) {
+		t.Errorf("Expected the moved occurrence first, got %+v", occurrences[0])
+	}
+	if occurrences[1].Moved || !occurrences[1].StartsAt.Equal(third) {
+		t.Errorf("Expected the third occurrence unchanged, got %+v", occurrences[1])
+	}
+
+	if err := repo.Delete(ctx, series.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := repo.GetByID(ctx, series.ID); !errors.Is(err, ErrRecurringBookingNotFound) {
+		t.Errorf("Expected ErrRecurringBookingNotFound after delete, got %v", err)
+	}
+}
+
+func TestRecurringBookingRepository_TimeZone(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	repo := NewRecurringBookingRepository(db)
+	ctx := context.Background()
+	seedBookingData(t, NewBookingRepository(db), 1)
+
+	// Daylight saving time starts on 2026-03-08 in New York
+	start := time.Date(2026, 3, 6, 9, 0, 0, 0, loc)
+	if _, err := repo.Create(ctx, 1, &models.CreateRecurringBookingRequest{
+		UserID:   1,
+		StartsAt: start,
+		EndsAt:   start.Add(time.Hour),
+		Timezone: "America/New_York",
+		RRule:    "FREQ=DAILY;COUNT=4",
+	}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	occurrences, err := repo.ListOccurrences(ctx, 1, start, start.AddDate(0, 0, 7))
+	if err != nil {
+		t.Fatalf("ListOccurrences() error = %v", err)
+	}
+	if len(occurrences) != 4 {
+		t.Fatalf("Expected 4 occurrences, got %d", len(occurrences))
+	}
+	for _, occ := range occurrences {
+		if local := occ.StartsAt.In(loc); local.Hour() != 9 {
+			t.Errorf("Expected 09:00 New York time, got %s", local)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"cloudflaredb/internal/models"
+)
+
+// roomTagsSQL and roomAmenitiesSQL check a tag or amenity of the room referenced by rooms.id
+const (
+	roomTagsSQL = `EXISTS (SELECT 1 FROM room_tags rt INNER JOIN tags t ON t.id = rt.tag_id
+	                       WHERE rt.room_id = rooms.id AND t.name = ?)`
+	roomAmenitiesSQL = `EXISTS (SELECT 1 FROM room_amenities ra WHERE ra.room_id = rooms.id AND ra.amenity = ?)`
+)
+
+// setRoomTags replaces the tags of a room, creating tags that do not exist yet.
+// Callers run it in inTx with the write of the room; each statement is idempotent, so repeating
+// an update that failed halfway on D1 converges.
+func setRoomTags(ctx context.Context, ex execer, roomID int64, tags []string) error {
+	tags = normalizeTags(tags)
+
+	if _, err := ex.ExecContext(ctx, `DELETE FROM room_tags WHERE room_id = ?`, roomID); err != nil {
+		return fmt.Errorf("failed to clear room tags: %w", err)
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, len(tags))
+	args := make([]interface{}, len(tags))
+	for i, tag := range tags {
+		placeholders[i] = "(?)"
+		args[i] = tag
+	}
+
+	query := `INSERT INTO tags (name) VALUES ` + strings.Join(placeholders, ", ") + ` ON CONFLICT(name) DO NOTHING`
+	if _, err := ex.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to create tags: %w", err)
+	}
+
+	for i := range placeholders {
+		placeholders[i] = "?"
+	}
+	query = `
+		INSERT INTO room_tags (room_id, tag_id)
+		SELECT ?, id FROM tags WHERE name IN (` + strings.Join(placeholders, ", ") + `)
+	`
+	if _, err := ex.ExecContext(ctx, query, append([]interface{}{roomID}, args...)...); err != nil {
+		return fmt.Errorf("failed to tag room: %w", err)
+	}
+
+	return nil
+}
+
+// setRoomAmenities replaces the amenities of a room
+func setRoomAmenities(ctx context.Context, ex execer, roomID int64, amenities []string) error {
+	amenities = slices.Compact(slices.Sorted(slices.Values(amenities)))
+
+	if _, err := ex.ExecContext(ctx, `DELETE FROM room_amenities WHERE room_id = ?`, roomID); err != nil {
+		return fmt.Errorf("failed to clear room amenities: %w", err)
+	}
+	if len(amenities) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, len(amenities))
+	args := make([]interface{}, 0, 2*len(amenities))
+	for i, amenity := range amenities {
+		placeholders[i] = "(?, ?)"
+		args = append(args, roomID, amenity)
+	}
+
+	query := `INSERT INTO room_amenities (room_id, amenity) VALUES ` + strings.Join(placeholders, ", ")
+	if _, err := ex.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to set room amenities: %w", classifyError(err))
+	}
+
+	return nil
+}
+
+// loadRoomMetadata fills in the tags and amenities of rooms with one query each
+func (r *RoomRepository) loadRoomMetadata(ctx context.Context, rooms ...*models.Room) error {
+	if len(rooms) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*models.Room, len(rooms))
+	placeholders := make([]string, len(rooms))
+	args := make([]interface{}, len(rooms))
+	for i, room := range rooms {
+		byID[room.ID] = room
+		placeholders[i] = "?"
+		args[i] = room.ID
+	}
+	in := `(` + strings.Join(placeholders, ", ") + `)`
+
+	tagQuery := `
+		SELECT rt.room_id AS room_id, t.name AS value
+		FROM room_tags rt
+		INNER JOIN tags t ON t.id = rt.tag_id
+		WHERE rt.room_id IN ` + in + `
+		ORDER BY t.name
+	`
+	if err := r.loadRoomValues(ctx, tagQuery, args, func(room *models.Room, v string) {
+		room.Tags = append(room.Tags, v)
+	}, byID); err != nil {
+		return fmt.Errorf("failed to load room tags: %w", err)
+	}
+
+	amenityQuery := `
+		SELECT room_id, amenity AS value
+		FROM room_amenities
+		WHERE room_id IN ` + in + `
+		ORDER BY amenity
+	`
+	if err := r.loadRoomValues(ctx, amenityQuery, args, func(room *models.Room, v string) {
+		room.Amenities = append(room.Amenities, v)
+	}, byID); err != nil {
+		return fmt.Errorf("failed to load room amenities: %w", err)
+	}
+
+	return nil
+}
+
+// loadRoomValues runs a query returning (room_id, value) rows and hands each value to add
+func (r *RoomRepository) loadRoomValues(ctx context.Context, query string, args []interface{}, add func(*models.Room, string), byID map[int64]*models.Room) error {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		v, err := scanValues(rows)
+		if err != nil {
+			return err
+		}
+		if room, ok := byID[toInt64(v["room_id"])]; ok {
+			add(room, toString(v["value"]))
+		}
+	}
+
+	return rows.Err()
+}
+
+// addRoomMetadataConditions appends the tag, amenity and attribute filters of f.
+// Every tag, amenity and attribute must match.
+func addRoomMetadataConditions(c *conditions, f RoomFilter) {
+	for _, tag := range normalizeTags(f.Tags) {
+		c.add(roomTagsSQL, tag)
+	}
+	for _, amenity := range f.Amenities {
+		c.add(roomAmenitiesSQL, amenity)
+	}
+
+	keys := make([]string, 0, len(f.Attributes))
+	for key := range f.Attributes {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		// json_extract on both sides compares typed values: numbers, booleans and strings
+		c.add(`json_extract(rooms.attributes, ?) = json_extract(?, '$')`,
+			attributePath(key), attributeLiteral(f.Attributes[key]))
+	}
+}
+
+// attributePath returns the json1 path of a top-level attribute key
+func attributePath(key string) string {
+	return `$."` + key + `"`
+}
+
+// attributeLiteral returns the JSON form of a filter value: JSON numbers, booleans and strings are
+// used as they are, anything else is matched as a string
+func attributeLiteral(value string) string {
+	if json.Valid([]byte(value)) && !strings.HasPrefix(value, "{") && !strings.HasPrefix(value, "[") {
+		return value
+	}
+	quoted, _ := json.Marshal(value)
+	return string(quoted)
+}
+
+// normalizeTags returns tags trimmed, lowercased, sorted and without duplicates or empty names
+func normalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			normalized = append(normalized, tag)
+		}
+	}
+	slices.Sort(normalized)
+	return slices.Compact(normalized)
+}
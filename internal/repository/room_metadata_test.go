@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"cloudflaredb/internal/models"
+)
+
+func TestRoomRepository_Metadata(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	repo := NewRoomRepository(db)
+	ctx := context.Background()
+
+	lab, err := repo.Create(ctx, &models.CreateRoomRequest{
+		Name:       "Lab",
+		Capacity:   4,
+		Tags:       []string{" Quiet", "lab", "quiet"},
+		Amenities:  []string{models.AmenityWhiteboard, models.AmenityProjector},
+		Attributes: json.RawMessage(`{"floor": 3, "building": "north", "accessible": true}`),
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if !slices.Equal(lab.Tags, []string{"lab", "quiet"}) {
+		t.Errorf("Expected normalized tags, got %v", lab.Tags)
+	}
+	if !slices.Equal(lab.Amenities, []string{models.AmenityProjector, models.AmenityWhiteboard}) {
+		t.Errorf("Expected sorted amenities, got %v", lab.Amenities)
+	}
+
+	hall, err := repo.Create(ctx, &models.CreateRoomRequest{Name: "Hall", Capacity: 50, Tags: []string{"quiet"}})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if string(hall.Attributes) != "{}" || hall.Amenities != nil {
+		t.Errorf("Expected empty metadata, got %+v", hall)
+	}
+
+	tests := []struct {
+		name   string
+		filter RoomFilter
+		want   []string
+	}{
+		{"one tag", RoomFilter{Tags: []string{"QUIET"}}, []string{"Hall", "Lab"}},
+		{"all tags", RoomFilter{Tags: []string{"quiet", "lab"}}, []string{"Lab"}},
+		{"amenity", RoomFilter{Amenities: []string{models.AmenityProjector}}, []string{"Lab"}},
+		{"missing amenity", RoomFilter{Amenities: []string{models.AmenityVideo}}, nil},
+		{"number attribute", RoomFilter{Attributes: map[string]string{"floor": "3"}}, []string{"Lab"}},
+		{"string attribute", RoomFilter{Attributes: map[string]string{"building": "north"}}, []string{"Lab"}},
+		{"boolean attribute", RoomFilter{Attributes: map[string]string{"accessible": "true"}}, []string{"Lab"}},
+		{"other value", RoomFilter{Attributes: map[string]string{"floor": "4"}}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rooms, err := repo.List(ctx, tt.filter, ListOptions{Sort: []SortField{{Column: "name"}}, Limit: 10})
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			var names []string
+			for _, room := range rooms {
+				names = append(names, room.Name)
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, names)
+			}
+		})
+	}
+
+	// Patches replace present metadata and keep the rest
+	updated, err := repo.Patch(ctx, lab.ID, 0, &models.PatchRoomRequest{Tags: models.Null[[]string](), Amenities: models.Set([]string{models.AmenityVideo})})
+	if err != nil {
+		t.Fatalf("Patch() error = %v", err)
+	}
+	if updated.Tags != nil || !slices.Equal(updated.Amenities, []string{models.AmenityVideo}) {
+		t.Errorf("Unexpected metadata after patch: %+v", updated)
+	}
+	var attributes map[string]interface{}
+	if err := json.Unmarshal(updated.Attributes, &attributes); err != nil || attributes["building"] != "north" {
+		t.Errorf("Expected attributes to be kept, got %s", updated.Attributes)
+	}
+
+	// Attributes are merged, with null removing a key
+	updated, err = repo.Patch(ctx, lab.ID, 0, &models.PatchRoomRequest{Attributes: models.Set(json.RawMessage(`{"floor": 1, "building": null}`))})
+	if err != nil {
+		t.Fatalf("Patch() error = %v", err)
+	}
+	attributes = nil
+	if err := json.Unmarshal(updated.Attributes, &attributes); err != nil || len(attributes) != 2 || attributes["floor"] != 1.0 || attributes["accessible"] != true {
+		t.Errorf("Unexpected attributes after merging: %s", updated.Attributes)
+	}
+
+	// Updates replace the whole room, clearing what is left out
+	updated, err = repo.Update(ctx, lab.ID, 0, &models.UpdateRoomRequest{Name: "Lab", Capacity: 4, Attributes: json.RawMessage(`{"floor": 2}`)})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if string(updated.Attributes) != `{"floor": 2}` || updated.Amenities != nil {
+		t.Errorf("Unexpected room after replacing it: %+v", updated)
+	}
+}
+
+func TestRoomRepository_MetadataWriteIsAtomic(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	repo := NewRoomRepository(db)
+	ctx := context.Background()
+
+	// The amenity fails the CHECK constraint after the room and its tags were written
+	if _, err := repo.Create(ctx, &models.CreateRoomRequest{
+		Name:      "Sauna",
+		Capacity:  4,
+		Tags:      []string{"hot"},
+		Amenities: []string{"sauna"},
+	}); err == nil {
+		t.Fatal("Expected Create() to fail on an unknown amenity")
+	}
+
+	var rooms, tags int
+	if err := db.QueryRow(`SELECT (SELECT COUNT(*) FROM rooms), (SELECT COUNT(*) FROM tags)`).Scan(&rooms, &tags); err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	if rooms != 0 || tags != 0 {
+		t.Errorf("Expected the failed create to leave nothing behind, got %d rooms and %d tags", rooms, tags)
+	}
+
+	lab, err := repo.Create(ctx, &models.CreateRoomRequest{Name: "Lab", Capacity: 4, Tags: []string{"lab"}})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	if _, err := repo.Patch(ctx, lab.ID, lab.Version, &models.PatchRoomRequest{
+		Name:      models.Set("Sauna"),
+		Tags:      models.Set([]string{"hot"}),
+		Amenities: models.Set([]string{"sauna"}),
+	}); err == nil {
+		t.Fatal("Expected Patch() to fail on an unknown amenity")
+	}
+
+	got, err := repo.GetByID(ctx, lab.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if got.Name != "Lab" || got.Version != lab.Version || !slices.Equal(got.Tags, []string{"lab"}) {
+		t.Errorf("Expected the failed patch to leave the room unchanged, got %+v", got)
+	}
+}
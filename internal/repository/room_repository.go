@@ -3,15 +3,41 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
+	"cloudflaredb/internal/events"
 	"cloudflaredb/internal/models"
 )
 
+// roomUsersSQL selects the users of the room referenced by rooms.id: those assigned directly
+// and the members of the groups assigned to it
+const roomUsersSQL = `SELECT user_rooms.user_id FROM user_rooms WHERE user_rooms.room_id = rooms.id
+		UNION
+		SELECT gm.user_id FROM room_groups rg INNER JOIN group_members gm ON gm.group_id = rg.group_id
+		WHERE rg.room_id = rooms.id`
+
+// roomOccupancySQL counts the users of the room referenced by rooms.id; a user reaching the
+// room both directly and through groups takes a single seat
+const roomOccupancySQL = `(SELECT COUNT(*) FROM (` + roomUsersSQL + `))`
+
+// roomWaitlistedSQL reports whether the room referenced by rooms.id has a waitlist
+const roomWaitlistedSQL = `EXISTS (SELECT 1 FROM room_waitlist WHERE room_waitlist.room_id = rooms.id)`
+
+// roomHasOwnerSQL reports whether the room referenced by rooms.id has an owner
+const roomHasOwnerSQL = `EXISTS (SELECT 1 FROM user_rooms WHERE user_rooms.room_id = rooms.id AND user_rooms.role = 'owner')`
+
+// keepsOwnerSQL reports whether the room of the user_rooms row being changed keeps an owner
+// when that row stops being one
+const keepsOwnerSQL = `(user_rooms.role != 'owner'
+		OR EXISTS (SELECT 1 FROM user_rooms o
+		           WHERE o.room_id = user_rooms.room_id AND o.role = 'owner' AND o.user_id != user_rooms.user_id))`
+
 // RoomRepository handles database operations for rooms
 type RoomRepository struct {
-	db *sql.DB
+	db     *sql.DB
+	events events.Publisher
 }
 
 // NewRoomRepository creates a new room repository
@@ -19,22 +45,54 @@ func NewRoomRepository(db *sql.DB) *RoomRepository {
 	return &RoomRepository{db: db}
 }
 
-// Create inserts a new room into the database
+// Create inserts a new room into the database along with its tags and amenities, in one
+// transaction on SQLite (see inTx)
 func (r *RoomRepository) Create(ctx context.Context, req *models.CreateRoomRequest) (*models.Room, error) {
+	var floorID interface{}
+	if req.FloorID != 0 {
+		if err := checkExists(ctx, r.db, "floors", req.FloorID, ErrFloorNotFound); err != nil {
+			return nil, err
+		}
+		floorID = req.FloorID
+	}
+
 	query := `
-		INSERT INTO rooms (name, description, capacity, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?)
+		INSERT INTO rooms (name, description, capacity, floor_id, attributes, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
 	`
 
-	now := time.Now()
-	result, err := r.db.ExecContext(ctx, query, req.Name, req.Description, req.Capacity, now, now)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create room: %w", err)
+	attributes := "{}"
+	if len(req.Attributes) > 0 {
+		attributes = string(req.Attributes)
 	}
 
-	id, err := result.LastInsertId()
+	now := time.Now()
+	var id int64
+	err := inTx(ctx, r.db, func(ex execer) error {
+		result, err := ex.ExecContext(ctx, query, req.Name, req.Description, req.Capacity, floorID, attributes, now, now)
+		if err != nil {
+			return fmt.Errorf("failed to create room: %w", classifyError(err))
+		}
+
+		id, err = result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get last insert id: %w", err)
+		}
+
+		if len(req.Tags) > 0 {
+			if err := setRoomTags(ctx, ex, id, req.Tags); err != nil {
+				return err
+			}
+		}
+		if len(req.Amenities) > 0 {
+			if err := setRoomAmenities(ctx, ex, id, req.Amenities); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+		return nil, err
 	}
 
 	// Fetch the created room
@@ -56,28 +114,106 @@ func (r *RoomRepository) GetByID(ctx context.Context, id int64) (*models.Room, e
 	defer rows.Close()
 
 	if !rows.Next() {
-		return nil, fmt.Errorf("room not found")
+		return nil, ErrRoomNotFound
 	}
 
 	room := &models.Room{}
-	err = scanRoom(rows, &room.ID, &room.Name, &room.Description, &room.Capacity, &room.CreatedAt, &room.UpdatedAt)
+	err = scanRoom(rows, room)
 	if err != nil {
 		return nil, fmt.Errorf("failed to scan room: %w", err)
 	}
+	rows.Close()
+
+	if err := r.loadRoomMetadata(ctx, room); err != nil {
+		return nil, err
+	}
 
 	return room, nil
 }
 
-// List retrieves all rooms with pagination
-func (r *RoomRepository) List(ctx context.Context, limit, offset int) ([]*models.Room, error) {
+// List retrieves the rooms matching filter, sorted and paginated according to opts
+func (r *RoomRepository) List(ctx context.Context, filter RoomFilter, opts ListOptions) ([]*models.Room, error) {
+	c := roomConditions(filter)
+	tail, err := listClauses(c, opts, RoomSortColumns)
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
 		SELECT *
 		FROM rooms
-		ORDER BY created_at DESC
-		LIMIT ? OFFSET ?
+		` + c.where() + `
+		` + tail
+
+	return r.queryRooms(ctx, query, c.args...)
+}
+
+// Count returns the number of rooms matching filter
+func (r *RoomRepository) Count(ctx context.Context, filter RoomFilter) (int, error) {
+	c := roomConditions(filter)
+	query := `SELECT COUNT(*) FROM rooms ` + c.where()
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, c.args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count rooms: %w", err)
+	}
+
+	return count, nil
+}
+
+// Available returns up to limit rooms matching filter with at least minFreeSeats unassigned seats, best fit first:
+// rooms whose free seats exceed the request by the least come first, then smaller rooms.
+func (r *RoomRepository) Available(ctx context.Context, minFreeSeats, limit int, filter RoomFilter) ([]*models.RoomAvailability, error) {
+	c := roomConditions(filter)
+	c.add("rooms.capacity - "+roomOccupancySQL+" >= ?", minFreeSeats)
+
+	query := `
+		SELECT rooms.*, ` + roomOccupancySQL + ` AS occupancy
+		FROM rooms
+		` + c.where() + `
+		ORDER BY rooms.capacity - occupancy, rooms.capacity, rooms.id
+		LIMIT ?
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	rows, err := r.db.QueryContext(ctx, query, append(c.args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query available rooms: %w", err)
+	}
+	defer rows.Close()
+
+	var rooms []*models.RoomAvailability
+	for rows.Next() {
+		v, err := scanValues(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan room: %w", err)
+		}
+
+		room := &models.RoomAvailability{
+			Room:      roomFromValues(v),
+			Occupancy: int(toInt64(v["occupancy"])),
+		}
+		room.FreeSeats = room.Capacity - room.Occupancy
+		rooms = append(rooms, room)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	embedded := make([]*models.Room, len(rooms))
+	for i, room := range rooms {
+		embedded[i] = &room.Room
+	}
+	if err := r.loadRoomMetadata(ctx, embedded...); err != nil {
+		return nil, err
+	}
+
+	return rooms, nil
+}
+
+// queryRooms runs a query returning full room rows and scans them
+func (r *RoomRepository) queryRooms(ctx context.Context, query string, args ...interface{}) ([]*models.Room, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list rooms: %w", err)
 	}
@@ -86,7 +222,7 @@ func (r *RoomRepository) List(ctx context.Context, limit, offset int) ([]*models
 	var rooms []*models.Room
 	for rows.Next() {
 		room := &models.Room{}
-		err := scanRoom(rows, &room.ID, &room.Name, &room.Description, &room.Capacity, &room.CreatedAt, &room.UpdatedAt)
+		err := scanRoom(rows, room)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan room: %w", err)
 		}
@@ -97,43 +233,185 @@ func (r *RoomRepository) List(ctx context.Context, limit, offset int) ([]*models
 		return nil, fmt.Errorf("rows iteration error: %w", err)
 	}
 
+	if err := r.loadRoomMetadata(ctx, rooms...); err != nil {
+		return nil, err
+	}
+
 	return rooms, nil
 }
 
-// Update updates a room's information
-func (r *RoomRepository) Update(ctx context.Context, id int64, req *models.UpdateRoomRequest) (*models.Room, error) {
+// roomUpdate describes the changes of a room update besides its column assignments
+type roomUpdate struct {
+	// capacity is the new capacity, checked against the occupancy; 0 when unchanged
+	capacity int
+	// floorID is a floor that must exist; 0 when unchanged or cleared
+	floorID int64
+	// tags and amenities replace the current values when not nil
+	tags      []string
+	amenities []string
+}
+
+// Update replaces a room's information. Fields left out of req are reset: the description is
+// emptied and the floor, tags, amenities and attributes are cleared. A non-zero version must match
+// the current one, otherwise ErrVersionMismatch is returned; the check is part of the UPDATE statement.
+func (r *RoomRepository) Update(ctx context.Context, id, version int64, req *models.UpdateRoomRequest) (*models.Room, error) {
+	var floorID interface{}
+	if req.FloorID != 0 {
+		floorID = req.FloorID
+	}
+	attributes := "{}"
+	if len(req.Attributes) > 0 {
+		attributes = string(req.Attributes)
+	}
+
+	var set assignments
+	set.set("name", req.Name)
+	set.set("description", req.Description)
+	set.set("capacity", req.Capacity)
+	set.set("floor_id", floorID)
+	set.set("attributes", attributes)
+
+	u := roomUpdate{capacity: req.Capacity, floorID: req.FloorID, tags: req.Tags, amenities: req.Amenities}
+	if u.tags == nil {
+		u.tags = []string{}
+	}
+	if u.amenities == nil {
+		u.amenities = []string{}
+	}
+	return r.update(ctx, id, version, &set, u)
+}
+
+// Patch applies a merge patch to a room, updating only the fields it contains. Null clears the
+// description, floor, tags, amenities or attributes; attributes are merged key by key with json_patch,
+// which implements the same merge patch rules. A non-zero version must match the current one, as for Update.
+func (r *RoomRepository) Patch(ctx context.Context, id, version int64, req *models.PatchRoomRequest) (*models.Room, error) {
+	var set assignments
+	var u roomUpdate
+	if req.Name.Set {
+		set.set("name", req.Name.Value)
+	}
+	if req.Description.Set {
+		set.set("description", req.Description.Value)
+	}
+	if req.Capacity.Set {
+		set.set("capacity", req.Capacity.Value)
+		u.capacity = req.Capacity.Value
+	}
+	if req.FloorID.Set {
+		var floorID interface{}
+		if req.FloorID.Value != 0 {
+			floorID = req.FloorID.Value
+		}
+		set.set("floor_id", floorID)
+		u.floorID = req.FloorID.Value
+	}
+	if req.Attributes.Set {
+		if req.Attributes.Null {
+			set.set("attributes", "{}")
+		} else {
+			set.setExpr("attributes = json_patch(attributes, ?)", string(req.Attributes.Value))
+		}
+	}
+	if req.Tags.Set {
+		u.tags = req.Tags.Value
+		if u.tags == nil {
+			u.tags = []string{}
+		}
+	}
+	if req.Amenities.Set {
+		u.amenities = req.Amenities.Value
+		if u.amenities == nil {
+			u.amenities = []string{}
+		}
+	}
+	return r.update(ctx, id, version, &set, u)
+}
+
+// update assigns the columns in set, bumps updated_at and the version, and applies the rest of u. Tags and amenities
+// are replaced in the same transaction on SQLite (see inTx). Lowering the capacity below the number of assigned users is
+// rejected with ErrCapacityBelowOccupancy; the check runs in the same statement as the update so
+// concurrent assignments cannot slip in between. Raising the capacity promotes waitlisted users to
+// the new seats.
+func (r *RoomRepository) update(ctx context.Context, id, version int64, set *assignments, u roomUpdate) (*models.Room, error) {
+	if u.floorID != 0 {
+		if err := checkExists(ctx, r.db, "floors", u.floorID, ErrFloorNotFound); err != nil {
+			return nil, err
+		}
+	}
+
+	set.set("updated_at", time.Now())
+	set.setExpr("version = version + 1")
 	query := `
 		UPDATE rooms
-		SET name = COALESCE(NULLIF(?, ''), name),
-		    description = COALESCE(NULLIF(?, ''), description),
-		    capacity = CASE WHEN ? > 0 THEN ? ELSE capacity END,
-		    updated_at = ?
+		SET ` + set.sql() + `
 		WHERE id = ?
+		  AND (? = 0 OR version = ?)
+		  AND (? <= 0 OR ? >= ` + roomOccupancySQL + `)
 	`
 
-	now := time.Now()
-	result, err := r.db.ExecContext(ctx, query, req.Name, req.Description, req.Capacity, req.Capacity, now, id)
+	var updated bool
+	err := inTx(ctx, r.db, func(ex execer) error {
+		result, err := ex.ExecContext(ctx, query, append(set.args, id, version, version, u.capacity, u.capacity)...)
+		if err != nil {
+			return fmt.Errorf("failed to update room: %w", classifyError(err))
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return nil
+		}
+		updated = true
+
+		if u.tags != nil {
+			if err := setRoomTags(ctx, ex, id, u.tags); err != nil {
+				return err
+			}
+		}
+		if u.amenities != nil {
+			if err := setRoomAmenities(ctx, ex, id, u.amenities); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to update room: %w", err)
+		return nil, err
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	if !updated {
+		// The room does not exist, has changed or is too small for the new capacity
+		room, err := r.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if version != 0 && room.Version != version {
+			return nil, ErrVersionMismatch
+		}
+		return nil, ErrCapacityBelowOccupancy
 	}
 
-	if rowsAffected == 0 {
-		return nil, fmt.Errorf("room not found")
+	if u.capacity > 0 {
+		if _, err := r.promoteWaitlisted(ctx, id); err != nil {
+			return nil, err
+		}
 	}
 
 	return r.GetByID(ctx, id)
 }
 
-// Delete removes a room from the database
-func (r *RoomRepository) Delete(ctx context.Context, id int64) error {
-	query := `DELETE FROM rooms WHERE id = ?`
+// Delete removes a room from the database. A non-zero version must match the current one,
+// otherwise ErrVersionMismatch is returned.
+// The room's memberships, waitlist, bookings, recurring bookings, tags, amenities, groups,
+// invitations and join requests go with it. D1 cascades the deletion to these rows, but SQLite only
+// does when foreign keys are enforced, so they are deleted explicitly; the waitlist goes first so
+// that removing the memberships promotes nobody.
+func (r *RoomRepository) Delete(ctx context.Context, id, version int64) error {
+	query := `DELETE FROM rooms WHERE id = ? AND (? = 0 OR version = ?)`
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	result, err := r.db.ExecContext(ctx, query, id, version, version)
 	if err != nil {
 		return fmt.Errorf("failed to delete room: %w", err)
 	}
@@ -144,13 +422,31 @@ func (r *RoomRepository) Delete(ctx context.Context, id int64) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("room not found")
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return err
+		}
+		return ErrVersionMismatch
+	}
+
+	exceptionsQuery := `DELETE FROM recurring_booking_exceptions WHERE series_id IN (SELECT id FROM recurring_bookings WHERE room_id = ?)`
+	if _, err := r.db.ExecContext(ctx, exceptionsQuery, id); err != nil {
+		return fmt.Errorf("failed to remove room from recurring_booking_exceptions: %w", err)
+	}
+
+	for _, table := range []string{
+		"room_waitlist", "user_rooms", "waitlist_promotions", "bookings", "recurring_bookings",
+		"room_tags", "room_amenities", "room_groups", "room_invitations", "room_join_requests",
+	} {
+		if _, err := r.db.ExecContext(ctx, `DELETE FROM `+table+` WHERE room_id = ?`, id); err != nil {
+			return fmt.Errorf("failed to remove room from %s: %w", table, err)
+		}
 	}
 
 	return nil
 }
 
-// GetRoomWithUsers retrieves a room with all its assigned users
+// GetRoomWithUsers retrieves a room with all its users: those assigned directly, with their roles,
+// and the members of the groups assigned to it
 func (r *RoomRepository) GetRoomWithUsers(ctx context.Context, roomID int64) (*models.RoomWithUsers, error) {
 	// Get room details
 	room, err := r.GetByID(ctx, roomID)
@@ -158,29 +454,55 @@ func (r *RoomRepository) GetRoomWithUsers(ctx context.Context, roomID int64) (*m
 		return nil, err
 	}
 
-	// Get all users assigned to this room
+	// One row per way a user reaches the room: their direct assignment, then each of their groups
 	query := `
-		SELECT u.*
+		SELECT u.id, u.email, u.name, u.created_at, u.updated_at, u.version, ur.role AS role, NULL AS group_id
 		FROM users u
 		INNER JOIN user_rooms ur ON u.id = ur.user_id
 		WHERE ur.room_id = ?
-		ORDER BY u.name
+		UNION ALL
+		SELECT u.id, u.email, u.name, u.created_at, u.updated_at, u.version, NULL AS role, rg.group_id AS group_id
+		FROM users u
+		INNER JOIN group_members gm ON gm.user_id = u.id
+		INNER JOIN room_groups rg ON rg.group_id = gm.group_id
+		WHERE rg.room_id = ?
+		ORDER BY name, id, group_id
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, roomID)
+	rows, err := r.db.QueryContext(ctx, query, roomID, roomID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get room users: %w", err)
 	}
 	defer rows.Close()
 
-	var users []*models.User
+	var users []*models.RoomMember
 	for rows.Next() {
-		user := &models.User{}
-		err := scanUser(rows, &user.ID, &user.Email, &user.Name, &user.CreatedAt, &user.UpdatedAt)
+		v, err := scanValues(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan user: %w", err)
 		}
-		users = append(users, user)
+
+		id := toInt64(v["id"])
+		if len(users) == 0 || users[len(users)-1].ID != id {
+			users = append(users, &models.RoomMember{
+				User: models.User{
+					ID:        id,
+					Email:     toString(v["email"]),
+					Name:      toString(v["name"]),
+					CreatedAt: parseTimeValue(v["created_at"]),
+					UpdatedAt: parseTimeValue(v["updated_at"]),
+					Version:   toInt64(v["version"]),
+				},
+			})
+		}
+
+		member := users[len(users)-1]
+		if v["group_id"] == nil {
+			member.Direct = true
+			member.Role = toString(v["role"])
+		} else {
+			member.GroupIDs = append(member.GroupIDs, toInt64(v["group_id"]))
+		}
 	}
 
 	if err := rows.Err(); err != nil {
@@ -193,38 +515,142 @@ func (r *RoomRepository) GetRoomWithUsers(ctx context.Context, roomID int64) (*m
 	}, nil
 }
 
-// AssignUserToRoom assigns a user to a room (user can have multiple rooms)
-func (r *RoomRepository) AssignUserToRoom(ctx context.Context, userID, roomID int64) error {
-	// Check if assignment already exists
+// AssignUserToRoom assigns a user to a room (user can have multiple rooms) with the given role.
+// An empty role makes the user the owner of a room without one, and a member otherwise.
+// The capacity check and the insert run as a single statement, so concurrent
+// assignments can never push a room above its capacity. A room with a waitlist
+// is reported as full, so that direct assignments cannot jump the queue. A user who
+// already reaches the room through a group keeps their seat and can always be assigned.
+// The statement also checks that the user exists, since foreign keys are not enforced on SQLite.
+func (r *RoomRepository) AssignUserToRoom(ctx context.Context, userID, roomID int64, role string) (*models.UserRoom, error) {
+	insertQuery := `
+		INSERT INTO user_rooms (user_id, room_id, role, created_at)
+		SELECT ?, rooms.id, COALESCE(NULLIF(?, ''), CASE WHEN ` + roomHasOwnerSQL + ` THEN 'member' ELSE 'owner' END), ?
+		FROM rooms
+		WHERE rooms.id = ?
+		  AND EXISTS (SELECT 1 FROM users WHERE id = ?)
+		  AND NOT EXISTS (SELECT 1 FROM user_rooms WHERE user_id = ? AND room_id = rooms.id)
+		  AND ((` + roomOccupancySQL + ` < rooms.capacity
+		        AND NOT ` + roomWaitlistedSQL + `)
+		       OR ? IN (` + roomUsersSQL + `))
+	`
+
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx, insertQuery, userID, role, now, roomID, userID, userID, userID)
+	if err != nil {
+		err = classifyError(err)
+		if errors.Is(err, ErrConflict) {
+			return nil, ErrAlreadyAssigned
+		}
+		return nil, fmt.Errorf("failed to assign user to room: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected > 0 {
+		return r.GetMembership(ctx, roomID, userID)
+	}
+
+	// Nothing was inserted: find out why
+	if _, err := r.GetByID(ctx, roomID); err != nil {
+		return nil, err
+	}
+	if err := checkExists(ctx, r.db, "users", userID, ErrUserNotFound); err != nil {
+		return nil, err
+	}
+
 	checkQuery := `SELECT COUNT(*) FROM user_rooms WHERE user_id = ? AND room_id = ?`
 	var count int
-	err := r.db.QueryRowContext(ctx, checkQuery, userID, roomID).Scan(&count)
-	if err != nil {
-		return fmt.Errorf("failed to check existing assignment: %w", err)
+	if err := r.db.QueryRowContext(ctx, checkQuery, userID, roomID).Scan(&count); err != nil {
+		return nil, fmt.Errorf("failed to check existing assignment: %w", err)
 	}
 
 	if count > 0 {
-		return fmt.Errorf("user already assigned to this room")
+		return nil, ErrAlreadyAssigned
 	}
 
-	// Assign user to room
-	insertQuery := `
-		INSERT INTO user_rooms (user_id, room_id, created_at)
-		VALUES (?, ?, ?)
+	return nil, ErrRoomFull
+}
+
+// GetMembership retrieves the assignment of a user to a room
+func (r *RoomRepository) GetMembership(ctx context.Context, roomID, userID int64) (*models.UserRoom, error) {
+	query := `
+		SELECT id, user_id, room_id, role, created_at
+		FROM user_rooms
+		WHERE room_id = ? AND user_id = ?
 	`
 
-	now := time.Now()
-	_, err = r.db.ExecContext(ctx, insertQuery, userID, roomID, now)
+	rows, err := r.db.QueryContext(ctx, query, roomID, userID)
 	if err != nil {
-		return fmt.Errorf("failed to assign user to room: %w", err)
+		return nil, fmt.Errorf("failed to query membership: %w", err)
 	}
+	defer rows.Close()
 
-	return nil
+	if !rows.Next() {
+		return nil, ErrNotAssigned
+	}
+
+	v, err := scanValues(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan membership: %w", err)
+	}
+
+	return &models.UserRoom{
+		ID:        toInt64(v["id"]),
+		UserID:    toInt64(v["user_id"]),
+		RoomID:    toInt64(v["room_id"]),
+		Role:      toString(v["role"]),
+		CreatedAt: parseTimeValue(v["created_at"]),
+	}, nil
+}
+
+// UpdateMembershipRole changes the role of a user in a room.
+// Demoting the last owner of a room is rejected with ErrLastOwner; the check runs in the
+// same statement as the update, so two owners demoting each other cannot both succeed.
+func (r *RoomRepository) UpdateMembershipRole(ctx context.Context, roomID, userID int64, role string) (*models.UserRoom, error) {
+	query := `
+		UPDATE user_rooms
+		SET role = ?
+		WHERE room_id = ? AND user_id = ?
+		  AND (? = 'owner' OR ` + keepsOwnerSQL + `)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, role, roomID, userID, role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update membership: %w", classifyError(err))
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		// Either the user is not in the room or they are its last owner
+		if _, err := r.GetMembership(ctx, roomID, userID); err != nil {
+			return nil, err
+		}
+		return nil, ErrLastOwner
+	}
+
+	return r.GetMembership(ctx, roomID, userID)
 }
 
-// RemoveUserFromRoom removes a user from a specific room
+// RemoveUserFromRoom removes a user from a specific room and gives the freed seat to the
+// longest-waiting user on the room's waitlist.
+// The last owner of a room can only leave once everyone else has; otherwise ErrLastOwner is returned.
+// The user_rooms_promote_after_delete trigger promotes as part of the DELETE, on SQLite and D1
+// alike, so the seat cannot be lost between the two; only the events are published afterwards.
 func (r *RoomRepository) RemoveUserFromRoom(ctx context.Context, userID, roomID int64) error {
-	query := `DELETE FROM user_rooms WHERE user_id = ? AND room_id = ?`
+	query := `
+		DELETE FROM user_rooms
+		WHERE user_id = ? AND room_id = ?
+		  AND (` + keepsOwnerSQL + `
+		       OR NOT EXISTS (SELECT 1 FROM user_rooms m WHERE m.room_id = user_rooms.room_id AND m.user_id != user_rooms.user_id))
+	`
 
 	result, err := r.db.ExecContext(ctx, query, userID, roomID)
 	if err != nil {
@@ -237,14 +663,30 @@ func (r *RoomRepository) RemoveUserFromRoom(ctx context.Context, userID, roomID
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("user not assigned to this room")
+		if _, err := r.GetMembership(ctx, roomID, userID); err != nil {
+			return err
+		}
+		return ErrLastOwner
 	}
 
-	return nil
+	_, err = r.publishPromotions(ctx, roomID)
+	return err
 }
 
-// RemoveUserFromAllRooms removes a user from all their assigned rooms
+// RemoveUserFromAllRooms removes a user from all their assigned rooms and waitlists,
+// and gives each freed seat to the longest-waiting user of that room.
+// Rooms the user was the last owner of pass to their longest-standing remaining member.
+// Both are done by the user_rooms_promote_after_delete trigger as part of the DELETE.
 func (r *RoomRepository) RemoveUserFromAllRooms(ctx context.Context, userID int64) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM room_waitlist WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("failed to remove user from waitlists: %w", err)
+	}
+
+	roomIDs, err := r.userRoomIDs(ctx, userID)
+	if err != nil {
+		return err
+	}
+
 	query := `DELETE FROM user_rooms WHERE user_id = ?`
 
 	result, err := r.db.ExecContext(ctx, query, userID)
@@ -258,23 +700,88 @@ func (r *RoomRepository) RemoveUserFromAllRooms(ctx context.Context, userID int6
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("user not assigned to any rooms")
+		return ErrNotAssigned
+	}
+
+	for _, roomID := range roomIDs {
+		if _, err := r.publishPromotions(ctx, roomID); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-// GetUserRooms retrieves all rooms assigned to a user
+// userRoomIDs returns the IDs of the rooms a user is assigned to
+func (r *RoomRepository) userRoomIDs(ctx context.Context, userID int64) ([]int64, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT room_id FROM user_rooms WHERE user_id = ? ORDER BY room_id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user rooms: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan room id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return ids, nil
+}
+
+// seatRoomIDs returns the IDs of the rooms a user has a seat in, directly or through a group
+func (r *RoomRepository) seatRoomIDs(ctx context.Context, userID int64) ([]int64, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT room_id FROM user_rooms WHERE user_id = ?
+		UNION
+		SELECT rg.room_id FROM room_groups rg
+		INNER JOIN group_members gm ON gm.group_id = rg.group_id
+		WHERE gm.user_id = ?
+		ORDER BY room_id
+	`, userID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user rooms: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan room id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return ids, nil
+}
+
+// GetUserRooms retrieves all rooms a user reaches, directly or through their groups
 func (r *RoomRepository) GetUserRooms(ctx context.Context, userID int64) ([]*models.Room, error) {
 	query := `
 		SELECT r.*
 		FROM rooms r
-		INNER JOIN user_rooms ur ON r.id = ur.room_id
-		WHERE ur.user_id = ?
+		WHERE r.id IN (
+			SELECT room_id FROM user_rooms WHERE user_id = ?
+			UNION
+			SELECT rg.room_id FROM room_groups rg INNER JOIN group_members gm ON gm.group_id = rg.group_id
+			WHERE gm.user_id = ?
+		)
 		ORDER BY r.name
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, userID)
+	rows, err := r.db.QueryContext(ctx, query, userID, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user rooms: %w", err)
 	}
@@ -283,7 +790,7 @@ func (r *RoomRepository) GetUserRooms(ctx context.Context, userID int64) ([]*mod
 	var rooms []*models.Room
 	for rows.Next() {
 		room := &models.Room{}
-		err := scanRoom(rows, &room.ID, &room.Name, &room.Description, &room.Capacity, &room.CreatedAt, &room.UpdatedAt)
+		err := scanRoom(rows, room)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan room: %w", err)
 		}
@@ -294,5 +801,68 @@ func (r *RoomRepository) GetUserRooms(ctx context.Context, userID int64) ([]*mod
 		return nil, fmt.Errorf("rows iteration error: %w", err)
 	}
 
+	if err := r.loadRoomMetadata(ctx, rooms...); err != nil {
+		return nil, err
+	}
+
 	return rooms, nil
 }
+
+// RoomAssignments retrieves the user assignments of a room, oldest first
+func (r *RoomRepository) RoomAssignments(ctx context.Context, roomID int64) ([]*models.Assignment, error) {
+	if err := checkExists(ctx, r.db, "rooms", roomID, ErrRoomNotFound); err != nil {
+		return nil, err
+	}
+	return r.queryAssignments(ctx, "ur.room_id = ?", roomID)
+}
+
+// UserAssignments retrieves the room assignments of a user, oldest first
+func (r *RoomRepository) UserAssignments(ctx context.Context, userID int64) ([]*models.Assignment, error) {
+	if err := checkExists(ctx, r.db, "users", userID, ErrUserNotFound); err != nil {
+		return nil, err
+	}
+	return r.queryAssignments(ctx, "ur.user_id = ?", userID)
+}
+
+// queryAssignments lists the assignments matching condition, joined with their user and room
+func (r *RoomRepository) queryAssignments(ctx context.Context, condition string, args ...interface{}) ([]*models.Assignment, error) {
+	query := `
+		SELECT ur.id, ur.user_id, u.name AS user_name, u.email AS user_email,
+		       ur.room_id, r.name AS room_name, ur.created_at
+		FROM user_rooms ur
+		INNER JOIN users u ON u.id = ur.user_id
+		INNER JOIN rooms r ON r.id = ur.room_id
+		WHERE ` + condition + `
+		ORDER BY ur.created_at, ur.id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query assignments: %w", err)
+	}
+	defer rows.Close()
+
+	var assignments []*models.Assignment
+	for rows.Next() {
+		v, err := scanValues(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan assignment: %w", err)
+		}
+
+		assignments = append(assignments, &models.Assignment{
+			ID:        toInt64(v["id"]),
+			UserID:    toInt64(v["user_id"]),
+			UserName:  toString(v["user_name"]),
+			UserEmail: toString(v["user_email"]),
+			RoomID:    toInt64(v["room_id"]),
+			RoomName:  toString(v["room_name"]),
+			CreatedAt: parseTimeValue(v["created_at"]),
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return assignments, nil
+}
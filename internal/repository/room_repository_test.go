@@ -3,6 +3,10 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
 	"testing"
 
 	"cloudflaredb/internal/models"
@@ -10,11 +14,17 @@ import (
 	_ "github.com/mattn/go-sqlite3"
 )
 
-// setupTestDBWithRooms creates an in-memory SQLite database with users and rooms tables
+// setupTestDBWithRooms creates an in-memory SQLite database with the users, rooms and booking tables
 func setupTestDBWithRooms(t *testing.T) *sql.DB {
 	t.Helper()
+	return openTestDBWithRooms(t, ":memory:")
+}
+
+// openTestDBWithRooms opens the given SQLite DSN and creates the users, rooms and booking tables
+func openTestDBWithRooms(t *testing.T, dsn string) *sql.DB {
+	t.Helper()
 
-	db, err := sql.Open("sqlite3", ":memory:")
+	db, err := sql.Open("sqlite3", dsn)
 	if err != nil {
 		t.Fatalf("Failed to open test database: %v", err)
 	}
@@ -26,7 +36,8 @@ func setupTestDBWithRooms(t *testing.T) *sql.DB {
 		email TEXT NOT NULL UNIQUE,
 		name TEXT NOT NULL,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		version INTEGER NOT NULL DEFAULT 1
 	);
 	CREATE INDEX idx_users_email ON users(email);
 
@@ -36,8 +47,56 @@ func setupTestDBWithRooms(t *testing.T) *sql.DB {
 		description TEXT NOT NULL DEFAULT '',
 		capacity INTEGER NOT NULL DEFAULT 1,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		attributes TEXT NOT NULL DEFAULT '{}' CHECK (json_valid(attributes) AND json_type(attributes) = 'object'),
+		floor_id INTEGER,
+		version INTEGER NOT NULL DEFAULT 1
+	);
+
+	CREATE TABLE sites (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		address TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
+
+	CREATE TABLE buildings (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		site_id INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(site_id, name)
+	);
+
+	CREATE TABLE floors (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		building_id INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		level INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(building_id, level)
+	);
+
+	CREATE TABLE tags (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE room_tags (
+		room_id INTEGER NOT NULL,
+		tag_id INTEGER NOT NULL,
+		PRIMARY KEY (room_id, tag_id)
+	);
+
+	CREATE TABLE room_amenities (
+		room_id INTEGER NOT NULL,
+		amenity TEXT NOT NULL CHECK (amenity IN ('projector', 'whiteboard', 'video')),
+		PRIMARY KEY (room_id, amenity)
+	);
 	CREATE INDEX idx_rooms_name ON rooms(name);
 
 	CREATE TABLE user_rooms (
@@ -45,12 +104,143 @@ func setupTestDBWithRooms(t *testing.T) *sql.DB {
 		user_id INTEGER NOT NULL,
 		room_id INTEGER NOT NULL,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		role TEXT NOT NULL DEFAULT 'member' CHECK (role IN ('owner', 'moderator', 'member')),
 		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
 		FOREIGN KEY (room_id) REFERENCES rooms(id) ON DELETE CASCADE,
 		UNIQUE(user_id, room_id)
 	);
 	CREATE INDEX idx_user_rooms_user_id ON user_rooms(user_id);
 	CREATE INDEX idx_user_rooms_room_id ON user_rooms(room_id);
+
+	CREATE TABLE bookings (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		room_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		title TEXT NOT NULL DEFAULT '',
+		starts_at DATETIME NOT NULL,
+		ends_at DATETIME NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		CHECK (ends_at > starts_at)
+	);
+
+	CREATE TABLE recurring_bookings (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		room_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		title TEXT NOT NULL DEFAULT '',
+		starts_at DATETIME NOT NULL,
+		ends_at DATETIME NOT NULL,
+		timezone TEXT NOT NULL DEFAULT 'UTC',
+		rrule TEXT NOT NULL,
+		last_ends_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE recurring_booking_exceptions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		series_id INTEGER NOT NULL,
+		recurrence_id DATETIME NOT NULL,
+		starts_at DATETIME,
+		ends_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(series_id, recurrence_id)
+	);
+
+	CREATE TABLE groups (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		description TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE group_members (
+		group_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (group_id, user_id)
+	);
+
+	CREATE TABLE room_groups (
+		room_id INTEGER NOT NULL,
+		group_id INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (room_id, group_id)
+	);
+
+	CREATE TABLE room_waitlist (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		room_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(room_id, user_id)
+	);
+
+	CREATE TABLE waitlist_promotions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		room_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		promoted_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TRIGGER user_rooms_dequeue_after_insert AFTER INSERT ON user_rooms BEGIN
+		INSERT INTO waitlist_promotions (room_id, user_id, promoted_at)
+		SELECT room_id, user_id, new.created_at FROM room_waitlist WHERE room_id = new.room_id AND user_id = new.user_id;
+		DELETE FROM room_waitlist WHERE room_id = new.room_id AND user_id = new.user_id;
+	END;
+
+	CREATE TRIGGER user_rooms_promote_after_delete AFTER DELETE ON user_rooms BEGIN
+		UPDATE user_rooms
+		SET role = 'owner'
+		WHERE id = (SELECT MIN(id) FROM user_rooms WHERE room_id = old.room_id)
+		  AND NOT EXISTS (SELECT 1 FROM user_rooms WHERE room_id = old.room_id AND role = 'owner');
+		INSERT INTO user_rooms (user_id, room_id, role, created_at)
+		SELECT w.user_id, w.room_id,
+		       CASE WHEN NOT EXISTS (SELECT 1 FROM user_rooms WHERE room_id = w.room_id)
+		             AND w.id = (SELECT MIN(f.id) FROM room_waitlist f
+		                         WHERE f.room_id = w.room_id
+		                           AND NOT EXISTS (SELECT 1 FROM user_rooms WHERE user_id = f.user_id AND room_id = f.room_id))
+		            THEN 'owner' ELSE 'member' END,
+		       CURRENT_TIMESTAMP
+		FROM room_waitlist w
+		WHERE w.room_id = old.room_id
+		  AND NOT EXISTS (SELECT 1 FROM user_rooms WHERE user_id = w.user_id AND room_id = w.room_id)
+		ORDER BY w.id
+		LIMIT COALESCE((SELECT MAX(0, rooms.capacity - (SELECT COUNT(*) FROM (
+		                    SELECT user_rooms.user_id FROM user_rooms WHERE user_rooms.room_id = rooms.id
+		                    UNION
+		                    SELECT gm.user_id FROM room_groups rg INNER JOIN group_members gm ON gm.group_id = rg.group_id
+		                    WHERE rg.room_id = rooms.id)))
+		                FROM rooms WHERE rooms.id = old.room_id), 0);
+	END;
+
+	CREATE TABLE room_invitations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		room_id INTEGER NOT NULL,
+		invited_by INTEGER NOT NULL,
+		user_id INTEGER,
+		email TEXT,
+		role TEXT NOT NULL DEFAULT 'member' CHECK (role IN ('owner', 'moderator', 'member')),
+		token_hash TEXT NOT NULL UNIQUE,
+		status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined')),
+		expires_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		responded_at DATETIME,
+		CHECK ((user_id IS NULL) != (email IS NULL))
+	);
+
+	CREATE TABLE room_join_requests (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		room_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		message TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+		reviewed_by INTEGER,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		reviewed_at DATETIME
+	);
+
+	CREATE UNIQUE INDEX idx_room_join_requests_pending ON room_join_requests(room_id, user_id) WHERE status = 'pending';
 	`
 
 	if _, err := db.Exec(schema); err != nil {
@@ -214,7 +404,7 @@ func TestRoomRepository_List(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			rooms, err := repo.List(ctx, tt.limit, tt.offset)
+			rooms, err := repo.List(ctx, RoomFilter{}, ListOptions{Limit: tt.limit, Offset: tt.offset})
 			if err != nil {
 				t.Errorf("List() error = %v", err)
 				return
@@ -227,6 +417,73 @@ func TestRoomRepository_List(t *testing.T) {
 	}
 }
 
+func TestRoomRepository_ListFiltered(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	repo := NewRoomRepository(db)
+	ctx := context.Background()
+
+	for _, room := range []models.CreateRoomRequest{
+		{Name: "Blue Meeting Room", Capacity: 8},
+		{Name: "Phone Booth", Capacity: 1},
+		{Name: "Red Meeting Room", Capacity: 12},
+		{Name: "100% Focus", Capacity: 2},
+	} {
+		if _, err := repo.Create(ctx, &room); err != nil {
+			t.Fatalf("Failed to create test room: %v", err)
+		}
+	}
+
+	names := func(rooms []*models.Room) []string {
+		var out []string
+		for _, room := range rooms {
+			out = append(out, room.Name)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name   string
+		filter RoomFilter
+		sort   []SortField
+		want   []string
+	}{
+		{
+			name:   "name contains, case-insensitive",
+			filter: RoomFilter{NameContains: "meeting"},
+			sort:   []SortField{{Column: "name"}},
+			want:   []string{"Blue Meeting Room", "Red Meeting Room"},
+		},
+		{
+			name:   "capacity range sorted descending",
+			filter: RoomFilter{MinCapacity: 2, MaxCapacity: 12},
+			sort:   []SortField{{Column: "capacity", Desc: true}},
+			want:   []string{"Red Meeting Room", "Blue Meeting Room", "100% Focus"},
+		},
+		{
+			name:   "LIKE wildcards are literal",
+			filter: RoomFilter{NameContains: "0%"},
+			want:   []string{"100% Focus"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rooms, err := repo.List(ctx, tt.filter, ListOptions{Sort: tt.sort, Limit: 10})
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if fmt.Sprint(names(rooms)) != fmt.Sprint(tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, names(rooms))
+			}
+		})
+	}
+
+	if _, err := repo.List(ctx, RoomFilter{}, ListOptions{Sort: []SortField{{Column: "name"}}, After: &Cursor{ID: 1}}); !errors.Is(err, ErrSortWithCursor) {
+		t.Errorf("Expected ErrSortWithCursor, got %v", err)
+	}
+}
 func TestRoomRepository_Update(t *testing.T) {
 	db := setupTestDBWithRooms(t)
 	defer db.Close()
@@ -254,14 +511,17 @@ func TestRoomRepository_Update(t *testing.T) {
 			name: "update name",
 			id:   room.ID,
 			req: &models.UpdateRoomRequest{
-				Name: "Updated Room",
+				Name:        "Updated Room",
+				Description: "Original Description",
+				Capacity:    10,
 			},
 			wantErr: false,
 		},
 		{
-			name: "update capacity",
+			name: "update capacity and clear description",
 			id:   room.ID,
 			req: &models.UpdateRoomRequest{
+				Name:     "Updated Room",
 				Capacity: 25,
 			},
 			wantErr: false,
@@ -270,7 +530,8 @@ func TestRoomRepository_Update(t *testing.T) {
 			name: "update non-existent room",
 			id:   9999,
 			req: &models.UpdateRoomRequest{
-				Name: "Should Fail",
+				Name:     "Should Fail",
+				Capacity: 1,
 			},
 			wantErr: true,
 		},
@@ -278,24 +539,139 @@ func TestRoomRepository_Update(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			updated, err := repo.Update(ctx, tt.id, tt.req)
+			updated, err := repo.Update(ctx, tt.id, 0, tt.req)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Update() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 
 			if !tt.wantErr {
-				if tt.req.Name != "" && updated.Name != tt.req.Name {
-					t.Errorf("Expected name %s, got %s", tt.req.Name, updated.Name)
-				}
-				if tt.req.Capacity > 0 && updated.Capacity != tt.req.Capacity {
-					t.Errorf("Expected capacity %d, got %d", tt.req.Capacity, updated.Capacity)
+				if updated.Name != tt.req.Name || updated.Description != tt.req.Description || updated.Capacity != tt.req.Capacity {
+					t.Errorf("Expected %+v, got %+v", tt.req, updated)
 				}
 			}
 		})
 	}
 }
 
+func TestRoomRepository_Patch(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	repo := NewRoomRepository(db)
+	locations := NewLocationRepository(db)
+	ctx := context.Background()
+
+	site, err := locations.CreateSite(ctx, &models.CreateSiteRequest{Name: "HQ"})
+	if err != nil {
+		t.Fatalf("Failed to create site: %v", err)
+	}
+	building, err := locations.CreateBuilding(ctx, site.ID, &models.CreateBuildingRequest{Name: "North"})
+	if err != nil {
+		t.Fatalf("Failed to create building: %v", err)
+	}
+	floor, err := locations.CreateFloor(ctx, building.ID, &models.CreateFloorRequest{Name: "Ground"})
+	if err != nil {
+		t.Fatalf("Failed to create floor: %v", err)
+	}
+
+	room, err := repo.Create(ctx, &models.CreateRoomRequest{
+		Name:        "Original Room",
+		Description: "Original Description",
+		Capacity:    10,
+		FloorID:     floor.ID,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test room: %v", err)
+	}
+
+	// Fields left out of the patch keep their values
+	patched, err := repo.Patch(ctx, room.ID, 0, &models.PatchRoomRequest{Capacity: models.Set(12)})
+	if err != nil {
+		t.Fatalf("Patch() error = %v", err)
+	}
+	if patched.Name != "Original Room" || patched.Description != "Original Description" || patched.Capacity != 12 ||
+		patched.FloorID == nil || *patched.FloorID != floor.ID {
+		t.Errorf("Expected only the capacity to change, got %+v", patched)
+	}
+
+	// Null clears the description and the floor
+	patched, err = repo.Patch(ctx, room.ID, 0, &models.PatchRoomRequest{Description: models.Null[string](), FloorID: models.Null[int64]()})
+	if err != nil {
+		t.Fatalf("Patch() error = %v", err)
+	}
+	if patched.Description != "" || patched.FloorID != nil || patched.Capacity != 12 {
+		t.Errorf("Expected the description and floor to be cleared, got %+v", patched)
+	}
+
+	if _, err := repo.Patch(ctx, room.ID, 0, &models.PatchRoomRequest{FloorID: models.Set(int64(9999))}); !errors.Is(err, ErrFloorNotFound) {
+		t.Errorf("Expected ErrFloorNotFound, got %v", err)
+	}
+	if _, err := repo.Patch(ctx, 9999, 0, &models.PatchRoomRequest{Name: models.Set("Missing")}); !errors.Is(err, ErrRoomNotFound) {
+		t.Errorf("Expected ErrRoomNotFound, got %v", err)
+	}
+}
+
+func TestRoomRepository_Versions(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	roomRepo := NewRoomRepository(db)
+	userRepo := NewUserRepository(db, roomRepo)
+	ctx := context.Background()
+
+	room, err := roomRepo.Create(ctx, &models.CreateRoomRequest{Name: "Room", Capacity: 5})
+	if err != nil {
+		t.Fatalf("Failed to create test room: %v", err)
+	}
+	if room.Version != 1 {
+		t.Errorf("Expected a new room to have version 1, got %d", room.Version)
+	}
+	for i := 0; i < 2; i++ {
+		user, err := userRepo.Create(ctx, &models.CreateUserRequest{
+			Email: fmt.Sprintf("user%d@example.com", i),
+			Name:  fmt.Sprintf("User %d", i),
+		})
+		if err != nil {
+			t.Fatalf("Failed to create test user: %v", err)
+		}
+		if _, err := roomRepo.AssignUserToRoom(ctx, user.ID, room.ID, ""); err != nil {
+			t.Fatalf("Failed to assign user to room: %v", err)
+		}
+	}
+
+	patched, err := roomRepo.Patch(ctx, room.ID, room.Version, &models.PatchRoomRequest{Capacity: models.Set(4)})
+	if err != nil {
+		t.Fatalf("Patch() error = %v", err)
+	}
+	if patched.Version != 2 {
+		t.Errorf("Expected the patch to bump the version to 2, got %d", patched.Version)
+	}
+
+	// A stale version wins over the occupancy check, so clients know to refetch first
+	_, err = roomRepo.Update(ctx, room.ID, room.Version, &models.UpdateRoomRequest{Name: "Room", Capacity: 1})
+	if !errors.Is(err, ErrVersionMismatch) {
+		t.Errorf("Expected ErrVersionMismatch, got %v", err)
+	}
+	if err := roomRepo.Delete(ctx, room.ID, room.Version); !errors.Is(err, ErrVersionMismatch) {
+		t.Errorf("Expected ErrVersionMismatch, got %v", err)
+	}
+	if _, err := roomRepo.Patch(ctx, room.ID, patched.Version, &models.PatchRoomRequest{Capacity: models.Set(1)}); !errors.Is(err, ErrCapacityBelowOccupancy) {
+		t.Errorf("Expected ErrCapacityBelowOccupancy, got %v", err)
+	}
+	if err := roomRepo.Delete(ctx, 9999, 1); !errors.Is(err, ErrRoomNotFound) {
+		t.Errorf("Expected ErrRoomNotFound, got %v", err)
+	}
+
+	current, err := roomRepo.GetByID(ctx, room.ID)
+	if err != nil {
+		t.Fatalf("Failed to get room: %v", err)
+	}
+	if current.Capacity != 4 || current.Version != 2 {
+		t.Errorf("Expected rejected writes to leave the room unchanged, got capacity %d version %d", current.Capacity, current.Version)
+	}
+}
+
 func TestRoomRepository_Delete(t *testing.T) {
 	db := setupTestDBWithRooms(t)
 	defer db.Close()
@@ -331,7 +707,7 @@ func TestRoomRepository_Delete(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := repo.Delete(ctx, tt.id)
+			err := repo.Delete(ctx, tt.id, 0)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Delete() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -348,12 +724,75 @@ func TestRoomRepository_Delete(t *testing.T) {
 	}
 }
 
+func TestRoomRepository_DeleteRemovesDependents(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	repo := NewRoomRepository(db)
+	ctx := context.Background()
+
+	// Production SQLite does not enforce foreign keys, so nothing may rely on the cascade
+	if _, err := db.Exec(`PRAGMA foreign_keys = OFF`); err != nil {
+		t.Fatalf("Failed to disable foreign keys: %v", err)
+	}
+
+	// Rooms 1 and 2 get the same dependents; only those of room 1 may go
+	seed := `INSERT INTO users (email, name) VALUES ('a@example.com', 'A'), ('b@example.com', 'B');
+		INSERT INTO rooms (name, capacity) VALUES ('Doomed', 1), ('Kept', 1);
+		INSERT INTO tags (name) VALUES ('quiet');
+		INSERT INTO groups (name) VALUES ('Team');
+		INSERT INTO recurring_bookings (room_id, user_id, starts_at, ends_at, rrule)
+		VALUES (1, 1, '2026-10-16 09:00:00', '2026-10-16 10:00:00', 'FREQ=DAILY'),
+		       (2, 1, '2026-10-16 09:00:00', '2026-10-16 10:00:00', 'FREQ=DAILY');`
+	for _, roomID := range []int{1, 2} {
+		seed += fmt.Sprintf(`
+		INSERT INTO user_rooms (user_id, room_id, role) VALUES (1, %[1]d, 'owner');
+		INSERT INTO room_waitlist (room_id, user_id) VALUES (%[1]d, 2);
+		INSERT INTO bookings (room_id, user_id, starts_at, ends_at) VALUES (%[1]d, 1, '2026-10-16 11:00:00', '2026-10-16 12:00:00');
+		INSERT INTO recurring_booking_exceptions (series_id, recurrence_id) VALUES (%[1]d, '2026-10-17 09:00:00');
+		INSERT INTO room_tags (room_id, tag_id) VALUES (%[1]d, 1);
+		INSERT INTO room_amenities (room_id, amenity) VALUES (%[1]d, 'projector');
+		INSERT INTO room_groups (room_id, group_id) VALUES (%[1]d, 1);
+		INSERT INTO room_invitations (room_id, invited_by, user_id, token_hash) VALUES (%[1]d, 1, 2, 'hash%[1]d');
+		INSERT INTO room_join_requests (room_id, user_id) VALUES (%[1]d, 2);`, roomID)
+	}
+	if _, err := db.Exec(seed); err != nil {
+		t.Fatalf("Failed to seed data: %v", err)
+	}
+
+	if err := repo.Delete(ctx, 1, 0); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	for _, table := range []string{
+		"room_waitlist", "user_rooms", "bookings", "recurring_bookings", "room_tags",
+		"room_amenities", "room_groups", "room_invitations", "room_join_requests",
+	} {
+		var doomed, kept int
+		query := `SELECT COUNT(*) FILTER (WHERE room_id = 1), COUNT(*) FILTER (WHERE room_id = 2) FROM ` + table
+		if err := db.QueryRow(query).Scan(&doomed, &kept); err != nil {
+			t.Fatalf("Failed to count %s: %v", table, err)
+		}
+		if doomed != 0 || kept != 1 {
+			t.Errorf("Expected only the kept room's row in %s, got %d for the deleted room and %d for the kept one", table, doomed, kept)
+		}
+	}
+
+	var exceptions int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM recurring_booking_exceptions WHERE series_id = 1`).Scan(&exceptions); err != nil {
+		t.Fatalf("Failed to count exceptions: %v", err)
+	}
+	if exceptions != 0 {
+		t.Errorf("Expected the deleted room's recurring booking exceptions to go, got %d", exceptions)
+	}
+}
+
 func TestRoomRepository_AssignUserToRoom(t *testing.T) {
 	db := setupTestDBWithRooms(t)
 	defer db.Close()
 
 	roomRepo := NewRoomRepository(db)
-	userRepo := NewUserRepository(db)
+	userRepo := NewUserRepository(db, roomRepo)
 	ctx := context.Background()
 
 	// Create test user
@@ -383,19 +822,19 @@ func TestRoomRepository_AssignUserToRoom(t *testing.T) {
 	}
 
 	// Test assigning user to first room
-	err = roomRepo.AssignUserToRoom(ctx, user.ID, room1.ID)
+	_, err = roomRepo.AssignUserToRoom(ctx, user.ID, room1.ID, "")
 	if err != nil {
 		t.Errorf("Failed to assign user to room 1: %v", err)
 	}
 
 	// Test assigning same user to second room (should succeed - many-to-many)
-	err = roomRepo.AssignUserToRoom(ctx, user.ID, room2.ID)
+	_, err = roomRepo.AssignUserToRoom(ctx, user.ID, room2.ID, "")
 	if err != nil {
 		t.Errorf("Failed to assign user to room 2: %v", err)
 	}
 
 	// Test assigning user to same room again (should fail - duplicate)
-	err = roomRepo.AssignUserToRoom(ctx, user.ID, room1.ID)
+	_, err = roomRepo.AssignUserToRoom(ctx, user.ID, room1.ID, "")
 	if err == nil {
 		t.Error("Expected error when assigning user to same room twice")
 	}
@@ -411,12 +850,260 @@ func TestRoomRepository_AssignUserToRoom(t *testing.T) {
 	}
 }
 
+func TestRoomRepository_AssignUserToRoomCapacity(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	roomRepo := NewRoomRepository(db)
+	userRepo := NewUserRepository(db, roomRepo)
+	ctx := context.Background()
+
+	room, err := roomRepo.Create(ctx, &models.CreateRoomRequest{
+		Name:     "Small Room",
+		Capacity: 2,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test room: %v", err)
+	}
+
+	var userIDs []int64
+	for i := 0; i < 3; i++ {
+		user, err := userRepo.Create(ctx, &models.CreateUserRequest{
+			Email: fmt.Sprintf("user%d@example.com", i),
+			Name:  fmt.Sprintf("User %d", i),
+		})
+		if err != nil {
+			t.Fatalf("Failed to create test user: %v", err)
+		}
+		userIDs = append(userIDs, user.ID)
+	}
+
+	for _, id := range userIDs[:2] {
+		if _, err := roomRepo.AssignUserToRoom(ctx, id, room.ID, ""); err != nil {
+			t.Fatalf("Failed to assign user to room: %v", err)
+		}
+	}
+
+	// Third user exceeds capacity
+	_, err = roomRepo.AssignUserToRoom(ctx, userIDs[2], room.ID, "")
+	if !errors.Is(err, ErrRoomFull) {
+		t.Errorf("Expected ErrRoomFull, got %v", err)
+	}
+
+	// Re-assigning an existing member is a duplicate, not a full room
+	_, err = roomRepo.AssignUserToRoom(ctx, userIDs[0], room.ID, "")
+	if !errors.Is(err, ErrAlreadyAssigned) {
+		t.Errorf("Expected ErrAlreadyAssigned, got %v", err)
+	}
+
+	// Non-existent room
+	_, err = roomRepo.AssignUserToRoom(ctx, userIDs[2], 9999, "")
+	if !errors.Is(err, ErrRoomNotFound) {
+		t.Errorf("Expected ErrRoomNotFound, got %v", err)
+	}
+
+	// Non-existent user: foreign keys are off, so the insert itself has to check
+	_, err = roomRepo.AssignUserToRoom(ctx, 9999, room.ID, "")
+	if !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound, got %v", err)
+	}
+
+	// Freeing a seat allows the next user in
+	if err := roomRepo.RemoveUserFromRoom(ctx, userIDs[1], room.ID); err != nil {
+		t.Fatalf("Failed to remove user from room: %v", err)
+	}
+
+	if _, err := roomRepo.AssignUserToRoom(ctx, userIDs[2], room.ID, ""); err != nil {
+		t.Errorf("Expected assignment to succeed after a seat was freed: %v", err)
+	}
+}
+
+func TestRoomRepository_AssignUserToRoomConcurrent(t *testing.T) {
+	// A file database lets concurrent connections share the same data
+	dsn := filepath.Join(t.TempDir(), "capacity.db") + "?_busy_timeout=5000"
+	db := openTestDBWithRooms(t, dsn)
+	defer db.Close()
+
+	roomRepo := NewRoomRepository(db)
+	userRepo := NewUserRepository(db, roomRepo)
+	ctx := context.Background()
+
+	const capacity = 3
+	const attempts = 12
+
+	room, err := roomRepo.Create(ctx, &models.CreateRoomRequest{
+		Name:     "Contested Room",
+		Capacity: capacity,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test room: %v", err)
+	}
+
+	var userIDs []int64
+	for i := 0; i < attempts; i++ {
+		user, err := userRepo.Create(ctx, &models.CreateUserRequest{
+			Email: fmt.Sprintf("user%d@example.com", i),
+			Name:  fmt.Sprintf("User %d", i),
+		})
+		if err != nil {
+			t.Fatalf("Failed to create test user: %v", err)
+		}
+		userIDs = append(userIDs, user.ID)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, attempts)
+	for _, id := range userIDs {
+		wg.Add(1)
+		go func(userID int64) {
+			defer wg.Done()
+			_, err := roomRepo.AssignUserToRoom(ctx, userID, room.ID, "")
+			errs <- err
+		}(id)
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, ErrRoomFull):
+			t.Errorf("Unexpected error: %v", err)
+		}
+	}
+
+	if succeeded != capacity {
+		t.Errorf("Expected %d successful assignments, got %d", capacity, succeeded)
+	}
+
+	roomWithUsers, err := roomRepo.GetRoomWithUsers(ctx, room.ID)
+	if err != nil {
+		t.Fatalf("Failed to get room with users: %v", err)
+	}
+
+	if len(roomWithUsers.Users) != capacity {
+		t.Errorf("Expected %d users in room, got %d", capacity, len(roomWithUsers.Users))
+	}
+}
+
+func TestRoomRepository_UpdateCapacityBelowOccupancy(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	roomRepo := NewRoomRepository(db)
+	userRepo := NewUserRepository(db, roomRepo)
+	ctx := context.Background()
+
+	room, err := roomRepo.Create(ctx, &models.CreateRoomRequest{
+		Name:     "Room",
+		Capacity: 5,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test room: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		user, err := userRepo.Create(ctx, &models.CreateUserRequest{
+			Email: fmt.Sprintf("user%d@example.com", i),
+			Name:  fmt.Sprintf("User %d", i),
+		})
+		if err != nil {
+			t.Fatalf("Failed to create test user: %v", err)
+		}
+		if _, err := roomRepo.AssignUserToRoom(ctx, user.ID, room.ID, ""); err != nil {
+			t.Fatalf("Failed to assign user to room: %v", err)
+		}
+	}
+
+	// Below occupancy is rejected and leaves the room unchanged
+	_, err = roomRepo.Update(ctx, room.ID, 0, &models.UpdateRoomRequest{Name: "Renamed", Capacity: 2})
+	if !errors.Is(err, ErrCapacityBelowOccupancy) {
+		t.Fatalf("Expected ErrCapacityBelowOccupancy, got %v", err)
+	}
+
+	unchanged, err := roomRepo.GetByID(ctx, room.ID)
+	if err != nil {
+		t.Fatalf("Failed to get room: %v", err)
+	}
+	if unchanged.Capacity != 5 || unchanged.Name != "Room" {
+		t.Errorf("Expected room to be unchanged, got name %s capacity %d", unchanged.Name, unchanged.Capacity)
+	}
+
+	// Equal to occupancy is allowed
+	updated, err := roomRepo.Patch(ctx, room.ID, 0, &models.PatchRoomRequest{Capacity: models.Set(3)})
+	if err != nil {
+		t.Fatalf("Expected update to current occupancy to succeed: %v", err)
+	}
+	if updated.Capacity != 3 {
+		t.Errorf("Expected capacity 3, got %d", updated.Capacity)
+	}
+
+	// Updates that don't touch capacity are unaffected
+	if _, err := roomRepo.Patch(ctx, room.ID, 0, &models.PatchRoomRequest{Description: models.Set("Full")}); err != nil {
+		t.Errorf("Expected description update to succeed: %v", err)
+	}
+}
+
+func TestRoomRepository_Available(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	repo := NewRoomRepository(db)
+	ctx := context.Background()
+
+	// Free seats: Hall 20, Booth 0, Office 4, Studio 6
+	if _, err := db.Exec(`
+		INSERT INTO users (email, name) VALUES ('a@example.com', 'A'), ('b@example.com', 'B');
+		INSERT INTO rooms (name, capacity) VALUES ('Hall', 20), ('Booth', 1), ('Office', 6), ('Studio', 6);
+		INSERT INTO user_rooms (user_id, room_id) VALUES (1, 2), (1, 3), (2, 3);
+	`); err != nil {
+		t.Fatalf("Failed to seed data: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		minFreeSeats int
+		limit        int
+		wantNames    []string
+		wantFree     []int
+	}{
+		{name: "any free seat", minFreeSeats: 1, limit: 10, wantNames: []string{"Office", "Studio", "Hall"}, wantFree: []int{4, 6, 20}},
+		{name: "best fit first", minFreeSeats: 5, limit: 10, wantNames: []string{"Studio", "Hall"}, wantFree: []int{6, 20}},
+		{name: "limited", minFreeSeats: 1, limit: 1, wantNames: []string{"Office"}, wantFree: []int{4}},
+		{name: "none large enough", minFreeSeats: 21, limit: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rooms, err := repo.Available(ctx, tt.minFreeSeats, tt.limit, RoomFilter{})
+			if err != nil {
+				t.Fatalf("Available() error = %v", err)
+			}
+			if len(rooms) != len(tt.wantNames) {
+				t.Fatalf("Expected %d rooms, got %d", len(tt.wantNames), len(rooms))
+			}
+			for i, room := range rooms {
+				if room.Name != tt.wantNames[i] || room.FreeSeats != tt.wantFree[i] {
+					t.Errorf("Room %d: expected %s with %d free seats, got %s with %d",
+						i, tt.wantNames[i], tt.wantFree[i], room.Name, room.FreeSeats)
+				}
+				if room.Occupancy+room.FreeSeats != room.Capacity {
+					t.Errorf("Room %s: occupancy %d and free seats %d don't add up to capacity %d",
+						room.Name, room.Occupancy, room.FreeSeats, room.Capacity)
+				}
+			}
+		})
+	}
+}
+
 func TestRoomRepository_GetRoomWithUsers(t *testing.T) {
 	db := setupTestDBWithRooms(t)
 	defer db.Close()
 
 	roomRepo := NewRoomRepository(db)
-	userRepo := NewUserRepository(db)
+	userRepo := NewUserRepository(db, roomRepo)
 	ctx := context.Background()
 
 	// Create test room
@@ -439,8 +1126,8 @@ func TestRoomRepository_GetRoomWithUsers(t *testing.T) {
 	})
 
 	// Assign users to room
-	roomRepo.AssignUserToRoom(ctx, user1.ID, room.ID)
-	roomRepo.AssignUserToRoom(ctx, user2.ID, room.ID)
+	roomRepo.AssignUserToRoom(ctx, user1.ID, room.ID, "")
+	roomRepo.AssignUserToRoom(ctx, user2.ID, room.ID, "")
 
 	// Get room with users
 	roomWithUsers, err := roomRepo.GetRoomWithUsers(ctx, room.ID)
@@ -458,7 +1145,7 @@ func TestRoomRepository_RemoveUserFromRoom(t *testing.T) {
 	defer db.Close()
 
 	roomRepo := NewRoomRepository(db)
-	userRepo := NewUserRepository(db)
+	userRepo := NewUserRepository(db, roomRepo)
 	ctx := context.Background()
 
 	// Create test user and room
@@ -472,7 +1159,7 @@ func TestRoomRepository_RemoveUserFromRoom(t *testing.T) {
 	})
 
 	// Assign user to room
-	roomRepo.AssignUserToRoom(ctx, user.ID, room.ID)
+	roomRepo.AssignUserToRoom(ctx, user.ID, room.ID, "")
 
 	// Remove user from room
 	err := roomRepo.RemoveUserFromRoom(ctx, user.ID, room.ID)
@@ -497,12 +1184,134 @@ func TestRoomRepository_RemoveUserFromRoom(t *testing.T) {
 	}
 }
 
+func TestRoomRepository_MembershipRoles(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	roomRepo := NewRoomRepository(db)
+	userRepo := NewUserRepository(db, roomRepo)
+	ctx := context.Background()
+
+	room, _ := roomRepo.Create(ctx, &models.CreateRoomRequest{Name: "Team Room", Capacity: 5})
+
+	var userIDs []int64
+	for i := 0; i < 3; i++ {
+		user, err := userRepo.Create(ctx, &models.CreateUserRequest{
+			Email: fmt.Sprintf("member%d@example.com", i),
+			Name:  fmt.Sprintf("Member %d", i),
+		})
+		if err != nil {
+			t.Fatalf("Failed to create test user: %v", err)
+		}
+		userIDs = append(userIDs, user.ID)
+	}
+
+	// The first user becomes the owner, later ones members unless a role is given
+	wantRoles := []string{models.RoleOwner, models.RoleMember, models.RoleModerator}
+	for i, role := range []string{"", "", models.RoleModerator} {
+		m, err := roomRepo.AssignUserToRoom(ctx, userIDs[i], room.ID, role)
+		if err != nil {
+			t.Fatalf("AssignUserToRoom() error = %v", err)
+		}
+		if m.Role != wantRoles[i] || m.UserID != userIDs[i] || m.RoomID != room.ID {
+			t.Errorf("Expected role %s, got %+v", wantRoles[i], m)
+		}
+	}
+
+	roomWithUsers, err := roomRepo.GetRoomWithUsers(ctx, room.ID)
+	if err != nil {
+		t.Fatalf("GetRoomWithUsers() error = %v", err)
+	}
+	for i, u := range roomWithUsers.Users {
+		if u.Role != wantRoles[i] {
+			t.Errorf("Expected %s to be %s, got %s", u.Name, wantRoles[i], u.Role)
+		}
+	}
+
+	// The last owner can neither be demoted nor leave while others remain
+	if _, err := roomRepo.UpdateMembershipRole(ctx, room.ID, userIDs[0], models.RoleMember); !errors.Is(err, ErrLastOwner) {
+		t.Errorf("Expected ErrLastOwner on demotion, got %v", err)
+	}
+	if err := roomRepo.RemoveUserFromRoom(ctx, userIDs[0], room.ID); !errors.Is(err, ErrLastOwner) {
+		t.Errorf("Expected ErrLastOwner on removal, got %v", err)
+	}
+	if _, err := roomRepo.UpdateMembershipRole(ctx, room.ID, 9999, models.RoleOwner); !errors.Is(err, ErrNotAssigned) {
+		t.Errorf("Expected ErrNotAssigned, got %v", err)
+	}
+
+	// With a second owner the first one can step down
+	if _, err := roomRepo.UpdateMembershipRole(ctx, room.ID, userIDs[1], models.RoleOwner); err != nil {
+		t.Fatalf("UpdateMembershipRole() error = %v", err)
+	}
+	m, err := roomRepo.UpdateMembershipRole(ctx, room.ID, userIDs[0], models.RoleMember)
+	if err != nil {
+		t.Fatalf("UpdateMembershipRole() error = %v", err)
+	}
+	if m.Role != models.RoleMember {
+		t.Errorf("Expected the former owner to be a member, got %+v", m)
+	}
+
+	// An invalid role violates the CHECK constraint
+	if _, err := roomRepo.UpdateMembershipRole(ctx, room.ID, userIDs[2], "admin"); err == nil {
+		t.Error("Expected an error for an invalid role")
+	}
+
+	// The last owner may leave once everyone else has
+	for _, id := range []int64{userIDs[0], userIDs[2], userIDs[1]} {
+		if err := roomRepo.RemoveUserFromRoom(ctx, id, room.ID); err != nil {
+			t.Fatalf("RemoveUserFromRoom(%d) error = %v", id, err)
+		}
+	}
+}
+
+func TestUserRepository_DeleteOwner(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	roomRepo := NewRoomRepository(db)
+	userRepo := NewUserRepository(db, roomRepo)
+	ctx := context.Background()
+
+	room, _ := roomRepo.Create(ctx, &models.CreateRoomRequest{Name: "Team Room", Capacity: 5})
+
+	var userIDs []int64
+	for i := 0; i < 3; i++ {
+		user, err := userRepo.Create(ctx, &models.CreateUserRequest{
+			Email: fmt.Sprintf("member%d@example.com", i),
+			Name:  fmt.Sprintf("Member %d", i),
+		})
+		if err != nil {
+			t.Fatalf("Failed to create test user: %v", err)
+		}
+		if _, err := roomRepo.AssignUserToRoom(ctx, user.ID, room.ID, ""); err != nil {
+			t.Fatalf("AssignUserToRoom() error = %v", err)
+		}
+		userIDs = append(userIDs, user.ID)
+	}
+
+	// Deleting the sole owner hands the room to the longest-standing remaining member
+	if err := userRepo.Delete(ctx, userIDs[0], 0); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	m, err := roomRepo.GetMembership(ctx, room.ID, userIDs[1])
+	if err != nil {
+		t.Fatalf("GetMembership() error = %v", err)
+	}
+	if m.Role != models.RoleOwner {
+		t.Errorf("Expected user %d to own the room, got %+v", userIDs[1], m)
+	}
+	if m, err := roomRepo.GetMembership(ctx, room.ID, userIDs[2]); err != nil || m.Role != models.RoleMember {
+		t.Errorf("Expected user %d to stay a member, got %+v, %v", userIDs[2], m, err)
+	}
+}
+
 func TestRoomRepository_GetUserRooms(t *testing.T) {
 	db := setupTestDBWithRooms(t)
 	defer db.Close()
 
 	roomRepo := NewRoomRepository(db)
-	userRepo := NewUserRepository(db)
+	userRepo := NewUserRepository(db, roomRepo)
 	ctx := context.Background()
 
 	// Create test user
@@ -526,9 +1335,9 @@ func TestRoomRepository_GetUserRooms(t *testing.T) {
 	})
 
 	// Assign user to multiple rooms
-	roomRepo.AssignUserToRoom(ctx, user.ID, room1.ID)
-	roomRepo.AssignUserToRoom(ctx, user.ID, room2.ID)
-	roomRepo.AssignUserToRoom(ctx, user.ID, room3.ID)
+	roomRepo.AssignUserToRoom(ctx, user.ID, room1.ID, "")
+	roomRepo.AssignUserToRoom(ctx, user.ID, room2.ID, "")
+	roomRepo.AssignUserToRoom(ctx, user.ID, room3.ID, "")
 
 	// Get user rooms
 	rooms, err := roomRepo.GetUserRooms(ctx, user.ID)
@@ -545,3 +1354,43 @@ func TestRoomRepository_GetUserRooms(t *testing.T) {
 		t.Errorf("Expected first room to be 'Room 1', got '%s'", rooms[0].Name)
 	}
 }
+
+func TestRoomRepository_Assignments(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	repo := NewRoomRepository(db)
+	ctx := context.Background()
+
+	if _, err := db.Exec(`
+		INSERT INTO users (email, name) VALUES ('a@example.com', 'A'), ('b@example.com', 'B');
+		INSERT INTO rooms (name, capacity) VALUES ('Lab', 5), ('Hall', 5);
+		INSERT INTO user_rooms (user_id, room_id, created_at) VALUES
+			(2, 1, '2026-01-02 00:00:00'), (1, 1, '2026-01-01 00:00:00'), (1, 2, '2026-01-03 00:00:00');
+	`); err != nil {
+		t.Fatalf("Failed to seed data: %v", err)
+	}
+
+	byRoom, err := repo.RoomAssignments(ctx, 1)
+	if err != nil {
+		t.Fatalf("RoomAssignments() error = %v", err)
+	}
+	if len(byRoom) != 2 || byRoom[0].UserName != "A" || byRoom[1].UserEmail != "b@example.com" || byRoom[0].RoomName != "Lab" {
+		t.Errorf("Expected A then B in Lab, got %+v %+v", byRoom[0], byRoom[1])
+	}
+
+	byUser, err := repo.UserAssignments(ctx, 1)
+	if err != nil {
+		t.Fatalf("UserAssignments() error = %v", err)
+	}
+	if len(byUser) != 2 || byUser[0].RoomName != "Lab" || byUser[1].RoomName != "Hall" {
+		t.Errorf("Expected Lab then Hall, got %+v", byUser)
+	}
+
+	if _, err := repo.RoomAssignments(ctx, 99); !errors.Is(err, ErrRoomNotFound) {
+		t.Errorf("Expected ErrRoomNotFound, got %v", err)
+	}
+	if _, err := repo.UserAssignments(ctx, 99); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound, got %v", err)
+	}
+}
@@ -1,9 +1,13 @@
 package repository
 
 import (
+	"database/sql"
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
 	"time"
+
+	"cloudflaredb/internal/models"
 )
 
 // NullableTime is a custom type that can scan both time.Time and string timestamps
@@ -70,22 +74,23 @@ type ColumnScanner interface {
 // scanUser is a helper function to scan a user row that handles cfd1 timestamp strings, numeric types, and column ordering bugs
 func scanUser(scanner interface {
 	Scan(dest ...interface{}) error
-}, id *int64, email, name *string, createdAt, updatedAt *time.Time) error {
+}, id *int64, email, name *string, createdAt, updatedAt *time.Time, version *int64) error {
 	// Try to get column names if available (for *sql.Rows)
 	if colScanner, ok := scanner.(ColumnScanner); ok {
-		return scanUserWithColumns(colScanner, id, email, name, createdAt, updatedAt)
+		return scanUserWithColumns(colScanner, id, email, name, createdAt, updatedAt, version)
 	}
 
 	// Fallback to position-based scanning for *sql.Row
 	var createdAtNT, updatedAtNT NullableTime
-	var idFloat float64
+	var idFloat, versionFloat float64
 
-	err := scanner.Scan(&idFloat, email, name, &createdAtNT, &updatedAtNT)
+	err := scanner.Scan(&idFloat, email, name, &createdAtNT, &updatedAtNT, &versionFloat)
 	if err != nil {
 		return err
 	}
 
 	*id = int64(idFloat)
+	*version = int64(versionFloat)
 
 	if createdAtNT.Valid {
 		*createdAt = createdAtNT.Time
@@ -98,7 +103,7 @@ func scanUser(scanner interface {
 }
 
 // scanUserWithColumns scans a user using column names to handle cfd1's column ordering bug
-func scanUserWithColumns(scanner ColumnScanner, id *int64, email, name *string, createdAt, updatedAt *time.Time) error {
+func scanUserWithColumns(scanner ColumnScanner, id *int64, email, name *string, createdAt, updatedAt *time.Time, version *int64) error {
 	cols, err := scanner.Columns()
 	if err != nil {
 		return err
@@ -124,9 +129,7 @@ func scanUserWithColumns(scanner ColumnScanner, id *int64, email, name *string,
 
 		switch col {
 		case "id":
-			if f, ok := val.(float64); ok {
-				*id = int64(f)
-			}
+			*id = toInt64(val)
 		case "email":
 			if s, ok := val.(string); ok {
 				*email = s
@@ -139,6 +142,8 @@ func scanUserWithColumns(scanner ColumnScanner, id *int64, email, name *string,
 			*createdAt = parseTimeValue(val)
 		case "updated_at":
 			*updatedAt = parseTimeValue(val)
+		case "version":
+			*version = toInt64(val)
 		}
 	}
 
@@ -148,84 +153,76 @@ func scanUserWithColumns(scanner ColumnScanner, id *int64, email, name *string,
 // scanRoom is a helper function to scan a room row that handles cfd1 timestamp strings, numeric types, and column ordering bugs
 func scanRoom(scanner interface {
 	Scan(dest ...interface{}) error
-}, id *int64, name, description *string, capacity *int, createdAt, updatedAt *time.Time) error {
+}, room *models.Room) error {
 	// Try to get column names if available (for *sql.Rows)
 	if colScanner, ok := scanner.(ColumnScanner); ok {
-		return scanRoomWithColumns(colScanner, id, name, description, capacity, createdAt, updatedAt)
+		return scanRoomWithColumns(colScanner, room)
 	}
 
 	// Fallback to position-based scanning for *sql.Row
 	var createdAtNT, updatedAtNT NullableTime
-	var idFloat, capacityFloat float64
+	var idFloat, capacityFloat, versionFloat float64
+	var attributesText string
+	var floorID sql.NullFloat64
 
-	err := scanner.Scan(&idFloat, name, description, &capacityFloat, &createdAtNT, &updatedAtNT)
+	err := scanner.Scan(&idFloat, &room.Name, &room.Description, &capacityFloat, &createdAtNT, &updatedAtNT, &attributesText, &floorID, &versionFloat)
 	if err != nil {
 		return err
 	}
 
-	*id = int64(idFloat)
-	*capacity = int(capacityFloat)
+	room.ID = int64(idFloat)
+	room.Capacity = int(capacityFloat)
+	room.Version = int64(versionFloat)
+	room.Attributes = toJSON(attributesText)
+	if floorID.Valid {
+		room.FloorID = optionalInt64(floorID.Float64)
+	}
 
 	if createdAtNT.Valid {
-		*createdAt = createdAtNT.Time
+		room.CreatedAt = createdAtNT.Time
 	}
 	if updatedAtNT.Valid {
-		*updatedAt = updatedAtNT.Time
+		room.UpdatedAt = updatedAtNT.Time
 	}
 
 	return nil
 }
 
 // scanRoomWithColumns scans a room using column names to handle cfd1's column ordering bug
-func scanRoomWithColumns(scanner ColumnScanner, id *int64, name, description *string, capacity *int, createdAt, updatedAt *time.Time) error {
-	cols, err := scanner.Columns()
+func scanRoomWithColumns(scanner ColumnScanner, room *models.Room) error {
+	v, err := scanValues(scanner)
 	if err != nil {
 		return err
 	}
 
-	// Create a map to store values by column name
-	values := make([]interface{}, len(cols))
-	for i := range values {
-		values[i] = new(interface{})
-	}
+	*room = roomFromValues(v)
+	return nil
+}
 
-	err = scanner.Scan(values...)
-	if err != nil {
-		return err
+// roomFromValues builds a room from a row scanned with scanValues; missing columns are left empty
+func roomFromValues(v map[string]interface{}) models.Room {
+	return models.Room{
+		ID:          toInt64(v["id"]),
+		Name:        toString(v["name"]),
+		Description: toString(v["description"]),
+		Capacity:    int(toInt64(v["capacity"])),
+		FloorID:     optionalInt64(v["floor_id"]),
+		Attributes:  toJSON(v["attributes"]),
+		CreatedAt:   parseTimeValue(v["created_at"]),
+		UpdatedAt:   parseTimeValue(v["updated_at"]),
+		Version:     toInt64(v["version"]),
 	}
+}
 
-	// Map values to struct fields based on column names
-	for i, col := range cols {
-		val := *(values[i].(*interface{}))
-		if val == nil {
-			continue
-		}
-
-		switch col {
-		case "id":
-			if f, ok := val.(float64); ok {
-				*id = int64(f)
-			}
-		case "name":
-			if s, ok := val.(string); ok {
-				*name = s
-			}
-		case "description":
-			if s, ok := val.(string); ok {
-				*description = s
-			}
-		case "capacity":
-			if f, ok := val.(float64); ok {
-				*capacity = int(f)
-			}
-		case "created_at":
-			*createdAt = parseTimeValue(val)
-		case "updated_at":
-			*updatedAt = parseTimeValue(val)
-		}
+// toInt64 converts a numeric column value to int64 (cfd1 returns float64, sqlite3 returns int64)
+func toInt64(val interface{}) int64 {
+	switch v := val.(type) {
+	case int64:
+		return v
+	case float64:
+		return int64(v)
 	}
-
-	return nil
+	return 0
 }
 
 // parseTimeValue parses a time value from various types
@@ -252,3 +249,57 @@ func parseTimeValue(val interface{}) time.Time {
 	}
 	return time.Time{}
 }
+
+// scanValues scans the current row into a map keyed by column name
+func scanValues(scanner ColumnScanner) (map[string]interface{}, error) {
+	cols, err := scanner.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]interface{}, len(cols))
+	for i := range values {
+		values[i] = new(interface{})
+	}
+
+	if err := scanner.Scan(values...); err != nil {
+		return nil, err
+	}
+
+	row := make(map[string]interface{}, len(cols))
+	for i, col := range cols {
+		row[col] = *(values[i].(*interface{}))
+	}
+	return row, nil
+}
+
+// toString converts a text column value to string (drivers may return []byte)
+func toString(val interface{}) string {
+	switch v := val.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	return ""
+}
+
+// toFloat64 converts a numeric column value to float64
+func toFloat64(val interface{}) float64 {
+	switch v := val.(type) {
+	case float64:
+		return v
+	case int64:
+		return float64(v)
+	}
+	return 0
+}
+
+// toJSON converts a JSON text column value, returning nil for empty or invalid text
+func toJSON(val interface{}) json.RawMessage {
+	s := toString(val)
+	if s == "" || !json.Valid([]byte(s)) {
+		return nil
+	}
+	return json.RawMessage(s)
+}
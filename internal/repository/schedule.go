@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// slot is a time range during which a booking or an occurrence of a recurring booking takes one
+// seat of a room. seriesID and recurrenceID identify occurrences and are zero for one-off bookings.
+type slot struct {
+	start, end   time.Time
+	seriesID     int64
+	recurrenceID time.Time
+}
+
+// peakOccupancy returns the largest number of slots active at the same moment within [start, end).
+// Occupancy only increases when a slot starts, so it is enough to check start and every slot start inside the range.
+func peakOccupancy(slots []slot, start, end time.Time) int {
+	points := []time.Time{start}
+	for _, s := range slots {
+		if s.start.After(start) && s.start.Before(end) {
+			points = append(points, s.start)
+		}
+	}
+
+	peak := 0
+	for _, p := range points {
+		n := 0
+		for _, s := range slots {
+			if !s.start.After(p) && s.end.After(p) {
+				n++
+			}
+		}
+		peak = max(peak, n)
+	}
+	return peak
+}
+
+// roomSlots loads the bookings and recurring occurrences of a room that overlap [from, to)
+func roomSlots(ctx context.Context, db *sql.DB, roomID int64, from, to time.Time) ([]slot, error) {
+	query := `
+		SELECT starts_at, ends_at
+		FROM bookings
+		WHERE room_id = ? AND starts_at < ? AND ends_at > ?
+	`
+
+	rows, err := db.QueryContext(ctx, query, roomID, formatBookingTime(to), formatBookingTime(from))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query bookings: %w", err)
+	}
+	defer rows.Close()
+
+	var slots []slot
+	for rows.Next() {
+		v, err := scanValues(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan booking: %w", err)
+		}
+		slots = append(slots, slot{start: parseTimeValue(v["starts_at"]), end: parseTimeValue(v["ends_at"])})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	occurrences, err := roomOccurrences(ctx, db, roomID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range occurrences {
+		slots = append(slots, slot{start: o.StartsAt, end: o.EndsAt, seriesID: o.SeriesID, recurrenceID: o.RecurrenceID})
+	}
+
+	return slots, nil
+}
+
+// roomCapacity returns the capacity of a room, or ErrRoomNotFound
+func roomCapacity(ctx context.Context, db *sql.DB, roomID int64) (int, error) {
+	var capacity int
+	err := db.QueryRowContext(ctx, `SELECT capacity FROM rooms WHERE id = ?`, roomID).Scan(&capacity)
+	if err == sql.ErrNoRows {
+		return 0, ErrRoomNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get room capacity: %w", err)
+	}
+	return capacity, nil
+}
+
+// checkExists returns notFound when table has no row with the given id
+func checkExists(ctx context.Context, db *sql.DB, table string, id int64, notFound error) error {
+	var count int
+	query := `SELECT COUNT(*) FROM ` + table + ` WHERE id = ?`
+	if err := db.QueryRowContext(ctx, query, id).Scan(&count); err != nil {
+		return fmt.Errorf("failed to check %s: %w", table, err)
+	}
+
+	if count == 0 {
+		return notFound
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"html"
+	"strings"
+
+	"cloudflaredb/internal/models"
+)
+
+// highlightOpen and highlightClose delimit matched terms in the highlights built by FTS5. They are
+// the STX and ETX control characters rather than markup, so the stored text around them can be
+// HTML-escaped before highlightMarkup turns them into <mark> tags.
+const (
+	highlightOpen  = "char(2)"
+	highlightClose = "char(3)"
+)
+
+// highlightMarkup replaces the highlight delimiters with <mark> tags
+var highlightMarkup = strings.NewReplacer("\x02", "<mark>", "\x03", "</mark>")
+
+// ftsQuery turns free text into an FTS5 query matching every term as a prefix.
+// Terms are quoted so FTS5 operators and punctuation in user input are taken literally.
+func ftsQuery(q string) string {
+	terms := strings.Fields(q)
+	for i, term := range terms {
+		terms[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"*`
+	}
+	return strings.Join(terms, " ")
+}
+
+// isSearchIndexUnavailable reports whether err means the FTS5 module or index tables are missing,
+// e.g. because the search migration was skipped on a build without FTS5
+func isSearchIndexUnavailable(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "no such module: fts5") ||
+		(strings.Contains(msg, "no such table") && strings.Contains(msg, "_fts"))
+}
+
+// termConditions matches rows where every term of q appears in at least one of the columns
+func termConditions(q string, columns ...string) *conditions {
+	c := &conditions{}
+	for _, term := range strings.Fields(q) {
+		pattern := "%" + escapeLike(term) + "%"
+		clauses := make([]string, len(columns))
+		args := make([]interface{}, len(columns))
+		for i, column := range columns {
+			clauses[i] = column + ` LIKE ? ESCAPE '\'`
+			args[i] = pattern
+		}
+		c.add("("+strings.Join(clauses, " OR ")+")", args...)
+	}
+	return c
+}
+
+// Search returns up to limit rooms whose name or description match q, best matches first,
+// along with the search mode. Without the FTS5 index it falls back to unranked substring matching.
+func (r *RoomRepository) Search(ctx context.Context, q string, limit int) ([]*models.RoomSearchHit, string, error) {
+	if strings.TrimSpace(q) == "" {
+		return nil, models.SearchModeFullText, nil
+	}
+
+	query := `
+		SELECT rooms.*,
+		       bm25(rooms_fts) AS score,
+		       highlight(rooms_fts, 0, ` + highlightOpen + `, ` + highlightClose + `) AS name_highlight,
+		       snippet(rooms_fts, 1, ` + highlightOpen + `, ` + highlightClose + `, '…', 16) AS description_highlight
+		FROM rooms_fts
+		INNER JOIN rooms ON rooms.id = rooms_fts.rowid
+		WHERE rooms_fts MATCH ?
+		ORDER BY score
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, ftsQuery(q), limit)
+	if err != nil {
+		if isSearchIndexUnavailable(err) {
+			return r.searchBasic(ctx, q, limit)
+		}
+		return nil, "", fmt.Errorf("failed to search rooms: %w", err)
+	}
+	defer rows.Close()
+
+	hits, err := r.scanRoomHits(ctx, rows)
+	if err != nil {
+		return nil, "", err
+	}
+	return hits, models.SearchModeFullText, nil
+}
+
+// searchBasic matches rooms with LIKE when the FTS5 index is unavailable
+func (r *RoomRepository) searchBasic(ctx context.Context, q string, limit int) ([]*models.RoomSearchHit, string, error) {
+	c := termConditions(q, "name", "description")
+	query := `
+		SELECT *
+		FROM rooms
+		` + c.where() + `
+		ORDER BY name, id
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, append(c.args, limit)...)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to search rooms: %w", err)
+	}
+	defer rows.Close()
+
+	hits, err := r.scanRoomHits(ctx, rows)
+	if err != nil {
+		return nil, "", err
+	}
+	return hits, models.SearchModeBasic, nil
+}
+
+// scanRoomHits scans room search rows; rank and highlight columns are optional
+func (r *RoomRepository) scanRoomHits(ctx context.Context, rows *sql.Rows) ([]*models.RoomSearchHit, error) {
+	var hits []*models.RoomSearchHit
+	for rows.Next() {
+		v, err := scanValues(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan room: %w", err)
+		}
+
+		hit := &models.RoomSearchHit{
+			Room:       roomFromValues(v),
+			Rank:       toFloat64(v["score"]),
+			Highlights: highlights(v, "name", "description"),
+		}
+		hits = append(hits, hit)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return hits, nil
+}
+
+// Search returns up to limit users whose name or email match q, best matches first,
+// along with the search mode. Without the FTS5 index it falls back to unranked substring matching.
+func (r *UserRepository) Search(ctx context.Context, q string, limit int) ([]*models.UserSearchHit, string, error) {
+	if strings.TrimSpace(q) == "" {
+		return nil, models.SearchModeFullText, nil
+	}
+
+	query := `
+		SELECT users.*,
+		       bm25(users_fts) AS score,
+		       highlight(users_fts, 0, ` + highlightOpen + `, ` + highlightClose + `) AS name_highlight,
+		       highlight(users_fts, 1, ` + highlightOpen + `, ` + highlightClose + `) AS email_highlight
+		FROM users_fts
+		INNER JOIN users ON users.id = users_fts.rowid
+		WHERE users_fts MATCH ?
+		ORDER BY score
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, ftsQuery(q), limit)
+	if err != nil {
+		if isSearchIndexUnavailable(err) {
+			return r.searchBasic(ctx, q, limit)
+		}
+		return nil, "", fmt.Errorf("failed to search users: %w", err)
+	}
+	defer rows.Close()
+
+	hits, err := scanUserHits(rows)
+	if err != nil {
+		return nil, "", err
+	}
+	return hits, models.SearchModeFullText, nil
+}
+
+// searchBasic matches users with LIKE when the FTS5 index is unavailable
+func (r *UserRepository) searchBasic(ctx context.Context, q string, limit int) ([]*models.UserSearchHit, string, error) {
+	c := termConditions(q, "name", "email")
+	query := `
+		SELECT *
+		FROM users
+		` + c.where() + `
+		ORDER BY name, id
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, append(c.args, limit)...)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to search users: %w", err)
+	}
+	defer rows.Close()
+
+	hits, err := scanUserHits(rows)
+	if err != nil {
+		return nil, "", err
+	}
+	return hits, models.SearchModeBasic, nil
+}
+
+// scanUserHits scans user search rows; rank and highlight columns are optional
+func scanUserHits(rows *sql.Rows) ([]*models.UserSearchHit, error) {
+	var hits []*models.UserSearchHit
+	for rows.Next() {
+		v, err := scanValues(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+
+		hit := &models.UserSearchHit{
+			User: models.User{
+				ID:        toInt64(v["id"]),
+				Email:     toString(v["email"]),
+				Name:      toString(v["name"]),
+				CreatedAt: parseTimeValue(v["created_at"]),
+				UpdatedAt: parseTimeValue(v["updated_at"]),
+				Version:   toInt64(v["version"]),
+			},
+			Rank:       toFloat64(v["score"]),
+			Highlights: highlights(v, "name", "email"),
+		}
+		hits = append(hits, hit)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return hits, nil
+}
+
+// highlights collects the <field>_highlight columns of a row as HTML with matches wrapped in
+// <mark> tags, or nil when there are none
+func highlights(v map[string]interface{}, fields ...string) map[string]string {
+	var out map[string]string
+	for _, field := range fields {
+		if h := toString(v[field+"_highlight"]); h != "" {
+			if out == nil {
+				out = make(map[string]string, len(fields))
+			}
+			out[field] = highlightMarkup.Replace(html.EscapeString(h))
+		}
+	}
+	return out
+}
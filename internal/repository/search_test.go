@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"cloudflaredb/internal/models"
+)
+
+// seedSearchData creates the rooms and users used by the search tests
+func seedSearchData(t *testing.T, rooms *RoomRepository, users *UserRepository) {
+	t.Helper()
+	ctx := context.Background()
+
+	for _, room := range []models.CreateRoomRequest{
+		{Name: "Aquarium", Description: "Glass walls and a projector for design reviews", Capacity: 8},
+		{Name: "Library", Description: "Quiet room, no calls", Capacity: 4},
+		{Name: "Projector Lab", Description: "Two projectors", Capacity: 12},
+	} {
+		if _, err := rooms.Create(ctx, &room); err != nil {
+			t.Fatalf("Failed to create test room: %v", err)
+		}
+	}
+
+	if _, err := users.Create(ctx, &models.CreateUserRequest{Email: "grace@navy.mil", Name: "Grace Hopper"}); err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+}
+
+func TestSearch_BasicFallback(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	rooms := NewRoomRepository(db)
+	users := NewUserRepository(db, rooms)
+	seedSearchData(t, rooms, users)
+	ctx := context.Background()
+
+	// No FTS5 tables exist, so search must fall back to substring matching
+	hits, mode, err := rooms.Search(ctx, "PROJECTOR", 10)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if mode != models.SearchModeBasic {
+		t.Errorf("Expected mode %s, got %s", models.SearchModeBasic, mode)
+	}
+	if len(hits) != 2 || hits[0].Name != "Aquarium" || hits[1].Name != "Projector Lab" {
+		t.Errorf("Expected Aquarium and Projector Lab, got %+v", hits)
+	}
+
+	userHits, _, err := users.Search(ctx, "navy grace", 10)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(userHits) != 1 || userHits[0].Email != "grace@navy.mil" {
+		t.Errorf("Expected Grace Hopper, got %+v", userHits)
+	}
+}
+
+func TestSearch_FullText(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	migration, err := os.ReadFile("../database/migrations/004_create_search_index.up.sql")
+	if err != nil {
+		t.Fatalf("Failed to read search migration: %v", err)
+	}
+	if _, err := db.Exec(string(migration)); err != nil {
+		if strings.Contains(err.Error(), "no such module: fts5") {
+			t.Skip("SQLite driver built without FTS5 (use -tags sqlite_fts5)")
+		}
+		t.Fatalf("Failed to apply search migration: %v", err)
+	}
+
+	rooms := NewRoomRepository(db)
+	users := NewUserRepository(db, rooms)
+	seedSearchData(t, rooms, users)
+	ctx := context.Background()
+
+	hits, mode, err := rooms.Search(ctx, "project", 10)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if mode != models.SearchModeFullText {
+		t.Errorf("Expected mode %s, got %s", models.SearchModeFullText, mode)
+	}
+	if len(hits) != 2 {
+		t.Fatalf("Expected 2 hits, got %+v", hits)
+	}
+	// The room matching in both name and description ranks first
+	if hits[0].Name != "Projector Lab" || hits[0].Rank > hits[1].Rank {
+		t.Errorf("Expected Projector Lab ranked first, got %s (%f) then %s (%f)",
+			hits[0].Name, hits[0].Rank, hits[1].Name, hits[1].Rank)
+	}
+	if hits[0].Highlights["name"] != "<mark>Projector</mark> Lab" {
+		t.Errorf("Unexpected name highlight %q", hits[0].Highlights["name"])
+	}
+
+	// Triggers keep the index in sync with updates and deletes
+	if _, err := rooms.Patch(ctx, hits[1].ID, 0, &models.PatchRoomRequest{Description: models.Set("Fish tank views")}); err != nil {
+		t.Fatalf("Patch() error = %v", err)
+	}
+	if err := rooms.Delete(ctx, hits[0].ID, 0); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if hits, _, err = rooms.Search(ctx, "projector", 10); err != nil || len(hits) != 0 {
+		t.Errorf("Expected no hits after update and delete, got %+v (err %v)", hits, err)
+	}
+
+	// FTS5 syntax in user input is matched literally instead of failing
+	if _, _, err := users.Search(ctx, `grace" OR NEAR(`, 10); err != nil {
+		t.Errorf("Search() with FTS5 syntax error = %v", err)
+	}
+
+	// Markup in stored text is escaped, only the highlights are tags
+	if _, err := users.Create(ctx, &models.CreateUserRequest{Email: "mallory@example.com", Name: "<script>Mallory</script>"}); err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+	userHits, _, err := users.Search(ctx, "mallory", 10)
+	if err != nil || len(userHits) != 1 {
+		t.Fatalf("Expected Mallory, got %+v (err %v)", userHits, err)
+	}
+	if got, want := userHits[0].Highlights["name"], "&lt;script&gt;<mark>Mallory</mark>&lt;/script&gt;"; got != want {
+		t.Errorf("Name highlight = %q, want %q", got, want)
+	}
+}
+
+func TestHighlights_EscapeText(t *testing.T) {
+	row := map[string]interface{}{
+		"name_highlight":        "<img src=x onerror=alert(1)> \x02Lab\x03",
+		"description_highlight": "",
+	}
+
+	got := highlights(row, "name", "description")
+	want := map[string]string{"name": "&lt;img src=x onerror=alert(1)&gt; <mark>Lab</mark>"}
+	if len(got) != 1 || got["name"] != want["name"] {
+		t.Errorf("highlights() = %v, want %v", got, want)
+	}
+	if highlights(map[string]interface{}{}, "name") != nil {
+		t.Error("Expected nil highlights for a row without highlight columns")
+	}
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// execer is implemented by *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// inTx runs fn, which makes several related writes, as a single unit. On SQLite the writes happen
+// in one transaction, so a failing statement leaves none of them behind. D1 has no interactive
+// transactions: there fn runs statement by statement, and a write that fails halfway keeps the
+// earlier ones, so fn's statements must be safe to repeat.
+// fn must only use the execer it is given; on SQLite the connection of the transaction is the
+// only one that sees its writes.
+func inTx(ctx context.Context, db *sql.DB, fn func(execer) error) error {
+	if _, ok := db.Driver().(*sqlite3.SQLiteDriver); !ok {
+		return fn(db)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -9,14 +9,16 @@ import (
 	"cloudflaredb/internal/models"
 )
 
-// UserRepository handles database operations for users
+// UserRepository handles database operations for users. Deleting a user frees their seats,
+// which go to the rooms' waitlists through RoomRepository.
 type UserRepository struct {
-	db *sql.DB
+	db    *sql.DB
+	rooms *RoomRepository
 }
 
 // NewUserRepository creates a new user repository
-func NewUserRepository(db *sql.DB) *UserRepository {
-	return &UserRepository{db: db}
+func NewUserRepository(db *sql.DB, rooms *RoomRepository) *UserRepository {
+	return &UserRepository{db: db, rooms: rooms}
 }
 
 // Create inserts a new user into the database
@@ -29,7 +31,7 @@ func (r *UserRepository) Create(ctx context.Context, req *models.CreateUserReque
 	now := time.Now()
 	result, err := r.db.ExecContext(ctx, query, req.Email, req.Name, now, now)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
+		return nil, fmt.Errorf("failed to create user: %w", classifyError(err))
 	}
 
 	id, err := result.LastInsertId()
@@ -56,11 +58,11 @@ func (r *UserRepository) GetByID(ctx context.Context, id int64) (*models.User, e
 	defer rows.Close()
 
 	if !rows.Next() {
-		return nil, fmt.Errorf("user not found")
+		return nil, ErrUserNotFound
 	}
 
 	user := &models.User{}
-	err = scanUser(rows, &user.ID, &user.Email, &user.Name, &user.CreatedAt, &user.UpdatedAt)
+	err = scanUser(rows, &user.ID, &user.Email, &user.Name, &user.CreatedAt, &user.UpdatedAt, &user.Version)
 	if err != nil {
 		return nil, fmt.Errorf("failed to scan user: %w", err)
 	}
@@ -83,11 +85,11 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.
 	defer rows.Close()
 
 	if !rows.Next() {
-		return nil, fmt.Errorf("user not found")
+		return nil, ErrUserNotFound
 	}
 
 	user := &models.User{}
-	err = scanUser(rows, &user.ID, &user.Email, &user.Name, &user.CreatedAt, &user.UpdatedAt)
+	err = scanUser(rows, &user.ID, &user.Email, &user.Name, &user.CreatedAt, &user.UpdatedAt, &user.Version)
 	if err != nil {
 		return nil, fmt.Errorf("failed to scan user: %w", err)
 	}
@@ -95,16 +97,39 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.
 	return user, nil
 }
 
-// List retrieves all users with pagination
-func (r *UserRepository) List(ctx context.Context, limit, offset int) ([]*models.User, error) {
+// List retrieves the users matching filter, sorted and paginated according to opts
+func (r *UserRepository) List(ctx context.Context, filter UserFilter, opts ListOptions) ([]*models.User, error) {
+	c := userConditions(filter)
+	tail, err := listClauses(c, opts, UserSortColumns)
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
 		SELECT *
 		FROM users
-		ORDER BY created_at DESC
-		LIMIT ? OFFSET ?
-	`
+		` + c.where() + `
+		` + tail
+
+	return r.queryUsers(ctx, query, c.args...)
+}
+
+// Count returns the number of users matching filter
+func (r *UserRepository) Count(ctx context.Context, filter UserFilter) (int, error) {
+	c := userConditions(filter)
+	query := `SELECT COUNT(*) FROM users ` + c.where()
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, c.args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count users: %w", err)
+	}
 
-	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	return count, nil
+}
+
+// queryUsers runs a query returning full user rows and scans them
+func (r *UserRepository) queryUsers(ctx context.Context, query string, args ...interface{}) ([]*models.User, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list users: %w", err)
 	}
@@ -113,7 +138,7 @@ func (r *UserRepository) List(ctx context.Context, limit, offset int) ([]*models
 	var users []*models.User
 	for rows.Next() {
 		user := &models.User{}
-		err := scanUser(rows, &user.ID, &user.Email, &user.Name, &user.CreatedAt, &user.UpdatedAt)
+		err := scanUser(rows, &user.ID, &user.Email, &user.Name, &user.CreatedAt, &user.UpdatedAt, &user.Version)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan user: %w", err)
 		}
@@ -127,20 +152,37 @@ func (r *UserRepository) List(ctx context.Context, limit, offset int) ([]*models
 	return users, nil
 }
 
-// Update updates a user's information
-func (r *UserRepository) Update(ctx context.Context, id int64, req *models.UpdateUserRequest) (*models.User, error) {
-	query := `
-		UPDATE users
-		SET email = COALESCE(NULLIF(?, ''), email),
-		    name = COALESCE(NULLIF(?, ''), name),
-		    updated_at = ?
-		WHERE id = ?
-	`
+// Update replaces a user's information. A non-zero version must match the current one,
+// otherwise ErrVersionMismatch is returned; the check is part of the UPDATE statement.
+func (r *UserRepository) Update(ctx context.Context, id, version int64, req *models.UpdateUserRequest) (*models.User, error) {
+	var set assignments
+	set.set("email", req.Email)
+	set.set("name", req.Name)
+	return r.update(ctx, id, version, &set)
+}
 
-	now := time.Now()
-	result, err := r.db.ExecContext(ctx, query, req.Email, req.Name, now, id)
+// Patch applies a merge patch to a user, updating only the fields it contains. A non-zero
+// version must match the current one, as for Update.
+func (r *UserRepository) Patch(ctx context.Context, id, version int64, req *models.PatchUserRequest) (*models.User, error) {
+	var set assignments
+	if req.Email.Set {
+		set.set("email", req.Email.Value)
+	}
+	if req.Name.Set {
+		set.set("name", req.Name.Value)
+	}
+	return r.update(ctx, id, version, &set)
+}
+
+// update assigns the columns in set, bumps updated_at and the version, and checks a non-zero version
+func (r *UserRepository) update(ctx context.Context, id, version int64, set *assignments) (*models.User, error) {
+	set.set("updated_at", time.Now())
+	set.setExpr("version = version + 1")
+	query := `UPDATE users SET ` + set.sql() + ` WHERE id = ? AND (? = 0 OR version = ?)`
+
+	result, err := r.db.ExecContext(ctx, query, append(set.args, id, version, version)...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to update user: %w", err)
+		return nil, fmt.Errorf("failed to update user: %w", classifyError(err))
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -149,17 +191,28 @@ func (r *UserRepository) Update(ctx context.Context, id int64, req *models.Updat
 	}
 
 	if rowsAffected == 0 {
-		return nil, fmt.Errorf("user not found")
+		return nil, r.missingOrChanged(ctx, id)
 	}
 
 	return r.GetByID(ctx, id)
 }
 
-// Delete removes a user from the database
-func (r *UserRepository) Delete(ctx context.Context, id int64) error {
-	query := `DELETE FROM users WHERE id = ?`
+// Delete removes a user from the database. A non-zero version must match the current one,
+// otherwise ErrVersionMismatch is returned.
+// The user leaves their rooms, waitlists, groups and bookings, and the seats they held, directly or
+// through a group, go to the rooms' waitlists. Rooms the user was the last owner of pass to their
+// longest-standing remaining member. D1 cascades the deletion to these rows, but SQLite only
+// does when foreign keys are enforced, so they are deleted explicitly; the rooms are looked up first
+// because the cascade would hide them.
+func (r *UserRepository) Delete(ctx context.Context, id, version int64) error {
+	roomIDs, err := r.rooms.seatRoomIDs(ctx, id)
+	if err != nil {
+		return err
+	}
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	query := `DELETE FROM users WHERE id = ? AND (? = 0 OR version = ?)`
+
+	result, err := r.db.ExecContext(ctx, query, id, version, version)
 	if err != nil {
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
@@ -170,8 +223,31 @@ func (r *UserRepository) Delete(ctx context.Context, id int64) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("user not found")
+		return r.missingOrChanged(ctx, id)
+	}
+
+	for _, table := range []string{"room_waitlist", "user_rooms", "group_members", "bookings", "recurring_bookings"} {
+		if _, err := r.db.ExecContext(ctx, `DELETE FROM `+table+` WHERE user_id = ?`, id); err != nil {
+			return fmt.Errorf("failed to remove user from %s: %w", table, err)
+		}
+	}
+
+	// The user_rooms_promote_after_delete trigger already handed over ownership and gave the user's
+	// direct seats away; the seats they held through groups are given here
+	for _, roomID := range roomIDs {
+		if _, err := r.rooms.promoteWaitlisted(ctx, roomID); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
+
+// missingOrChanged explains why a versioned statement on a user affected no row:
+// ErrUserNotFound when the user does not exist, ErrVersionMismatch otherwise
+func (r *UserRepository) missingOrChanged(ctx context.Context, id int64) error {
+	if _, err := r.GetByID(ctx, id); err != nil {
+		return err
+	}
+	return ErrVersionMismatch
+}
@@ -3,6 +3,8 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"testing"
 
 	"cloudflaredb/internal/models"
@@ -26,7 +28,8 @@ func setupTestDB(t *testing.T) *sql.DB {
 		email TEXT NOT NULL UNIQUE,
 		name TEXT NOT NULL,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		version INTEGER NOT NULL DEFAULT 1
 	);
 	CREATE INDEX idx_users_email ON users(email);
 	`
@@ -42,7 +45,7 @@ func TestUserRepository_Create(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
 
-	repo := NewUserRepository(db)
+	repo := NewUserRepository(db, NewRoomRepository(db))
 	ctx := context.Background()
 
 	tests := []struct {
@@ -98,7 +101,7 @@ func TestUserRepository_GetByID(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
 
-	repo := NewUserRepository(db)
+	repo := NewUserRepository(db, NewRoomRepository(db))
 	ctx := context.Background()
 
 	// Create a test user
@@ -151,7 +154,7 @@ func TestUserRepository_GetByEmail(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
 
-	repo := NewUserRepository(db)
+	repo := NewUserRepository(db, NewRoomRepository(db))
 	ctx := context.Background()
 
 	// Create a test user
@@ -201,7 +204,7 @@ func TestUserRepository_List(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
 
-	repo := NewUserRepository(db)
+	repo := NewUserRepository(db, NewRoomRepository(db))
 	ctx := context.Background()
 
 	// Create test users
@@ -243,7 +246,7 @@ func TestUserRepository_List(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			users, err := repo.List(ctx, tt.limit, tt.offset)
+			users, err := repo.List(ctx, UserFilter{}, ListOptions{Limit: tt.limit, Offset: tt.offset})
 			if err != nil {
 				t.Errorf("List() error = %v", err)
 				return
@@ -256,11 +259,102 @@ func TestUserRepository_List(t *testing.T) {
 	}
 }
 
+func TestUserRepository_ListCursor(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewUserRepository(db, NewRoomRepository(db))
+	ctx := context.Background()
+
+	for i := 1; i <= 5; i++ {
+		_, err := repo.Create(ctx, &models.CreateUserRequest{
+			Email: fmt.Sprintf("test%d@example.com", i),
+			Name:  "Test User",
+		})
+		if err != nil {
+			t.Fatalf("Failed to create test user: %v", err)
+		}
+	}
+
+	first, err := repo.List(ctx, UserFilter{}, ListOptions{Limit: 2})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(first) != 2 || first[0].ID != 5 || first[1].ID != 4 {
+		t.Fatalf("Expected users 5 and 4 on the first page, got %+v", first)
+	}
+
+	// A user created between pages must not shift the next page
+	if _, err := repo.Create(ctx, &models.CreateUserRequest{Email: "late@example.com", Name: "Late"}); err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	last := first[len(first)-1]
+	cursor, err := DecodeCursor(Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode())
+	if err != nil {
+		t.Fatalf("DecodeCursor() error = %v", err)
+	}
+
+	rest, err := repo.List(ctx, UserFilter{}, ListOptions{Limit: 10, After: cursor})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(rest) != 3 || rest[0].ID != 3 || rest[2].ID != 1 {
+		t.Errorf("Expected users 3, 2 and 1 after the cursor, got %+v", rest)
+	}
+}
+
+func TestUserRepository_Count(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewUserRepository(db, NewRoomRepository(db))
+	ctx := context.Background()
+
+	for _, name := range []string{"Ada", "Alan", "Grace"} {
+		if _, err := repo.Create(ctx, &models.CreateUserRequest{Email: name + "@example.com", Name: name}); err != nil {
+			t.Fatalf("Failed to create test user: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter UserFilter
+		want   int
+	}{
+		{name: "all", filter: UserFilter{}, want: 3},
+		{name: "name contains", filter: UserFilter{NameContains: "a"}, want: 3},
+		{name: "name contains al", filter: UserFilter{NameContains: "al"}, want: 1},
+		{name: "email", filter: UserFilter{Email: "Grace@example.com"}, want: 1},
+		{name: "no match", filter: UserFilter{Email: "nobody@example.com"}, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.Count(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("Count() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Count() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeCursor_Invalid(t *testing.T) {
+	for _, token := range []string{"not base64!", "bm90IGpzb24", Cursor{}.Encode()} {
+		if _, err := DecodeCursor(token); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("DecodeCursor(%q) error = %v, want ErrInvalidCursor", token, err)
+		}
+	}
+}
+
 func TestUserRepository_Update(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
 
-	repo := NewUserRepository(db)
+	repo := NewUserRepository(db, NewRoomRepository(db))
 	ctx := context.Background()
 
 	// Create a test user
@@ -282,7 +376,8 @@ func TestUserRepository_Update(t *testing.T) {
 			name: "update name",
 			id:   user.ID,
 			req: &models.UpdateUserRequest{
-				Name: "Updated Name",
+				Email: "test@example.com",
+				Name:  "Updated Name",
 			},
 			wantErr: false,
 		},
@@ -291,6 +386,7 @@ func TestUserRepository_Update(t *testing.T) {
 			id:   user.ID,
 			req: &models.UpdateUserRequest{
 				Email: "updated@example.com",
+				Name:  "Updated Name",
 			},
 			wantErr: false,
 		},
@@ -298,7 +394,8 @@ func TestUserRepository_Update(t *testing.T) {
 			name: "update non-existent user",
 			id:   9999,
 			req: &models.UpdateUserRequest{
-				Name: "Should Fail",
+				Email: "missing@example.com",
+				Name:  "Should Fail",
 			},
 			wantErr: true,
 		},
@@ -306,29 +403,105 @@ func TestUserRepository_Update(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			updated, err := repo.Update(ctx, tt.id, tt.req)
+			updated, err := repo.Update(ctx, tt.id, 0, tt.req)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Update() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 
 			if !tt.wantErr {
-				if tt.req.Name != "" && updated.Name != tt.req.Name {
-					t.Errorf("Expected name %s, got %s", tt.req.Name, updated.Name)
-				}
-				if tt.req.Email != "" && updated.Email != tt.req.Email {
-					t.Errorf("Expected email %s, got %s", tt.req.Email, updated.Email)
+				if updated.Name != tt.req.Name || updated.Email != tt.req.Email {
+					t.Errorf("Expected %s <%s>, got %s <%s>", tt.req.Name, tt.req.Email, updated.Name, updated.Email)
 				}
 			}
 		})
 	}
 }
 
-func TestUserRepository_Delete(t *testing.T) {
+func TestUserRepository_Patch(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
 
-	repo := NewUserRepository(db)
+	repo := NewUserRepository(db, NewRoomRepository(db))
+	ctx := context.Background()
+
+	user, err := repo.Create(ctx, &models.CreateUserRequest{Email: "test@example.com", Name: "Test User"})
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+	if _, err := repo.Create(ctx, &models.CreateUserRequest{Email: "taken@example.com", Name: "Other"}); err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	// Fields left out of the patch keep their values
+	patched, err := repo.Patch(ctx, user.ID, 0, &models.PatchUserRequest{Name: models.Set("Patched Name")})
+	if err != nil {
+		t.Fatalf("Patch() error = %v", err)
+	}
+	if patched.Name != "Patched Name" || patched.Email != "test@example.com" {
+		t.Errorf("Expected only the name to change, got %s <%s>", patched.Name, patched.Email)
+	}
+
+	// An empty patch only bumps updated_at
+	if patched, err = repo.Patch(ctx, user.ID, 0, &models.PatchUserRequest{}); err != nil || patched.Name != "Patched Name" {
+		t.Errorf("Expected an empty patch to keep the user, got %+v (err %v)", patched, err)
+	}
+
+	if _, err := repo.Patch(ctx, user.ID, 0, &models.PatchUserRequest{Email: models.Set("taken@example.com")}); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict, got %v", err)
+	}
+	if _, err := repo.Patch(ctx, 9999, 0, &models.PatchUserRequest{Name: models.Set("Missing")}); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound, got %v", err)
+	}
+}
+
+func TestUserRepository_Versions(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	repo := NewUserRepository(db, NewRoomRepository(db))
+	ctx := context.Background()
+
+	user, err := repo.Create(ctx, &models.CreateUserRequest{Email: "test@example.com", Name: "Test User"})
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+	if user.Version != 1 {
+		t.Errorf("Expected a new user to have version 1, got %d", user.Version)
+	}
+
+	updated, err := repo.Update(ctx, user.ID, user.Version, &models.UpdateUserRequest{Email: "test@example.com", Name: "Renamed"})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if updated.Version != 2 {
+		t.Errorf("Expected the update to bump the version to 2, got %d", updated.Version)
+	}
+
+	// Writes based on the old version are rejected and change nothing
+	if _, err := repo.Patch(ctx, user.ID, user.Version, &models.PatchUserRequest{Name: models.Set("Stale")}); !errors.Is(err, ErrVersionMismatch) {
+		t.Errorf("Expected ErrVersionMismatch, got %v", err)
+	}
+	if err := repo.Delete(ctx, user.ID, user.Version); !errors.Is(err, ErrVersionMismatch) {
+		t.Errorf("Expected ErrVersionMismatch, got %v", err)
+	}
+	if current, _ := repo.GetByID(ctx, user.ID); current == nil || current.Name != "Renamed" || current.Version != 2 {
+		t.Errorf("Expected stale writes to leave the user unchanged, got %+v", current)
+	}
+
+	if _, err := repo.Patch(ctx, 9999, 1, &models.PatchUserRequest{Name: models.Set("Missing")}); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound, got %v", err)
+	}
+	if err := repo.Delete(ctx, user.ID, updated.Version); err != nil {
+		t.Errorf("Delete() error = %v", err)
+	}
+}
+
+func TestUserRepository_Delete(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	repo := NewUserRepository(db, NewRoomRepository(db))
 	ctx := context.Background()
 
 	// Create a test user
@@ -359,7 +532,7 @@ func TestUserRepository_Delete(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := repo.Delete(ctx, tt.id)
+			err := repo.Delete(ctx, tt.id, 0)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Delete() error = %v, wantErr %v", err, tt.wantErr)
 				return
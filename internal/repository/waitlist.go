@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloudflaredb/internal/events"
+	"cloudflaredb/internal/models"
+)
+
+// SetEventPublisher sets the publisher that receives the repository's events; nil disables them
+func (r *RoomRepository) SetEventPublisher(p events.Publisher) {
+	r.events = p
+}
+
+// JoinWaitlist queues a user for a seat in a room. When a seat is free and nobody is ahead in the
+// queue the user is assigned right away, and the entry reports WaitlistStatusAssigned.
+func (r *RoomRepository) JoinWaitlist(ctx context.Context, roomID, userID int64) (*models.WaitlistEntry, error) {
+	if err := checkExists(ctx, r.db, "rooms", roomID, ErrRoomNotFound); err != nil {
+		return nil, err
+	}
+	if err := checkExists(ctx, r.db, "users", userID, ErrUserNotFound); err != nil {
+		return nil, err
+	}
+
+	var assigned int
+	checkQuery := `SELECT COUNT(*) FROM user_rooms WHERE user_id = ? AND room_id = ?`
+	if err := r.db.QueryRowContext(ctx, checkQuery, userID, roomID).Scan(&assigned); err != nil {
+		return nil, fmt.Errorf("failed to check existing assignment: %w", err)
+	}
+	if assigned > 0 {
+		return nil, ErrAlreadyAssigned
+	}
+
+	now := time.Now()
+	insertQuery := `INSERT INTO room_waitlist (room_id, user_id, created_at) VALUES (?, ?, ?)`
+	if _, err := r.db.ExecContext(ctx, insertQuery, roomID, userID, now); err != nil {
+		err = classifyError(err)
+		if errors.Is(err, ErrConflict) {
+			return nil, ErrAlreadyWaitlisted
+		}
+		return nil, fmt.Errorf("failed to join waitlist: %w", err)
+	}
+
+	promoted, err := r.promoteWaitlisted(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &models.WaitlistEntry{RoomID: roomID, UserID: userID, Status: models.WaitlistStatusWaiting, CreatedAt: now}
+	for _, p := range promoted {
+		if p.UserID == userID {
+			entry.Status = models.WaitlistStatusAssigned
+			return entry, nil
+		}
+	}
+
+	positionQuery := `
+		SELECT COUNT(*)
+		FROM room_waitlist
+		WHERE room_id = ?
+		  AND id <= (SELECT id FROM room_waitlist WHERE room_id = ? AND user_id = ?)
+	`
+	if err := r.db.QueryRowContext(ctx, positionQuery, roomID, roomID, userID).Scan(&entry.Position); err != nil {
+		return nil, fmt.Errorf("failed to get waitlist position: %w", err)
+	}
+
+	return entry, nil
+}
+
+// AssignOrWaitlist assigns a user to a room like AssignUserToRoom, but queues them on the room's
+// waitlist instead of failing with ErrRoomFull when it has no free seat. Exactly one of the
+// membership and the waitlist entry is returned. A queued user gets the member role when promoted,
+// or becomes the owner of a room left without members.
+func (r *RoomRepository) AssignOrWaitlist(ctx context.Context, userID, roomID int64, role string) (*models.UserRoom, *models.WaitlistEntry, error) {
+	membership, err := r.AssignUserToRoom(ctx, userID, roomID, role)
+	if !errors.Is(err, ErrRoomFull) {
+		return membership, nil, err
+	}
+
+	entry, err := r.JoinWaitlist(ctx, roomID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if entry.Status == models.WaitlistStatusAssigned {
+		// A seat was freed in the meantime and the user got it right away
+		membership, err := r.GetMembership(ctx, roomID, userID)
+		return membership, nil, err
+	}
+
+	return nil, entry, nil
+}
+
+// Waitlist returns the users queued for a room in FIFO order
+func (r *RoomRepository) Waitlist(ctx context.Context, roomID int64) ([]*models.WaitlistEntry, error) {
+	if err := checkExists(ctx, r.db, "rooms", roomID, ErrRoomNotFound); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT room_id, user_id, created_at
+		FROM room_waitlist
+		WHERE room_id = ?
+		ORDER BY id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, roomID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query waitlist: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*models.WaitlistEntry
+	for rows.Next() {
+		v, err := scanValues(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan waitlist entry: %w", err)
+		}
+
+		entries = append(entries, &models.WaitlistEntry{
+			RoomID:    toInt64(v["room_id"]),
+			UserID:    toInt64(v["user_id"]),
+			Status:    models.WaitlistStatusWaiting,
+			Position:  len(entries) + 1,
+			CreatedAt: parseTimeValue(v["created_at"]),
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return entries, nil
+}
+
+// LeaveWaitlist removes a user from the queue of a room
+func (r *RoomRepository) LeaveWaitlist(ctx context.Context, roomID, userID int64) error {
+	query := `DELETE FROM room_waitlist WHERE room_id = ? AND user_id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, roomID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to leave waitlist: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotWaitlisted
+	}
+
+	return nil
+}
+
+// promoteWaitlisted assigns the longest-waiting users of a room to its free seats, removes them
+// from the queue and publishes an event for each of them.
+// D1 has no interactive transactions, so all of this is a single INSERT ... SELECT: it computes the
+// free seats itself, so concurrent promotions can never overfill the room; the first promoted user
+// of a room without members becomes its owner; and the user_rooms_dequeue_after_insert trigger
+// removes each promoted user from the queue and records the promotion as part of the same statement.
+// Seats freed by deleting from user_rooms are filled by the user_rooms_promote_after_delete trigger
+// instead; either way the events are published from the recorded promotions.
+func (r *RoomRepository) promoteWaitlisted(ctx context.Context, roomID int64) ([]models.WaitlistPromotion, error) {
+	promoteQuery := `
+		INSERT INTO user_rooms (user_id, room_id, role, created_at)
+		SELECT w.user_id, w.room_id,
+		       CASE WHEN NOT EXISTS (SELECT 1 FROM user_rooms WHERE room_id = w.room_id)
+		             AND w.id = (SELECT MIN(f.id) FROM room_waitlist f
+		                         WHERE f.room_id = w.room_id
+		                           AND NOT EXISTS (SELECT 1 FROM user_rooms WHERE user_id = f.user_id AND room_id = f.room_id))
+		            THEN 'owner' ELSE 'member' END,
+		       ?
+		FROM room_waitlist w
+		WHERE w.room_id = ?
+		  AND NOT EXISTS (SELECT 1 FROM user_rooms WHERE user_id = w.user_id AND room_id = w.room_id)
+		ORDER BY w.id
+		LIMIT MAX(0, (SELECT rooms.capacity - ` + roomOccupancySQL + ` FROM rooms WHERE rooms.id = ?))
+	`
+
+	if _, err := r.db.ExecContext(ctx, promoteQuery, time.Now(), roomID, roomID); err != nil {
+		return nil, fmt.Errorf("failed to promote waitlisted users: %w", classifyError(err))
+	}
+
+	return r.publishPromotions(ctx, roomID)
+}
+
+// publishPromotions takes the promotions the triggers recorded for a room out of
+// waitlist_promotions and publishes an event for each of them.
+// Taking them is a single DELETE ... RETURNING, so concurrent callers never publish a promotion
+// twice. On D1 a Worker that stops after freeing a seat leaves the promotion recorded, and its
+// event is published by the next change to the room's seats.
+func (r *RoomRepository) publishPromotions(ctx context.Context, roomID int64) ([]models.WaitlistPromotion, error) {
+	rows, err := r.db.QueryContext(ctx, `DELETE FROM waitlist_promotions WHERE room_id = ? RETURNING user_id, promoted_at`, roomID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to take waitlist promotions: %w", err)
+	}
+	defer rows.Close()
+
+	var promoted []models.WaitlistPromotion
+	for rows.Next() {
+		v, err := scanValues(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan promoted user: %w", err)
+		}
+		promoted = append(promoted, models.WaitlistPromotion{
+			RoomID:     roomID,
+			UserID:     toInt64(v["user_id"]),
+			PromotedAt: parseTimeValue(v["promoted_at"]),
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	if r.events != nil {
+		for _, p := range promoted {
+			r.events.Publish(ctx, events.Event{Type: events.TypeWaitlistPromoted, Time: p.PromotedAt, Data: p})
+		}
+	}
+
+	return promoted, nil
+}
@@ -0,0 +1,407 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"cloudflaredb/internal/events"
+	"cloudflaredb/internal/models"
+)
+
+// seedFullRoom creates a room with the given capacity filled by the first capacity users of n,
+// and returns the room ID and all user IDs
+func seedFullRoom(t *testing.T, repo *RoomRepository, userRepo *UserRepository, capacity, n int) (int64, []int64) {
+	t.Helper()
+	ctx := context.Background()
+
+	room, err := repo.Create(ctx, &models.CreateRoomRequest{Name: "Waitlist Room", Capacity: capacity})
+	if err != nil {
+		t.Fatalf("Failed to create test room: %v", err)
+	}
+
+	var userIDs []int64
+	for i := 0; i < n; i++ {
+		user, err := userRepo.Create(ctx, &models.CreateUserRequest{
+			Email: fmt.Sprintf("waiter%d@example.com", i),
+			Name:  fmt.Sprintf("Waiter %d", i),
+		})
+		if err != nil {
+			t.Fatalf("Failed to create test user: %v", err)
+		}
+		userIDs = append(userIDs, user.ID)
+	}
+
+	for _, id := range userIDs[:capacity] {
+		if _, err := repo.AssignUserToRoom(ctx, id, room.ID, ""); err != nil {
+			t.Fatalf("Failed to assign user to room: %v", err)
+		}
+	}
+
+	return room.ID, userIDs
+}
+
+// recorder is an events.Publisher that keeps every published event
+type recorder struct {
+	events []events.Event
+}
+
+func (r *recorder) Publish(_ context.Context, e events.Event) {
+	r.events = append(r.events, e)
+}
+
+func TestRoomRepository_JoinWaitlist(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	repo := NewRoomRepository(db)
+	ctx := context.Background()
+	roomID, userIDs := seedFullRoom(t, repo, NewUserRepository(db, repo), 1, 3)
+
+	for i, id := range userIDs[1:] {
+		entry, err := repo.JoinWaitlist(ctx, roomID, id)
+		if err != nil {
+			t.Fatalf("JoinWaitlist() error = %v", err)
+		}
+		if entry.Status != models.WaitlistStatusWaiting || entry.Position != i+1 {
+			t.Errorf("Expected waiting at position %d, got %+v", i+1, entry)
+		}
+	}
+
+	if _, err := repo.JoinWaitlist(ctx, roomID, userIDs[1]); !errors.Is(err, ErrAlreadyWaitlisted) {
+		t.Errorf("Expected ErrAlreadyWaitlisted, got %v", err)
+	}
+	if _, err := repo.JoinWaitlist(ctx, roomID, userIDs[0]); !errors.Is(err, ErrAlreadyAssigned) {
+		t.Errorf("Expected ErrAlreadyAssigned, got %v", err)
+	}
+	if _, err := repo.JoinWaitlist(ctx, 9999, userIDs[1]); !errors.Is(err, ErrRoomNotFound) {
+		t.Errorf("Expected ErrRoomNotFound, got %v", err)
+	}
+	if _, err := repo.JoinWaitlist(ctx, roomID, 9999); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound, got %v", err)
+	}
+
+	waitlist, err := repo.Waitlist(ctx, roomID)
+	if err != nil {
+		t.Fatalf("Waitlist() error = %v", err)
+	}
+	if len(waitlist) != 2 || waitlist[0].UserID != userIDs[1] || waitlist[1].UserID != userIDs[2] {
+		t.Fatalf("Expected users %v in FIFO order, got %+v", userIDs[1:], waitlist)
+	}
+
+	// Leaving removes the entry once
+	if err := repo.LeaveWaitlist(ctx, roomID, userIDs[1]); err != nil {
+		t.Fatalf("LeaveWaitlist() error = %v", err)
+	}
+	if err := repo.LeaveWaitlist(ctx, roomID, userIDs[1]); !errors.Is(err, ErrNotWaitlisted) {
+		t.Errorf("Expected ErrNotWaitlisted, got %v", err)
+	}
+}
+
+func TestRoomRepository_JoinWaitlistWithFreeSeat(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	repo := NewRoomRepository(db)
+	ctx := context.Background()
+	roomID, userIDs := seedFullRoom(t, repo, NewUserRepository(db, repo), 1, 2)
+
+	if err := repo.RemoveUserFromRoom(ctx, userIDs[0], roomID); err != nil {
+		t.Fatalf("RemoveUserFromRoom() error = %v", err)
+	}
+
+	entry, err := repo.JoinWaitlist(ctx, roomID, userIDs[1])
+	if err != nil {
+		t.Fatalf("JoinWaitlist() error = %v", err)
+	}
+	if entry.Status != models.WaitlistStatusAssigned || entry.Position != 0 {
+		t.Errorf("Expected an immediate assignment, got %+v", entry)
+	}
+
+	waitlist, err := repo.Waitlist(ctx, roomID)
+	if err != nil {
+		t.Fatalf("Waitlist() error = %v", err)
+	}
+	if len(waitlist) != 0 {
+		t.Errorf("Expected an empty waitlist, got %+v", waitlist)
+	}
+}
+
+func TestRoomRepository_WaitlistPromotion(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	repo := NewRoomRepository(db)
+	published := &recorder{}
+	repo.SetEventPublisher(published)
+	ctx := context.Background()
+	roomID, userIDs := seedFullRoom(t, repo, NewUserRepository(db, repo), 2, 5)
+
+	for _, id := range userIDs[2:] {
+		if _, err := repo.JoinWaitlist(ctx, roomID, id); err != nil {
+			t.Fatalf("JoinWaitlist() error = %v", err)
+		}
+	}
+
+	// The freed seat goes to the first user in the queue, not to a direct assignment
+	if err := repo.RemoveUserFromRoom(ctx, userIDs[1], roomID); err != nil {
+		t.Fatalf("RemoveUserFromRoom() error = %v", err)
+	}
+	if _, err := repo.AssignUserToRoom(ctx, userIDs[1], roomID, ""); !errors.Is(err, ErrRoomFull) {
+		t.Errorf("Expected ErrRoomFull, got %v", err)
+	}
+
+	if len(published.events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(published.events))
+	}
+	e := published.events[0]
+	p, ok := e.Data.(models.WaitlistPromotion)
+	if e.Type != events.TypeWaitlistPromoted || !ok || p.UserID != userIDs[2] || p.RoomID != roomID || p.PromotedAt.IsZero() {
+		t.Errorf("Unexpected event: %+v", e)
+	}
+
+	// Leaving every room frees a seat for the next user and drops the leaver's own waitlist entries
+	if err := repo.RemoveUserFromAllRooms(ctx, userIDs[0]); err != nil {
+		t.Fatalf("RemoveUserFromAllRooms() error = %v", err)
+	}
+	if len(published.events) != 2 || published.events[1].Data.(models.WaitlistPromotion).UserID != userIDs[3] {
+		t.Errorf("Expected user %d to be promoted, got %+v", userIDs[3], published.events)
+	}
+
+	room, err := repo.GetRoomWithUsers(ctx, roomID)
+	if err != nil {
+		t.Fatalf("GetRoomWithUsers() error = %v", err)
+	}
+	if len(room.Users) != 2 {
+		t.Errorf("Expected the room to stay at capacity, got %d users", len(room.Users))
+	}
+
+	// The owner left, so the longest-standing member took over
+	if m, err := repo.GetMembership(ctx, roomID, userIDs[2]); err != nil || m.Role != models.RoleOwner {
+		t.Errorf("Expected user %d to own the room, got %+v, %v", userIDs[2], m, err)
+	}
+
+	// Raising the capacity promotes the rest of the queue
+	if _, err := repo.Patch(ctx, roomID, 0, &models.PatchRoomRequest{Capacity: models.Set(5)}); err != nil {
+		t.Fatalf("Patch() error = %v", err)
+	}
+	if len(published.events) != 3 || published.events[2].Data.(models.WaitlistPromotion).UserID != userIDs[4] {
+		t.Errorf("Expected user %d to be promoted, got %+v", userIDs[4], published.events)
+	}
+
+	waitlist, err := repo.Waitlist(ctx, roomID)
+	if err != nil {
+		t.Fatalf("Waitlist() error = %v", err)
+	}
+	if len(waitlist) != 0 {
+		t.Errorf("Expected an empty waitlist, got %+v", waitlist)
+	}
+}
+
+func TestRoomRepository_WaitlistPromotionIntoEmptyRoom(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	repo := NewRoomRepository(db)
+	ctx := context.Background()
+	roomID, userIDs := seedFullRoom(t, repo, NewUserRepository(db, repo), 1, 3)
+
+	for _, id := range userIDs[1:] {
+		if _, err := repo.JoinWaitlist(ctx, roomID, id); err != nil {
+			t.Fatalf("JoinWaitlist() error = %v", err)
+		}
+	}
+
+	// The sole owner leaves, so the promoted user takes over an otherwise empty room
+	if err := repo.RemoveUserFromRoom(ctx, userIDs[0], roomID); err != nil {
+		t.Fatalf("RemoveUserFromRoom() error = %v", err)
+	}
+	if m, err := repo.GetMembership(ctx, roomID, userIDs[1]); err != nil || m.Role != models.RoleOwner {
+		t.Errorf("Expected user %d to own the room, got %+v, %v", userIDs[1], m, err)
+	}
+
+	waitlist, err := repo.Waitlist(ctx, roomID)
+	if err != nil {
+		t.Fatalf("Waitlist() error = %v", err)
+	}
+	if len(waitlist) != 1 || waitlist[0].UserID != userIDs[2] || waitlist[0].Position != 1 {
+		t.Errorf("Expected only user %d left in the queue, got %+v", userIDs[2], waitlist)
+	}
+}
+
+func TestUserRepository_DeleteFreesSeats(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	repo := NewRoomRepository(db)
+	published := &recorder{}
+	repo.SetEventPublisher(published)
+	users := NewUserRepository(db, repo)
+	groups := NewGroupRepository(db, repo)
+	ctx := context.Background()
+	roomID, userIDs := seedFullRoom(t, repo, users, 2, 5)
+
+	// A second full room the doomed user reaches through a group
+	groupRoom, err := repo.Create(ctx, &models.CreateRoomRequest{Name: "Group Room", Capacity: 1})
+	if err != nil {
+		t.Fatalf("Failed to create test room: %v", err)
+	}
+	group, err := groups.Create(ctx, &models.CreateGroupRequest{Name: "Team"})
+	if err != nil {
+		t.Fatalf("Failed to create test group: %v", err)
+	}
+	if _, err := groups.AddMember(ctx, group.ID, userIDs[1]); err != nil {
+		t.Fatalf("AddMember() error = %v", err)
+	}
+	if _, err := groups.AssignToRoom(ctx, groupRoom.ID, group.ID); err != nil {
+		t.Fatalf("AssignToRoom() error = %v", err)
+	}
+
+	for _, id := range userIDs[2:] {
+		if _, err := repo.JoinWaitlist(ctx, roomID, id); err != nil {
+			t.Fatalf("JoinWaitlist() error = %v", err)
+		}
+	}
+	if _, err := repo.JoinWaitlist(ctx, groupRoom.ID, userIDs[4]); err != nil {
+		t.Fatalf("JoinWaitlist() error = %v", err)
+	}
+
+	// Deleting a seated user gives their seats to the heads of both queues
+	if err := users.Delete(ctx, userIDs[1], 0); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	if len(published.events) != 2 {
+		t.Fatalf("Expected 2 events, got %+v", published.events)
+	}
+	if p := published.events[0].Data.(models.WaitlistPromotion); p.RoomID != roomID || p.UserID != userIDs[2] {
+		t.Errorf("Expected user %d to be promoted in room %d, got %+v", userIDs[2], roomID, p)
+	}
+	if p := published.events[1].Data.(models.WaitlistPromotion); p.RoomID != groupRoom.ID || p.UserID != userIDs[4] {
+		t.Errorf("Expected user %d to be promoted in room %d, got %+v", userIDs[4], groupRoom.ID, p)
+	}
+
+	// The deleted user no longer takes a seat in either room
+	for _, id := range []int64{roomID, groupRoom.ID} {
+		room, err := repo.GetRoomWithUsers(ctx, id)
+		if err != nil {
+			t.Fatalf("GetRoomWithUsers() error = %v", err)
+		}
+		for _, u := range room.Users {
+			if u.ID == userIDs[1] {
+				t.Errorf("Expected user %d to be gone from room %d", userIDs[1], id)
+			}
+		}
+	}
+	members, err := groups.Members(ctx, group.ID)
+	if err != nil {
+		t.Fatalf("Members() error = %v", err)
+	}
+	if len(members) != 0 {
+		t.Errorf("Expected an empty group, got %+v", members)
+	}
+
+	waitlist, err := repo.Waitlist(ctx, roomID)
+	if err != nil {
+		t.Fatalf("Waitlist() error = %v", err)
+	}
+	if len(waitlist) != 2 || waitlist[0].UserID != userIDs[3] {
+		t.Errorf("Expected user %d first in the queue, got %+v", userIDs[3], waitlist)
+	}
+}
+
+func TestRoomRepository_AssignUserToRoomWithWaitlist(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	repo := NewRoomRepository(db)
+	ctx := context.Background()
+	roomID, userIDs := seedFullRoom(t, repo, NewUserRepository(db, repo), 1, 3)
+
+	// A seat the queue has not taken yet must not go to a direct assignment
+	if _, err := repo.JoinWaitlist(ctx, roomID, userIDs[1]); err != nil {
+		t.Fatalf("JoinWaitlist() error = %v", err)
+	}
+	if _, err := db.Exec(`UPDATE rooms SET capacity = 2 WHERE id = ?`, roomID); err != nil {
+		t.Fatalf("Failed to free a seat: %v", err)
+	}
+
+	if _, err := repo.AssignUserToRoom(ctx, userIDs[2], roomID, ""); !errors.Is(err, ErrRoomFull) {
+		t.Errorf("Expected ErrRoomFull while users are waiting, got %v", err)
+	}
+}
+
+func TestRoomRepository_PromotionOnDelete(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	repo := NewRoomRepository(db)
+	published := &recorder{}
+	repo.SetEventPublisher(published)
+	ctx := context.Background()
+	roomID, userIDs := seedFullRoom(t, repo, NewUserRepository(db, repo), 1, 2)
+
+	if _, err := repo.JoinWaitlist(ctx, roomID, userIDs[1]); err != nil {
+		t.Fatalf("JoinWaitlist() error = %v", err)
+	}
+
+	// The DELETE alone promotes, as if the process stopped right after it
+	if _, err := db.Exec(`DELETE FROM user_rooms WHERE user_id = ?`, userIDs[0]); err != nil {
+		t.Fatalf("Failed to free a seat: %v", err)
+	}
+	if m, err := repo.GetMembership(ctx, roomID, userIDs[1]); err != nil || m.Role != models.RoleOwner {
+		t.Fatalf("Expected user %d to own the room, got %+v, %v", userIDs[1], m, err)
+	}
+	if waitlist, _ := repo.Waitlist(ctx, roomID); len(waitlist) != 0 {
+		t.Errorf("Expected an empty waitlist, got %+v", waitlist)
+	}
+
+	// The event is published by the next change to the room's seats, and only once
+	for i := 0; i < 2; i++ {
+		if _, err := repo.promoteWaitlisted(ctx, roomID); err != nil {
+			t.Fatalf("promoteWaitlisted() error = %v", err)
+		}
+	}
+	if len(published.events) != 1 || published.events[0].Data.(models.WaitlistPromotion).UserID != userIDs[1] {
+		t.Errorf("Expected one event promoting user %d, got %+v", userIDs[1], published.events)
+	}
+}
+
+func TestRoomRepository_AssignOrWaitlist(t *testing.T) {
+	db := setupTestDBWithRooms(t)
+	defer db.Close()
+
+	repo := NewRoomRepository(db)
+	ctx := context.Background()
+	roomID, userIDs := seedFullRoom(t, repo, NewUserRepository(db, repo), 1, 3)
+
+	membership, entry, err := repo.AssignOrWaitlist(ctx, userIDs[1], roomID, models.RoleModerator)
+	if err != nil {
+		t.Fatalf("AssignOrWaitlist() error = %v", err)
+	}
+	if membership != nil || entry == nil || entry.Status != models.WaitlistStatusWaiting || entry.Position != 1 {
+		t.Fatalf("Expected a waiting entry at position 1, got %+v and %+v", membership, entry)
+	}
+	if _, _, err := repo.AssignOrWaitlist(ctx, userIDs[1], roomID, ""); !errors.Is(err, ErrAlreadyWaitlisted) {
+		t.Errorf("Expected ErrAlreadyWaitlisted, got %v", err)
+	}
+
+	// The queued user takes the freed seat as a member
+	if err := repo.RemoveUserFromRoom(ctx, userIDs[0], roomID); err != nil {
+		t.Fatalf("RemoveUserFromRoom() error = %v", err)
+	}
+	promoted, err := repo.GetMembership(ctx, roomID, userIDs[1])
+	if err != nil {
+		t.Fatalf("Expected the queued user to be promoted: %v", err)
+	}
+	if promoted.Role != models.RoleOwner {
+		t.Errorf("Expected the promoted user to own the emptied room, got role %s", promoted.Role)
+	}
+
+	room, _ := repo.Create(ctx, &models.CreateRoomRequest{Name: "Open Room", Capacity: 2})
+	membership, entry, err = repo.AssignOrWaitlist(ctx, userIDs[2], room.ID, models.RoleModerator)
+	if err != nil || entry != nil || membership == nil || membership.Role != models.RoleModerator {
+		t.Errorf("Expected a moderator membership in a room with free seats, got %+v, %+v, %v", membership, entry, err)
+	}
+}
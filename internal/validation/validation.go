@@ -0,0 +1,194 @@
+// Package validation checks request payloads against rules declared in `validate` struct tags:
+//
+//	Email string `json:"email" validate:"required,email,max=254"`
+//
+// Rules run in order and each field reports its first failing rule, so a payload yields at most
+// one error per field. Fields are named after their JSON keys.
+//
+// Rules:
+//   - required: the value is not empty (zero value or nil pointer) and not a blank string
+//   - omitempty: skip the remaining rules when the value is empty
+//   - min=N, max=N: bounds a number's value, a string's length in characters, or a slice's length
+//   - email: a bare email address such as jane@example.com
+//   - oneof=a b c: one of the space-separated values
+//
+// Fields implementing Optional, such as the members of a merge patch, are only checked when present.
+// A present value is never empty, so required only rejects blank strings; null fails required with
+// "must not be null" and skips the other rules.
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldError describes why a single request field was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Validator is implemented by payloads with checks that field rules cannot express, such as
+// rules on slice elements. Validate runs after the field rules and its errors are appended.
+type Validator interface {
+	Validate() []FieldError
+}
+
+// Optional is implemented by fields that may be left out of a payload or sent as null
+type Optional interface {
+	// Present returns the value, nil when it is null, and whether the field was present
+	Present() (any, bool)
+}
+
+// Struct validates the struct v points to, or is, and returns every rejected field
+func Struct(v any) []FieldError {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validation: %T is not a struct", v))
+	}
+
+	var errs []FieldError
+	checkFields(rv, &errs)
+	validator, ok := rv.Interface().(Validator)
+	if !ok && rv.CanAddr() {
+		validator, ok = rv.Addr().Interface().(Validator)
+	}
+	if ok {
+		errs = append(errs, validator.Validate()...)
+	}
+	return errs
+}
+
+// checkFields appends the errors of the tagged fields of rv, including those of embedded structs
+func checkFields(rv reflect.Value, errs *[]FieldError) {
+	t := rv.Type()
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			checkFields(rv.Field(i), errs)
+			continue
+		}
+
+		rules, ok := f.Tag.Lookup("validate")
+		if !ok || !f.IsExported() {
+			continue
+		}
+		if msg := check(rv.Field(i), rules); msg != "" {
+			*errs = append(*errs, FieldError{Field: fieldName(f), Message: msg})
+		}
+	}
+}
+
+// fieldName returns the JSON key of f
+func fieldName(f reflect.StructField) string {
+	if name, _, _ := strings.Cut(f.Tag.Get("json"), ","); name != "" && name != "-" {
+		return name
+	}
+	return f.Name
+}
+
+// check runs rules against v and returns the message of the first failing rule, or "" when v is valid
+func check(v reflect.Value, rules string) string {
+	if opt, ok := v.Interface().(Optional); ok {
+		return checkOptional(opt, rules)
+	}
+
+	empty := v.IsZero()
+	if v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+		empty = v.IsZero()
+	}
+	return checkRules(v, empty, rules)
+}
+
+// checkOptional runs rules against the value of a present optional field
+func checkOptional(opt Optional, rules string) string {
+	value, present := opt.Present()
+	if !present {
+		return ""
+	}
+	if value != nil {
+		return checkRules(reflect.ValueOf(value), false, rules)
+	}
+
+	for _, rule := range strings.Split(rules, ",") {
+		switch rule {
+		case "required":
+			return "must not be null"
+		case "omitempty":
+			return ""
+		}
+	}
+	return ""
+}
+
+// checkRules runs rules against v, which is skipped by omitempty and rejected by required when empty
+func checkRules(v reflect.Value, empty bool, rules string) string {
+	for _, rule := range strings.Split(rules, ",") {
+		name, arg, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			if empty || v.Kind() == reflect.String && strings.TrimSpace(v.String()) == "" {
+				return "is required"
+			}
+		case "omitempty":
+			if empty {
+				return ""
+			}
+		case "min", "max":
+			if msg := checkBound(v, name, arg); msg != "" {
+				return msg
+			}
+		case "email":
+			if addr, err := mail.ParseAddress(v.String()); err != nil || addr.Address != v.String() || addr.Name != "" {
+				return "must be a valid email address"
+			}
+		case "oneof":
+			options := strings.Fields(arg)
+			if !slices.Contains(options, v.String()) {
+				return "must be one of " + strings.Join(options, ", ")
+			}
+		default:
+			panic("validation: unknown rule " + rule)
+		}
+	}
+	return ""
+}
+
+// checkBound applies a min or max rule to a number, string or slice
+func checkBound(v reflect.Value, rule, arg string) string {
+	bound, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		panic("validation: invalid bound " + rule + "=" + arg)
+	}
+
+	var n float64
+	var unit string
+	switch v.Kind() {
+	case reflect.String:
+		n, unit = float64(utf8.RuneCountInString(v.String())), " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		n, unit = float64(v.Len()), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		n = v.Float()
+	default:
+		panic("validation: " + rule + " does not apply to " + v.Kind().String())
+	}
+
+	if rule == "min" && n < bound {
+		return "must be at least " + arg + unit
+	}
+	if rule == "max" && n > bound {
+		return "must be at most " + arg + unit
+	}
+	return ""
+}
@@ -0,0 +1,128 @@
+package validation
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type base struct {
+	ID int64 `json:"id" validate:"required"`
+}
+
+type payload struct {
+	base
+	Email    string   `json:"email" validate:"required,email,max=30"`
+	Name     *string  `json:"name,omitempty" validate:"omitempty,max=5"`
+	Count    int      `json:"count" validate:"min=1,max=10"`
+	Role     string   `json:"role,omitempty" validate:"omitempty,oneof=owner member"`
+	Tags     []string `json:"tags" validate:"max=2"`
+	Untagged string   `json:"untagged"`
+}
+
+type withCheck struct {
+	Value string `json:"value" validate:"required"`
+}
+
+func (w withCheck) Validate() []FieldError {
+	if w.Value == "bad" {
+		return []FieldError{{Field: "value", Message: "must not be bad"}}
+	}
+	return nil
+}
+
+type optional struct {
+	value any
+	set   bool
+}
+
+func (o optional) Present() (any, bool) {
+	return o.value, o.set
+}
+
+type patch struct {
+	Name     optional `json:"name" validate:"required,max=5"`
+	Count    optional `json:"count" validate:"required,min=1"`
+	Note     optional `json:"note" validate:"max=3"`
+	Optional optional `json:"optional" validate:"omitempty,required"`
+}
+
+func TestStruct(t *testing.T) {
+	long := "Longer than five"
+	short := "Jo"
+
+	tests := []struct {
+		name    string
+		payload any
+		want    []FieldError
+	}{
+		{
+			name:    "valid",
+			payload: &payload{base: base{ID: 1}, Email: "jane@example.com", Name: &short, Count: 3, Role: "owner", Tags: []string{"a"}},
+		},
+		{
+			name:    "every failing field is reported",
+			payload: payload{Role: "admin", Tags: []string{"a", "b", "c"}, Name: &long},
+			want: []FieldError{
+				{Field: "id", Message: "is required"},
+				{Field: "email", Message: "is required"},
+				{Field: "name", Message: "must be at most 5 characters"},
+				{Field: "count", Message: "must be at least 1"},
+				{Field: "role", Message: "must be one of owner, member"},
+				{Field: "tags", Message: "must be at most 2 items"},
+			},
+		},
+		{
+			name:    "malformed email",
+			payload: payload{base: base{ID: 1}, Email: "Jane <jane@example.com>", Count: 11},
+			want: []FieldError{
+				{Field: "email", Message: "must be a valid email address"},
+				{Field: "count", Message: "must be at most 10"},
+			},
+		},
+		{
+			name:    "length counts characters",
+			payload: payload{base: base{ID: 1}, Email: strings.Repeat("é", 20) + "@example.com", Count: 1},
+			want:    []FieldError{{Field: "email", Message: "must be at most 30 characters"}},
+		},
+		{
+			name:    "blank strings are missing",
+			payload: withCheck{Value: "  "},
+			want:    []FieldError{{Field: "value", Message: "is required"}},
+		},
+		{
+			name:    "validator runs after field rules",
+			payload: &withCheck{Value: "bad"},
+			want:    []FieldError{{Field: "value", Message: "must not be bad"}},
+		},
+		{
+			name:    "absent optional fields are skipped",
+			payload: patch{},
+		},
+		{
+			name:    "present optional values are checked but never empty",
+			payload: patch{Name: optional{"  ", true}, Count: optional{0, true}, Note: optional{"long", true}},
+			want: []FieldError{
+				{Field: "name", Message: "is required"},
+				{Field: "count", Message: "must be at least 1"},
+				{Field: "note", Message: "must be at most 3 characters"},
+			},
+		},
+		{
+			name:    "null fails required only",
+			payload: patch{Name: optional{nil, true}, Count: optional{nil, true}, Note: optional{nil, true}, Optional: optional{nil, true}},
+			want: []FieldError{
+				{Field: "name", Message: "must not be null"},
+				{Field: "count", Message: "must not be null"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Struct(tt.payload); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Struct() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -5,6 +5,6 @@ import (
 )
 
 func main() {
-	fmt.Println("Please use 'go run cmd/api/main.go' to start the API server")
-	fmt.Println("Or build with: go build -o bin/api cmd/api/main.go")
+	fmt.Println("Please use 'go run ./cmd/api' to start the API server")
+	fmt.Println("Or build with: go build -o bin/api ./cmd/api")
 }
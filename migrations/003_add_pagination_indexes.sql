@@ -0,0 +1,7 @@
+-- Migration: Add pagination indexes
+-- Created: 2026-10-16
+-- Description: Composite (created_at, id) indexes backing keyset pagination of users and rooms
+
+CREATE INDEX IF NOT EXISTS idx_users_created_at_id ON users(created_at DESC, id DESC);
+
+CREATE INDEX IF NOT EXISTS idx_rooms_created_at_id ON rooms(created_at DESC, id DESC);
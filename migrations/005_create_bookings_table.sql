@@ -0,0 +1,22 @@
+-- Migration: Create bookings table
+-- Created: 2026-10-16
+-- Description: Time-slotted room reservations; starts_at/ends_at are UTC text (YYYY-MM-DDTHH:MM:SSZ) and ends_at is exclusive
+
+CREATE TABLE IF NOT EXISTS bookings (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    room_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    title TEXT NOT NULL DEFAULT '',
+    starts_at DATETIME NOT NULL,
+    ends_at DATETIME NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (room_id) REFERENCES rooms(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CHECK (ends_at > starts_at)
+);
+
+-- Overlap checks and window queries scan a room's bookings by start time
+CREATE INDEX IF NOT EXISTS idx_bookings_room_starts_at ON bookings(room_id, starts_at);
+
+-- Create index on user_id for the foreign key
+CREATE INDEX IF NOT EXISTS idx_bookings_user_id ON bookings(user_id);
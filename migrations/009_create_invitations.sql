@@ -0,0 +1,44 @@
+-- Migration: Create invitations
+-- Created: 2026-10-16
+-- Description: Room invitations (by user ID or email, with a hashed token and optional UTC expiry) and join requests
+
+CREATE TABLE IF NOT EXISTS room_invitations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    room_id INTEGER NOT NULL,
+    invited_by INTEGER NOT NULL,
+    user_id INTEGER,
+    email TEXT,
+    role TEXT NOT NULL DEFAULT 'member' CHECK (role IN ('owner', 'moderator', 'member')),
+    token_hash TEXT NOT NULL UNIQUE,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined')),
+    expires_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    responded_at DATETIME,
+    FOREIGN KEY (room_id) REFERENCES rooms(id) ON DELETE CASCADE,
+    FOREIGN KEY (invited_by) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CHECK ((user_id IS NULL) != (email IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_room_invitations_room_id ON room_invitations(room_id);
+CREATE INDEX IF NOT EXISTS idx_room_invitations_user_id ON room_invitations(user_id);
+CREATE INDEX IF NOT EXISTS idx_room_invitations_email ON room_invitations(email);
+CREATE INDEX IF NOT EXISTS idx_room_invitations_invited_by ON room_invitations(invited_by);
+
+CREATE TABLE IF NOT EXISTS room_join_requests (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    room_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    message TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    reviewed_by INTEGER,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    reviewed_at DATETIME,
+    FOREIGN KEY (room_id) REFERENCES rooms(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (reviewed_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- A user has at most one pending request per room
+CREATE UNIQUE INDEX IF NOT EXISTS idx_room_join_requests_pending ON room_join_requests(room_id, user_id) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_room_join_requests_user_id ON room_join_requests(user_id);
@@ -0,0 +1,8 @@
+-- Migration: Dequeue seated users
+-- Created: 2026-10-16
+-- Description: Remove a user from a room's waitlist in the same statement that gives them a seat in it
+
+-- Every insert into user_rooms, including a waitlist promotion, drops the matching queue entry
+CREATE TRIGGER IF NOT EXISTS user_rooms_dequeue_after_insert AFTER INSERT ON user_rooms BEGIN
+    DELETE FROM room_waitlist WHERE room_id = new.room_id AND user_id = new.user_id;
+END;
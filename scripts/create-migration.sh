@@ -68,8 +68,9 @@ main() {
     # Generate filename
     migration_number=$(get_next_number)
     snake_case_desc=$(to_snake_case "$description")
-    filename="${migration_number}_${snake_case_desc}.sql"
+    filename="${migration_number}_${snake_case_desc}.up.sql"
     filepath="$MIGRATIONS_DIR/$filename"
+    down_filepath="$MIGRATIONS_DIR/${migration_number}_${snake_case_desc}.down.sql"
 
     # Create migration file
     cat > "$filepath" << EOF
@@ -96,14 +97,30 @@ main() {
 -- 1. Always use IF NOT EXISTS for idempotency
 -- 2. Create indexes for foreign keys and frequently queried columns
 -- 3. Test locally before running in production
+EOF
+
+    # Create rollback file
+    cat > "$down_filepath" << EOF
+-- Rollback: $description
+-- Created: $(date +%Y-%m-%d)
+-- Description: Reverts ${filename}
+
+-- Add the statements that undo the up migration here
+
+-- Example: Drop a table
+-- DROP TABLE IF EXISTS table_name;
+
+-- Example: Drop a column
+-- ALTER TABLE users DROP COLUMN new_column;
 EOF
 
     print_info "Created migration file: $filepath"
+    print_info "Created rollback file: $down_filepath"
     echo ""
     echo "Next steps:"
     echo "  1. Edit the migration file and add your SQL statements"
     echo "  2. Test locally: sqlite3 test.db < $filepath"
-    echo "  3. Run in app: go run cmd/api/main.go"
+    echo "  3. Run in app: go run ./cmd/api"
     echo "  4. Deploy to D1: ./scripts/run-migrations.sh remote"
     echo ""
 }
@@ -60,7 +60,7 @@ check_migrations_dir() {
     fi
 
     # Count migration files
-    migration_count=$(find "$MIGRATIONS_DIR" -name "*.sql" -type f | wc -l)
+    migration_count=$(find "$MIGRATIONS_DIR" -name "*.sql" ! -name "*.down.sql" -type f | wc -l)
     if [ "$migration_count" -eq 0 ]; then
         print_warning "No migration files found in $MIGRATIONS_DIR"
         exit 0
@@ -142,7 +142,8 @@ main() {
 
     # Find all migration files and sort them
     print_info "Collecting migration files..."
-    mapfile -t migrations < <(find "$MIGRATIONS_DIR" -name "*.sql" -type f | sort)
+    # Rollback files (*.down.sql) are only run by the application's Rollback/MigrateTo
+    mapfile -t migrations < <(find "$MIGRATIONS_DIR" -name "*.sql" ! -name "*.down.sql" -type f | sort)
 
     # Run migrations
     echo ""